@@ -1,6 +1,7 @@
 package bayesiannetwork
 
 import (
+	"context"
 	"geneticalgorithm"
 	"math"
 	"math/rand"
@@ -18,28 +19,44 @@ func NewBayesianNetwork() *BayesianNetwork {
 	return &BayesianNetwork{Nodes: make([]*Node, 0)}
 }
 
-// add a directed edge between Nodes p and c
-func (net BayesianNetwork) AddEdge(p, c *Node) {
+// add a directed edge between Nodes p and c.  A CPD of c that fit its
+// Parents no longer does and becomes stale; InsertEdge checks for cycles
+// and updates the CPD.
+func (net *BayesianNetwork) AddEdge(p, c *Node) {
+	fitted := c.fitted() && validateCPD(c) == nil
 	c.Parents = append(c.Parents, p)
 	p.Children = append(p.Children, c)
+	c.markStale(fitted)
+}
+
+// A directed edge of the network
+type Edge struct {
+	Parent *Node
+	Child  *Node
+}
+
+// list the edges of the net, ordered by the parent's position in the net
+func (net BayesianNetwork) Edges() []Edge {
+	edges := make([]Edge, 0)
+	for _, n := range net.Nodes {
+		for _, child := range n.Children {
+			edges = append(edges, Edge{Parent: n, Child: child})
+		}
+	}
+	return edges
 }
 
 // Sample from the bayesian net returns a []int of the
 // original index sampled from all Nodes in the net in order
-// that the Nodes appear in the net
+// that the Nodes appear in the net.  Panics if a CPD is stale; check
+// StaleNodes first.
 func (net BayesianNetwork) Sample(r *rand.Rand) (sample map[*Node]int) {
 	sample = make(map[*Node]int)
 	for _, n := range net.Nodes {
-		parent_sample := make(map[*Node]int)
-		if len(n.Parents) == 0 {
-			sample[n] = n.cpd[0].sample(r)
+		if n.stale {
+			panic(staleError(n))
 		}
-		for _, parent := range n.Parents {
-			parent_sample[parent] = sample[parent]
-		}
-		cpdIndex := n.getCPDIndex(parent_sample)
-
-		sample[n] = n.cpd[cpdIndex].sample(r)
+		sample[n] = n.sample(r, sample)
 	}
 
 	return sample
@@ -95,6 +112,41 @@ func (net *BayesianNetwork) topologicalSort() (hasCycles bool) {
 	return hasCycles
 }
 
+// topological sort that changes the order of the net as little as
+// possible -- returns whether the net has cycles.  Each Node is placed after
+// its Parents, so a net that is already sorted keeps its order.  Unlike
+// topologicalSort no Node is dropped: the Nodes of a cycle are ordered as if
+// one of its edges were missing, and Parents outside the net are ignored.
+func (net *BayesianNetwork) stableTopologicalSort() (hasCycles bool) {
+	in := make(map[*Node]bool, len(net.Nodes))
+	for _, n := range net.Nodes {
+		in[n] = true
+	}
+	sorted := make([]*Node, 0, len(net.Nodes))
+	// onPath is true for the Nodes being placed and false once they are
+	onPath := make(map[*Node]bool, len(net.Nodes))
+	var place func(n *Node)
+	place = func(n *Node) {
+		if placing, seen := onPath[n]; seen {
+			hasCycles = hasCycles || placing
+			return
+		}
+		onPath[n] = true
+		for _, p := range n.Parents {
+			if in[p] {
+				place(p)
+			}
+		}
+		onPath[n] = false
+		sorted = append(sorted, n)
+	}
+	for _, n := range net.Nodes {
+		place(n)
+	}
+	net.Nodes = sorted
+	return hasCycles
+}
+
 // Tarjan's algorithm for topological sort
 func (net *BayesianNetwork) topologicalSortTarjan() {
 	if HasCycles(net) {
@@ -149,14 +201,34 @@ func (net BayesianNetwork) logicSampling(n_samples int, r *rand.Rand) []map[*Nod
 }
 
 // calculate the probabiliy of a slice of Node States given a
-// map of evidence States
+// map of evidence States.  Only the sub-network and evidence left by Prune
+// are sampled.  Panics if one of their CPDs is stale; SampledPosterior
+// returns the error instead.
 func (net BayesianNetwork) PosteriorDistribution(
 	r *rand.Rand,
 	dependent []*Node,
 	evidence map[*Node]int) map[*Node]Density {
 
+	densities, err := net.SampledPosterior(r, dependent, evidence)
+	if err != nil {
+		panic(err)
+	}
+	return densities
+}
+
+// PosteriorDistribution, but returns an error if a CPD it samples is stale
+func (net BayesianNetwork) SampledPosterior(
+	r *rand.Rand,
+	dependent []*Node,
+	evidence map[*Node]int) (map[*Node]Density, error) {
+
 	// TODO: this is a bad heuristic
-	samples := net.logicSampling(len(net.Nodes)*100, r)
+	n_samples := len(net.Nodes) * 100
+	pruned, evidence := net.Prune(dependent, evidence)
+	if err := pruned.checkStale(); err != nil {
+		return nil, err
+	}
+	samples := pruned.logicSampling(n_samples, r)
 
 	// collect the samples that meet the evidence
 	keep := make([]int, 0)
@@ -194,7 +266,7 @@ func (net BayesianNetwork) PosteriorDistribution(
 		}
 		densities[Node] = NewDensity(probs...)
 	}
-	return densities
+	return densities, nil
 }
 
 // Calculate the model Likelihood for each of a set of
@@ -211,13 +283,7 @@ func (net BayesianNetwork) Likelihood(observations []map[*Node]int) []float64 {
 			defer wg.Done()
 
 			for _, n := range net.Nodes {
-				State := instance[n]
-				parentStates := make(map[*Node]int)
-				for _, parent := range n.Parents {
-					parentStates[parent] = instance[parent]
-				}
-				cpd_index := n.getCPDIndex(parentStates)
-				likelihoods[index] *= n.cpd[cpd_index].StateMap[State]
+				likelihoods[index] *= n.conditionalProbability(instance)
 			}
 		}()
 		wg.Wait()
@@ -237,53 +303,86 @@ func (net BayesianNetwork) ModelLikelihood(observations []map[*Node]int) float64
 
 // take a slice of observed Node States and update the CPDs of all Nodes
 func (net *BayesianNetwork) updateWeights(observations []map[*Node]int) {
+	net.updateWeightsSmoothed(observations, 0)
+}
+
+// update the CPDs of all Nodes, adding alpha pseudo-observations of every
+// State (Laplace smoothing when alpha is 1).  Parent States that are never
+// observed get a uniform distribution.
+func (net *BayesianNetwork) updateWeightsSmoothed(
+	observations []map[*Node]int,
+	alpha float64) {
+
+	net.fitDataset(datasetOf(net.Nodes, observations), alpha)
+}
+
+// update the CPDs of all Nodes from the Dataset, with alpha
+// pseudo-observations of every State.  Rows where the Node or one of its
+// Parents is Missing are ignored for that Node.
+func (net *BayesianNetwork) fitDataset(ds *Dataset, alpha float64) {
 	// assuming that the Nodes are in topological order
 	net.topologicalSort()
 
 	for _, n := range net.Nodes {
-		// get all unique parent States from the observations
-		parentStateNums := make([]int, 0)
-		for _, p := range n.Parents {
-			parentStateNums = append(parentStateNums, p.States)
-		}
+		n.fit(ds, alpha)
+	}
+}
 
-		current := make([]int, 0)
-		States := make([][]int, 0)
-		n.getAllParentStates(&States, current, parentStateNums...)
-		n.cpd = make([]Density, len(States))
-
-		// for each unique parent State, filter out the observations that
-		// match and update the CPD
-		for _, State := range States {
-			// get the cpd index of this State
-			nodeStates := make(map[*Node]int)
-			for i, p := range n.Parents {
-				nodeStates[p] = State[i]
-			}
-			cpdIndex := n.getCPDIndex(nodeStates)
-
-			// filter out the observations that don't match this State
-			total := 0.0
-			sums := make([]float64, n.States)
-			for _, observation := range observations {
-				pass := true
-				for n, s := range nodeStates {
-					if observation[n] != s {
-						pass = false
-					}
-				}
-				if pass {
-					sums[observation[n]] += 1.0
-					total += 1.0
+// update the CPD of the Node from the Dataset
+func (n *Node) fit(ds *Dataset, alpha float64) {
+	if n.CPD != nil {
+		n.CPD.Fit(n, ds, alpha)
+		n.stale = false
+		return
+	}
+	table := &TableCPD{}
+	table.Fit(n, ds, alpha)
+	n.cpd = table.Densities
+	n.stale = false
+}
+
+// the log likelihood of the Dataset, summed from the counts of each Node and
+// its Parents
+func (net BayesianNetwork) datasetLikelihood(ds *Dataset) float64 {
+	ll := 0.0
+	for _, n := range net.Nodes {
+		if n.CPD != nil {
+			for r := 0; r < ds.Len(); r++ {
+				if row := ds.Row(r); n.observed(row) {
+					ll += math.Log(n.conditionalProbability(row))
 				}
 			}
-			// calculate the probabilities
-			for i := 0; i < len(sums); i++ {
-				sums[i] = sums[i] / total
+			continue
+		}
+		counts := ds.Counts(append([]*Node{n}, n.Parents...)...)
+		for index, count := range counts.Values {
+			if count > 0 {
+				cpdIndex, state := index/n.States, index%n.States
+				ll += count * math.Log(n.cpd[cpdIndex].StateMap[state])
 			}
-			n.cpd[cpdIndex] = NewDensity(sums...)
 		}
 	}
+	return ll
+}
+
+// build a network over the Nodes of the Dataset with the given edges and fit
+// its CPDs with alpha pseudo-observations per State.  Any topology already on
+// the Nodes is discarded.
+func networkFromEdges(ds *Dataset, edges []Edge, alpha float64) *BayesianNetwork {
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, ds.Nodes...)
+	ds.inferNodeStates()
+	for _, n := range net.Nodes {
+		n.Children = make([]*Node, 0)
+		n.Parents = make([]*Node, 0)
+		n.cpd = make([]Density, 0)
+		n.CPD = nil
+	}
+	for _, e := range edges {
+		net.AddEdge(e.Parent, e.Child)
+	}
+	net.fitDataset(ds, alpha)
+	return net
 }
 
 // given observations, update the States and CPDs of the Nodes
@@ -319,16 +418,38 @@ func (net *BayesianNetwork) inferNodeStates(data []map[*Node]int) {
 
 // infer a bayesian net from a slice of map[*Node]int Node States
 func InferBayesianNetwork(data []map[*Node]int, iterations int) *BayesianNetwork {
+	net, _ := InferBayesianNetworkContext(context.Background(), data, iterations, nil)
+	return net
+}
+
+// infer a bayesian net, reporting every iteration of the search to observer
+// (which may be nil).  The search stops early when ctx is done or when the
+// observer returns false; in both cases the best network found so far is
+// returned, along with ctx.Err() if the context ended the search.
+func InferBayesianNetworkContext(
+	ctx context.Context,
+	data []map[*Node]int,
+	iterations int,
+	observer Observer) (*BayesianNetwork, error) {
+
+	return InferBayesianNetworkFromDataset(
+		ctx, DatasetFromInstances(data), iterations, observer)
+}
+
+// infer a bayesian net from the columns of a Dataset; see
+// InferBayesianNetworkContext
+func InferBayesianNetworkFromDataset(
+	ctx context.Context,
+	ds *Dataset,
+	iterations int,
+	observer Observer) (*BayesianNetwork, error) {
 
 	// set the order that the Nodes appear in the binary representation
-	// just assume that the first instance has all Nodes
-	nodeOrder := make([]*Node, 0)
-	for n, _ := range data[0] {
-		nodeOrder = append(nodeOrder, n)
-	}
+	nodeOrder := ds.Nodes
 
 	net := NewBayesianNetwork()
-	net.inferNodeStates(data)
+	net.Nodes = append(net.Nodes, ds.Nodes...)
+	ds.inferNodeStates()
 
 	mutateCheckFunction := func(edges []int) (ok bool) {
 		// make a net with the given topology
@@ -342,25 +463,25 @@ func InferBayesianNetwork(data []map[*Node]int, iterations int) *BayesianNetwork
 	// TODO: overfitting; use random subset for evaluation
 	scoreFunction := func(bits []int) float64 {
 		net.binaryToTopology(nodeOrder, bits)
-		net.inferNodeStates(data)
-		net.updateWeights(data)
-		return net.ModelLikelihood(data)
+		net.fitDataset(ds, 0)
+		return net.datasetLikelihood(ds)
 	}
 
-	best := geneticalgorithm.Evolve(
+	best, err := geneticalgorithm.EvolveContext(
+		ctx,
 		scoreFunction,
 		mutateCheckFunction,
-		len(data[0])*len(data[0]),
+		len(nodeOrder)*len(nodeOrder),
 		iterations,
-		false)
+		false,
+		progressObserver(observer, nodeOrder))
 
 	// the net is updated on every scoreFunction call, so we
 	// have to update to use the best topology here
 	net.binaryToTopology(nodeOrder, best)
-	net.inferNodeStates(data)
-	net.updateWeights(data)
+	net.fitDataset(ds, 0)
 
-	return net
+	return net, err
 }
 
 // TODO: Likelihood weighting
@@ -105,6 +105,28 @@ func TestTopologicalSortTarjan(t *testing.T) {
 	network.topologicalSortTarjan()
 }
 
+func TestStableTopologicalSort(t *testing.T) {
+	network := initStudentNetwork()
+	I, S, L, D, G := network.Nodes[0], network.Nodes[1], network.Nodes[2], network.Nodes[3], network.Nodes[4]
+	if network.stableTopologicalSort() {
+		t.Fatal("no cycles expected")
+	}
+	// L moves after G, which moves after D; the rest keep their order
+	if !sameNodes(network.Nodes, []*Node{I, S, D, G, L}) {
+		t.Fatal(network.Nodes)
+	}
+	network.stableTopologicalSort()
+	if !sameNodes(network.Nodes, []*Node{I, S, D, G, L}) {
+		t.Fatal(network.Nodes)
+	}
+
+	// the Nodes of a cycle are kept
+	network.AddEdge(L, I)
+	if !network.stableTopologicalSort() || len(network.Nodes) != 5 {
+		t.Fatal(network.Nodes)
+	}
+}
+
 func TestBayesianNetworkSample(t *testing.T) {
 	network := initStudentNetwork()
 	network.topologicalSort()
@@ -0,0 +1,472 @@
+package bayesiannetwork
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// interchange files round probabilities to a few digits; rows of a table
+// that sum to within this of 1 are normalized when they are read
+const roundingTolerance = 1e-3
+
+// the probabilities of n for each joint State of its Parents, the first
+// Parent's State varying fastest, from any CPD
+func probabilityRows(n *Node) ([][]float64, error) {
+	if n.CPD == nil && len(n.cpd) == 0 {
+		return nil, fmt.Errorf("bayesiannetwork: node %q has no CPD", n.Name)
+	}
+	f := TableFactor(n, n.distribution())
+	rows := make([][]float64, len(f.Values)/n.States)
+	for j := range rows {
+		rows[j] = f.Values[j*n.States : (j+1)*n.States]
+	}
+	return rows, nil
+}
+
+// set a table CPD of n from its rows, normalizing rows lost to rounding
+func setRows(n *Node, rows [][]float64) {
+	n.CPD, n.stale = nil, false
+	n.cpd = make([]Density, len(rows))
+	for j, probs := range rows {
+		total := 0.0
+		for _, p := range probs {
+			total += p
+		}
+		if total > 0 && math.Abs(total-1) <= roundingTolerance {
+			for s := range probs {
+				probs[s] /= total
+			}
+		}
+		n.cpd[j] = NewDensity(probs...)
+	}
+}
+
+// the index of the joint States of the nodes with the last Node's State
+// varying fastest, as in BIF and XMLBIF tables
+func lexicographicIndex(nodes []*Node, states []int) int {
+	index := 0
+	for i, n := range nodes {
+		index = index*n.States + states[i]
+	}
+	return index
+}
+
+// the rows of n from a flat table in which the State of n varies fastest,
+// then the State of its last Parent, as in XMLBIF, Hugin and GeNIe
+func rowsFromFlat(n *Node, values []float64) [][]float64 {
+	configurations := NewFactor(n.Parents...)
+	rows := make([][]float64, len(configurations.Values))
+	for j := range rows {
+		k := lexicographicIndex(n.Parents, configurations.states(j))
+		rows[j] = append([]float64{}, values[k*n.States:(k+1)*n.States]...)
+	}
+	return rows
+}
+
+// the flat table of the rows of n, the inverse of rowsFromFlat
+func flatFromRows(n *Node, rows [][]float64) []float64 {
+	configurations := NewFactor(n.Parents...)
+	values := make([]float64, n.States*len(rows))
+	for j, probs := range rows {
+		k := lexicographicIndex(n.Parents, configurations.states(j))
+		copy(values[k*n.States:], probs)
+	}
+	return values
+}
+
+// parse the probabilities of a table with a probability per State of n for
+// each joint State of its Parents
+func parseTable(n *Node, fields []string) ([]float64, error) {
+	size := n.States * len(NewFactor(n.Parents...).Values)
+	if len(fields) != size {
+		return nil, fmt.Errorf("table of %q has %d probabilities, expected %d", n.Name, len(fields), size)
+	}
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("table of %q has invalid probability %q", n.Name, field)
+		}
+		values[i] = p
+	}
+	return values, nil
+}
+
+// the State of n with the label, which may be the State's number when n
+// has no labels
+func stateOf(n *Node, label string) (int, bool) {
+	for s := 0; s < n.States; s++ {
+		if n.StateLabel(s) == label {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+func formatProbability(p float64) string {
+	return strconv.FormatFloat(p, 'g', -1, 64)
+}
+
+// the syntax of BIF files
+var bifSyntax = syntax{
+	format:       "bif",
+	punctuation:  "{}()[],;|",
+	lineComment:  "//",
+	blockComment: true}
+
+type bifParser struct {
+	parser
+	net    *BayesianNetwork
+	byName map[string]*Node
+	// the Nodes that have had a probability block
+	defined map[*Node]bool
+}
+
+// probabilities separated by commas up to a ';'
+func (p *bifParser) numbers() ([]float64, error) {
+	words, err := p.list(";", ",")
+	if err != nil {
+		return nil, err
+	}
+	probs := make([]float64, len(words))
+	for i, w := range words {
+		if probs[i], err = strconv.ParseFloat(w, 64); err != nil {
+			return nil, p.errorf("invalid probability %q", w)
+		}
+	}
+	return probs, nil
+}
+
+func (p *bifParser) variable() error {
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+	if _, exists := p.byName[name]; exists {
+		return p.errorf("duplicate variable %q", name)
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	n := &Node{Name: name}
+	for p.peek() != "}" {
+		text, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch text {
+		case "type":
+			if err := p.expect("discrete"); err != nil {
+				return err
+			}
+			if err := p.expect("["); err != nil {
+				return err
+			}
+			size, err := p.next()
+			if err != nil {
+				return err
+			}
+			if n.States, err = strconv.Atoi(size); err != nil || n.States <= 0 {
+				return p.errorf("invalid number of states %q for %q", size, name)
+			}
+			if err := p.expect("]"); err != nil {
+				return err
+			}
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if n.StateLabels, err = p.list("}", ","); err != nil {
+				return err
+			}
+			if len(n.StateLabels) != n.States {
+				return p.errorf("variable %q has %d states but %d values",
+					name, n.States, len(n.StateLabels))
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case "property":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			p.pos--
+			return p.errorf("unexpected %q in variable %q", text, name)
+		}
+	}
+	p.pos++
+	if n.States == 0 {
+		return p.errorf("variable %q has no type", name)
+	}
+	p.byName[name] = n
+	p.net.Nodes = append(p.net.Nodes, n)
+	return nil
+}
+
+// the Node declared with the name
+func (p *bifParser) node(name string) (*Node, error) {
+	n, exists := p.byName[name]
+	if !exists {
+		return nil, p.errorf("unknown variable %q", name)
+	}
+	return n, nil
+}
+
+func (p *bifParser) probability() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+	n, err := p.node(name)
+	if err != nil {
+		return err
+	}
+	if p.defined[n] {
+		return p.errorf("variable %q has two probability blocks", name)
+	}
+	p.defined[n] = true
+	names := []string{}
+	if p.peek() == "|" {
+		p.pos++
+		if names, err = p.list(")", ","); err != nil {
+			return err
+		}
+	} else if err := p.expect(")"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		parent, err := p.node(name)
+		if err != nil {
+			return err
+		}
+		if parent == n || containsNode(n.Parents, parent) {
+			return p.errorf("variable %q can't have %q as a parent", n.Name, name)
+		}
+		p.net.AddEdge(parent, n)
+	}
+
+	rows := make([][]float64, len(NewFactor(n.Parents...).Values))
+	var defaults []float64
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		text, err := p.next()
+		if err != nil {
+			return err
+		}
+		switch text {
+		case "table":
+			// the child's State varies slowest and the last Parent's fastest
+			probs, err := p.numbers()
+			if err != nil {
+				return err
+			}
+			if len(probs) != n.States*len(rows) {
+				return p.errorf("table of %q has %d probabilities, expected %d",
+					n.Name, len(probs), n.States*len(rows))
+			}
+			for j := range rows {
+				states := NewFactor(n.Parents...).states(j)
+				k := lexicographicIndex(n.Parents, states)
+				rows[j] = make([]float64, n.States)
+				for s := range rows[j] {
+					rows[j][s] = probs[s*len(rows)+k]
+				}
+			}
+		case "default":
+			if defaults, err = p.numbers(); err != nil {
+				return err
+			}
+		case "(":
+			labels, err := p.list(")", ",")
+			if err != nil {
+				return err
+			}
+			if len(labels) != len(n.Parents) {
+				return p.errorf("%q has %d parents but the row has %d states",
+					n.Name, len(n.Parents), len(labels))
+			}
+			states := make([]int, len(labels))
+			for i, label := range labels {
+				s, exists := stateOf(n.Parents[i], label)
+				if !exists {
+					return p.errorf("%q has no state %q", n.Parents[i].Name, label)
+				}
+				states[i] = s
+			}
+			if rows[tableIndex(n, states)], err = p.numbers(); err != nil {
+				return err
+			}
+		case "property":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			p.pos--
+			return p.errorf("unexpected %q in the probability of %q", text, n.Name)
+		}
+	}
+	p.pos++
+	for j := range rows {
+		if rows[j] == nil {
+			if defaults == nil {
+				return p.errorf("%q has no probabilities for some states of its parents", n.Name)
+			}
+			rows[j] = append([]float64{}, defaults...)
+		}
+	}
+	setRows(n, rows)
+	return nil
+}
+
+// read a network in the Bayesian Interchange Format (BIF 0.15) used by the
+// bnlearn repository, with the Nodes in topological order (otherwise in the
+// order the variables are declared) and the values of the variables as
+// State labels.  Properties are ignored.
+func ReadBIF(r io.Reader) (*BayesianNetwork, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(data), bifSyntax)
+	if err != nil {
+		return nil, err
+	}
+	p := &bifParser{
+		parser:  parser{tokens: tokens, syntax: bifSyntax},
+		net:     NewBayesianNetwork(),
+		byName:  make(map[string]*Node),
+		defined: make(map[*Node]bool)}
+	for !p.eof() {
+		text, _ := p.next()
+		switch text {
+		case "network":
+			if _, err := p.word(); err != nil {
+				return nil, err
+			}
+			err = p.skipBlock()
+		case "variable":
+			err = p.variable()
+		case "probability":
+			err = p.probability()
+		default:
+			p.pos--
+			err = p.errorf("unexpected %q", text)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range p.net.Nodes {
+		if !p.defined[n] {
+			return nil, fmt.Errorf("bayesiannetwork: bif variable %q has no probability", n.Name)
+		}
+	}
+	p.net.stableTopologicalSort()
+	if err := p.net.Validate(); err != nil {
+		return nil, err
+	}
+	return p.net, nil
+}
+
+// load a network from a BIF file
+func LoadBIF(path string) (*BayesianNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBIF(f)
+}
+
+// write the network in the Bayesian Interchange Format, with a row for
+// each joint State of a Node's Parents.  Any CPD is written as its table.
+// Names and State labels must be single BIF words.
+func (net BayesianNetwork) WriteBIF(w io.Writer) error {
+	if err := net.Validate(); err != nil {
+		return err
+	}
+	for _, n := range net.Nodes {
+		if !bifSyntax.isWord(n.Name) {
+			return fmt.Errorf("bayesiannetwork: node name %q can't be written in BIF", n.Name)
+		}
+		for s := 0; s < n.States; s++ {
+			if !bifSyntax.isWord(n.StateLabel(s)) {
+				return fmt.Errorf("bayesiannetwork: state %q of node %q can't be written in BIF",
+					n.StateLabel(s), n.Name)
+			}
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprint(b, "network unknown {\n}\n")
+	for _, n := range net.Nodes {
+		labels := make([]string, n.States)
+		for s := range labels {
+			labels[s] = n.StateLabel(s)
+		}
+		fmt.Fprintf(b, "variable %s {\n  type discrete [ %d ] { %s };\n}\n",
+			n.Name, n.States, strings.Join(labels, ", "))
+	}
+	for _, n := range net.Nodes {
+		rows, err := probabilityRows(n)
+		if err != nil {
+			return err
+		}
+		if len(n.Parents) == 0 {
+			fmt.Fprintf(b, "probability ( %s ) {\n  table %s;\n}\n", n.Name, joinProbabilities(rows[0], ", "))
+			continue
+		}
+		parents := make([]string, len(n.Parents))
+		for i, p := range n.Parents {
+			parents[i] = p.Name
+		}
+		fmt.Fprintf(b, "probability ( %s | %s ) {\n", n.Name, strings.Join(parents, ", "))
+		configurations := NewFactor(n.Parents...)
+		for j, probs := range rows {
+			labels := make([]string, len(n.Parents))
+			for i, s := range configurations.states(j) {
+				labels[i] = n.Parents[i].StateLabel(s)
+			}
+			fmt.Fprintf(b, "  (%s) %s;\n", strings.Join(labels, ", "), joinProbabilities(probs, ", "))
+		}
+		fmt.Fprint(b, "}\n")
+	}
+	return b.Flush()
+}
+
+// the probabilities separated by sep
+func joinProbabilities(probs []float64, sep string) string {
+	formatted := make([]string, len(probs))
+	for i, p := range probs {
+		formatted[i] = formatProbability(p)
+	}
+	return strings.Join(formatted, sep)
+}
+
+// save the network to a BIF file
+func (net BayesianNetwork) SaveBIF(path string) error {
+	return saveFile(path, net.WriteBIF)
+}
+
+// create the file and write it
+func saveFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
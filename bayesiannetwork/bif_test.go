@@ -0,0 +1,286 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the ASIA network as distributed by the bnlearn repository
+const asiaBIF = `network unknown {
+}
+variable asia {
+  type discrete [ 2 ] { yes, no };
+}
+variable tub {
+  type discrete [ 2 ] { yes, no };
+}
+variable smoke {
+  type discrete [ 2 ] { yes, no };
+}
+variable lung {
+  type discrete [ 2 ] { yes, no };
+}
+variable bronc {
+  type discrete [ 2 ] { yes, no };
+}
+variable either {
+  type discrete [ 2 ] { yes, no };
+}
+variable xray {
+  type discrete [ 2 ] { yes, no };
+}
+variable dysp {
+  type discrete [ 2 ] { yes, no };
+}
+probability ( asia ) {
+  table 0.01, 0.99;
+}
+probability ( tub | asia ) {
+  (yes) 0.05, 0.95;
+  (no) 0.01, 0.99;
+}
+probability ( smoke ) {
+  table 0.5, 0.5;
+}
+probability ( lung | smoke ) {
+  (yes) 0.1, 0.9;
+  (no) 0.01, 0.99;
+}
+probability ( bronc | smoke ) {
+  (yes) 0.6, 0.4;
+  (no) 0.3, 0.7;
+}
+probability ( either | lung, tub ) {
+  (yes, yes) 1.0, 0.0;
+  (no, yes) 1.0, 0.0;
+  (yes, no) 1.0, 0.0;
+  (no, no) 0.0, 1.0;
+}
+probability ( xray | either ) {
+  (yes) 0.98, 0.02;
+  (no) 0.05, 0.95;
+}
+probability ( dysp | bronc, either ) {
+  (yes, yes) 0.9, 0.1;
+  (no, yes) 0.7, 0.3;
+  (yes, no) 0.8, 0.2;
+  (no, no) 0.1, 0.9;
+}
+`
+
+func nodeNamed(net *BayesianNetwork, name string) *Node {
+	for _, n := range net.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+func checkSameNetwork(t *testing.T, a, b *BayesianNetwork) {
+	t.Helper()
+	if len(a.Nodes) != len(b.Nodes) {
+		t.Fatal(len(a.Nodes), len(b.Nodes))
+	}
+	for i, n := range a.Nodes {
+		m := b.Nodes[i]
+		if n.Name != m.Name || n.States != m.States || len(n.Parents) != len(m.Parents) {
+			t.Fatal(n.Name, m.Name)
+		}
+		for s := 0; s < n.States; s++ {
+			if n.StateLabel(s) != m.StateLabel(s) {
+				t.Fatal(n.Name, s)
+			}
+		}
+		for k, p := range n.Parents {
+			if p.Name != m.Parents[k].Name {
+				t.Fatal(n.Name, p.Name)
+			}
+		}
+		f, g := n.factor(), m.factor()
+		for k := range f.Values {
+			if math.Abs(f.Values[k]-g.Values[k]) > 1e-12 {
+				t.Fatal(n.Name, k, f.Values[k], g.Values[k])
+			}
+		}
+	}
+}
+
+func TestReadBIF(t *testing.T) {
+	network, err := ReadBIF(strings.NewReader(asiaBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 8 {
+		t.Fatal(len(network.Nodes))
+	}
+	either := nodeNamed(network, "either")
+	if either.Parents[0].Name != "lung" || either.Parents[1].Name != "tub" {
+		t.Fatal(either.Parents)
+	}
+	// P(dysp = yes | bronc = no, either = yes)
+	dysp := nodeNamed(network, "dysp")
+	if p := dysp.conditionalProbability(map[*Node]int{dysp: 0, dysp.Parents[0]: 1, dysp.Parents[1]: 0}); p != .7 {
+		t.Fatal(p)
+	}
+	// P(lung = yes | smoke = yes, xray = yes) = .098 / (.098 + .9 * .059672)
+	densities, err := network.ExactPosterior(
+		[]*Node{nodeNamed(network, "lung")},
+		map[*Node]int{nodeNamed(network, "smoke"): 0, nodeNamed(network, "xray"): 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := densities[nodeNamed(network, "lung")].StateMap[0]; math.Abs(p-.098/(.098+.9*.059672)) > 1e-12 {
+		t.Fatal(p)
+	}
+}
+
+func TestBIFChildFirst(t *testing.T) {
+	input := `network unknown {
+}
+variable wet {
+  type discrete [ 2 ] { yes, no };
+}
+variable rain {
+  type discrete [ 2 ] { yes, no };
+}
+probability ( wet | rain ) {
+  (yes) 0.9, 0.1;
+  (no) 0.2, 0.8;
+}
+probability ( rain ) {
+  table 0.3, 0.7;
+}
+`
+	network, err := ReadBIF(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rain, wet := network.Nodes[0], network.Nodes[1]
+	if rain.Name != "rain" || wet.Name != "wet" {
+		t.Fatal(network.Nodes)
+	}
+	// P(wet = yes) = .3 * .9 + .7 * .2
+	r := rand.New(rand.NewSource(1))
+	count := 0
+	for i := 0; i < 2000; i++ {
+		if network.Sample(r)[wet] == 0 {
+			count++
+		}
+	}
+	if p := float64(count) / 2000; math.Abs(p-.41) > .04 {
+		t.Fatal(p)
+	}
+}
+
+func TestBIFTableAndDefault(t *testing.T) {
+	data := `
+	// comments and properties are skipped
+	network "test" { property "x" ; }
+	variable a { type discrete [ 2 ] { t, f }; property position = (1, 2) ; }
+	variable b { type discrete [ 3 ] { x, y, z }; }
+	variable c { type discrete [ 2 ] { t, f }; }
+	probability ( a ) { table 0.2, 0.8; }
+	probability ( b ) { table 0.2, 0.3, 0.5; }
+	/* the child slowest,
+	   the last parent fastest */
+	probability ( c | a, b ) {
+	  table 0.1, 0.2, 0.3, 0.4, 0.5, 0.6,
+	        0.9, 0.8, 0.7, 0.6, 0.5, 0.4;
+	}`
+	network, err := ReadBIF(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	// a = f, b = y
+	if p := c.conditionalProbability(map[*Node]int{a: 1, b: 1, c: 0}); math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+	if p := c.conditionalProbability(map[*Node]int{a: 0, b: 2, c: 1}); math.Abs(p-.7) > 1e-12 {
+		t.Fatal(p)
+	}
+
+	data = `variable a { type discrete [ 2 ] { t, f }; }
+	variable b { type discrete [ 2 ] { t, f }; }
+	probability ( a ) { table 0.3333, 0.6667; }
+	probability ( b | a ) { default 0.5, 0.5; (t) 0.9, 0.1; }`
+	network, err = ReadBIF(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b = network.Nodes[0], network.Nodes[1]
+	if p := b.conditionalProbability(map[*Node]int{a: 1, b: 0}); p != .5 {
+		t.Fatal(p)
+	}
+	if p := b.conditionalProbability(map[*Node]int{a: 0, b: 0}); p != .9 {
+		t.Fatal(p)
+	}
+}
+
+func TestBIFErrors(t *testing.T) {
+	variables := "variable a { type discrete [ 2 ] { t, f }; }\n"
+	cases := map[string]string{
+		"syntax":    "variable a { type discrete [ 2 ] { t, f } }",
+		"unknown":   variables + "probability ( b ) { table 0.5, 0.5; }",
+		"parent":    variables + "probability ( a | b ) { (t) 0.5, 0.5; }",
+		"state":     variables + "variable b { type discrete [ 2 ] { t, f }; }\nprobability ( a ) { table 0.5, 0.5; }\nprobability ( b | a ) { (x) 0.5, 0.5; (f) 0.5, 0.5; }",
+		"missing":   variables + "variable b { type discrete [ 2 ] { t, f }; }\nprobability ( a ) { table 0.5, 0.5; }\nprobability ( b | a ) { (t) 0.5, 0.5; }",
+		"values":    "variable a { type discrete [ 3 ] { t, f }; }",
+		"count":     variables + "probability ( a ) { table 0.5, 0.2, 0.3; }",
+		"sum":       variables + "probability ( a ) { table 0.5, 0.2; }",
+		"number":    variables + "probability ( a ) { table 0.5, half; }",
+		"undefined": variables,
+		"twice":     variables + "probability ( a ) { table 0.5, 0.5; }\nprobability ( a ) { table 0.5, 0.5; }",
+		"cycle":     variables + "variable b { type discrete [ 2 ] { t, f }; }\nprobability ( a | b ) { default 0.5, 0.5; }\nprobability ( b | a ) { default 0.5, 0.5; }",
+		"comment":   variables + "/* unterminated",
+		"eof":       "variable a { type discrete [ 2 ]",
+	}
+	for name, data := range cases {
+		if _, err := ReadBIF(strings.NewReader(data)); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+	_, err := ReadBIF(strings.NewReader(cases["unknown"]))
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteBIF(t *testing.T) {
+	network, err := ReadBIF(strings.NewReader(asiaBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := network.WriteBIF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadBIF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, network, read)
+
+	// other CPDs are written as tables, and States without labels as numbers
+	noisy, causes, _ := initNoisyORNetwork([]float64{.2, .4}, []float64{.8, .5}, .1)
+	causes[1].Name = "other"
+	path := filepath.Join(t.TempDir(), "noisy.bif")
+	if err := noisy.SaveBIF(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, noisy, loaded)
+
+	noisy.Nodes[0].Name = "has space"
+	if err := noisy.WriteBIF(&buf); err == nil {
+		t.Fatal("expected an error for a name that isn't a word")
+	}
+}
@@ -0,0 +1,200 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Builder declares a network by hand: Nodes with named States, edges
+// between them and their CPTs, e.g. from expert knowledge.  Every method
+// checks its arguments and returns an error describing the first problem;
+// the first error is also kept and returned by Build, so the errors of the
+// other methods may be ignored.
+type Builder struct {
+	net    *BayesianNetwork
+	byName map[string]*Node
+	// the probabilities set for each joint State of a Node's Parents, nil
+	// until set
+	rows map[*Node][][]float64
+	err  error
+}
+
+// initialize an empty Builder
+func NewBuilder() *Builder {
+	return &Builder{
+		net:    NewBayesianNetwork(),
+		byName: make(map[string]*Node),
+		rows:   make(map[*Node][][]float64)}
+}
+
+// keep the first error
+func (b *Builder) fail(format string, args ...interface{}) error {
+	err := fmt.Errorf("bayesiannetwork: "+format, args...)
+	if b.err == nil {
+		b.err = err
+	}
+	return err
+}
+
+// the declared Node with the name
+func (b *Builder) node(name string) (*Node, error) {
+	n, exists := b.byName[name]
+	if !exists {
+		return nil, b.fail("unknown node %q", name)
+	}
+	return n, nil
+}
+
+// declare a Node with the named States
+func (b *Builder) AddNode(name string, states ...string) error {
+	if name == "" {
+		return b.fail("node without a name")
+	}
+	if _, exists := b.byName[name]; exists {
+		return b.fail("duplicate node %q", name)
+	}
+	if len(states) == 0 {
+		return b.fail("node %q has no states", name)
+	}
+	for i, s := range states {
+		if contains(states[:i], s) {
+			return b.fail("node %q has state %q twice", name, s)
+		}
+	}
+	n := &Node{Name: name, States: len(states), StateLabels: append([]string{}, states...)}
+	b.byName[name] = n
+	b.net.Nodes = append(b.net.Nodes, n)
+	return nil
+}
+
+// add an edge from the parent to the child, which must not have a CPT yet.
+// The Parents of a Node are in the order their edges are added.
+func (b *Builder) AddEdge(parent, child string) error {
+	p, err := b.node(parent)
+	if err != nil {
+		return err
+	}
+	c, err := b.node(child)
+	if err != nil {
+		return err
+	}
+	switch {
+	case p == c:
+		return b.fail("edge from %q to itself", parent)
+	case containsNode(c.Parents, p):
+		return b.fail("duplicate edge from %q to %q", parent, child)
+	case b.rows[c] != nil:
+		return b.fail("edge to %q after its CPT was set", child)
+	}
+	if descendants(c, uncut)[p] {
+		return b.fail("edge from %q to %q would make a cycle", parent, child)
+	}
+	b.net.AddEdge(p, c)
+	return nil
+}
+
+// describe the States of the Parents of n, e.g. "rain=yes, sprinkler=off"
+func describeParentStates(n *Node, parentStates []int) string {
+	described := make([]string, len(n.Parents))
+	for i, p := range n.Parents {
+		described[i] = p.Name + "=" + p.StateLabel(parentStates[i])
+	}
+	return strings.Join(described, ", ")
+}
+
+// check and keep the probabilities of the States of n for a joint State of
+// its Parents
+func (b *Builder) setRow(n *Node, parentStates []int, probs []float64) error {
+	if err := validateDistribution(probs, n.States); err != nil {
+		if len(n.Parents) == 0 {
+			return b.fail("node %q: cpt %v", n.Name, err)
+		}
+		return b.fail("node %q: cpt row for %s %v", n.Name, describeParentStates(n, parentStates), err)
+	}
+	if b.rows[n] == nil {
+		b.rows[n] = make([][]float64, len(NewFactor(n.Parents...).Values))
+	}
+	b.rows[n][tableIndex(n, parentStates)] = append([]float64{}, probs...)
+	return nil
+}
+
+// set the whole CPT of a Node: the probabilities of its States for each
+// joint State of its Parents, the first Parent's State varying fastest (a
+// single row for a Node without Parents)
+func (b *Builder) SetCPT(name string, rows ...[]float64) error {
+	n, err := b.node(name)
+	if err != nil {
+		return err
+	}
+	configurations := NewFactor(n.Parents...)
+	if len(rows) != len(configurations.Values) {
+		return b.fail("node %q: cpt has %d rows, expected %d for the states of its %d parents",
+			name, len(rows), len(configurations.Values), len(n.Parents))
+	}
+	for j, probs := range rows {
+		if err := b.setRow(n, configurations.states(j), probs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// set the probabilities of the States of a Node given the States of its
+// Parents, keyed by the names of the Parents; e.g.
+// SetRow("wet", map[string]string{"rain": "yes"}, .9, .1)
+func (b *Builder) SetRow(name string, given map[string]string, probs ...float64) error {
+	n, err := b.node(name)
+	if err != nil {
+		return err
+	}
+	parentStates := make([]int, len(n.Parents))
+	for i, p := range n.Parents {
+		label, exists := given[p.Name]
+		if !exists {
+			return b.fail("node %q: cpt row doesn't give the state of parent %q", name, p.Name)
+		}
+		s, exists := stateOf(p, label)
+		if !exists {
+			return b.fail("node %q: parent %q has no state %q", name, p.Name, label)
+		}
+		parentStates[i] = s
+	}
+	if len(given) != len(n.Parents) {
+		for parent := range given {
+			if !containsNode(n.Parents, b.byName[parent]) {
+				return b.fail("node %q: cpt row gives the state of %q, which isn't a parent",
+					name, parent)
+			}
+		}
+	}
+	return b.setRow(n, parentStates, probs)
+}
+
+// the network, once every Node has a complete CPT, with the Nodes in
+// topological order (otherwise in the order they are added).  The Builder
+// shouldn't be used afterwards.
+func (b *Builder) Build() (*BayesianNetwork, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	for _, n := range b.net.Nodes {
+		rows := b.rows[n]
+		if rows == nil {
+			return nil, fmt.Errorf("bayesiannetwork: node %q has no cpt", n.Name)
+		}
+		configurations := NewFactor(n.Parents...)
+		n.cpd = make([]Density, len(rows))
+		for j, probs := range rows {
+			if probs == nil {
+				return nil, fmt.Errorf("bayesiannetwork: node %q: cpt has no row for %s",
+					n.Name, describeParentStates(n, configurations.states(j)))
+			}
+			n.cpd[j] = NewDensity(probs...)
+		}
+	}
+	b.net.stableTopologicalSort()
+	if err := b.net.Validate(); err != nil {
+		return nil, err
+	}
+	return b.net, nil
+}
@@ -0,0 +1,136 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// the sprinkler network
+func buildSprinkler(b *Builder) {
+	b.AddNode("cloudy", "yes", "no")
+	b.AddNode("sprinkler", "on", "off")
+	b.AddNode("rain", "yes", "no")
+	b.AddNode("wet", "yes", "no")
+	b.AddEdge("cloudy", "sprinkler")
+	b.AddEdge("cloudy", "rain")
+	b.AddEdge("sprinkler", "wet")
+	b.AddEdge("rain", "wet")
+	b.SetCPT("cloudy", []float64{.5, .5})
+	b.SetCPT("sprinkler", []float64{.1, .9}, []float64{.5, .5})
+	b.SetRow("rain", map[string]string{"cloudy": "yes"}, .8, .2)
+	b.SetRow("rain", map[string]string{"cloudy": "no"}, .2, .8)
+	// the first parent varies fastest
+	b.SetCPT("wet",
+		[]float64{.99, .01}, // sprinkler on, rain
+		[]float64{.9, .1},   // sprinkler off, rain
+		[]float64{.9, .1},   // sprinkler on, no rain
+		[]float64{0, 1})
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	buildSprinkler(b)
+	network, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wet := network.Nodes[3]
+	if wet.Parents[0].Name != "sprinkler" || wet.StateLabel(1) != "no" {
+		t.Fatal(wet.Parents)
+	}
+	sprinkler, rain := network.Nodes[1], network.Nodes[2]
+	if p := wet.conditionalProbability(map[*Node]int{sprinkler: 1, rain: 0, wet: 0}); p != .9 {
+		t.Fatal(p)
+	}
+	// P(wet = yes) = .6471 for the textbook network
+	densities, err := network.ExactPosterior([]*Node{wet}, map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := densities[wet].StateMap[0]; math.Abs(p-.6471) > 1e-12 {
+		t.Fatal(p)
+	}
+}
+
+func TestBuilderChildFirst(t *testing.T) {
+	b := NewBuilder()
+	b.AddNode("wet", "yes", "no")
+	b.AddNode("rain", "yes", "no")
+	b.AddEdge("rain", "wet")
+	b.SetCPT("wet", []float64{.9, .1}, []float64{.2, .8})
+	b.SetCPT("rain", []float64{.3, .7})
+	network, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rain, wet := network.Nodes[0], network.Nodes[1]
+	if rain.Name != "rain" || wet.Name != "wet" {
+		t.Fatal(network.Nodes)
+	}
+	// P(wet = yes) = .3 * .9 + .7 * .2
+	if p := sampledFrequency(network, wet, 0); math.Abs(p-.41) > .04 {
+		t.Fatal(p)
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	cases := map[string]func(b *Builder) error{
+		"duplicate node": func(b *Builder) error { return b.AddNode("rain", "a") },
+		"no states":      func(b *Builder) error { return b.AddNode("new") },
+		"no name":        func(b *Builder) error { return b.AddNode("", "a") },
+		"same state":     func(b *Builder) error { return b.AddNode("new", "a", "a") },
+		"unknown":        func(b *Builder) error { return b.AddEdge("cloudy", "hail") },
+		"self":           func(b *Builder) error { return b.AddEdge("rain", "rain") },
+		"duplicate edge": func(b *Builder) error { return b.AddEdge("rain", "wet") },
+		"cycle":          func(b *Builder) error { return b.AddEdge("wet", "cloudy") },
+		"after cpt":      func(b *Builder) error { return b.AddEdge("cloudy", "wet") },
+		"rows":           func(b *Builder) error { return b.SetCPT("rain", []float64{.5, .5}) },
+		"sum": func(b *Builder) error {
+			return b.SetRow("rain", map[string]string{"cloudy": "no"}, .5, .6)
+		},
+		"length": func(b *Builder) error {
+			return b.SetRow("rain", map[string]string{"cloudy": "no"}, .5, .3, .2)
+		},
+		"missing parent": func(b *Builder) error { return b.SetRow("rain", map[string]string{}, .5, .5) },
+		"state": func(b *Builder) error {
+			return b.SetRow("rain", map[string]string{"cloudy": "maybe"}, .5, .5)
+		},
+		"not a parent": func(b *Builder) error {
+			return b.SetRow("rain", map[string]string{"cloudy": "no", "wet": "no"}, .5, .5)
+		},
+	}
+	for name, f := range cases {
+		b := NewBuilder()
+		buildSprinkler(b)
+		if err := f(b); err == nil {
+			t.Error(name, "expected an error")
+		}
+		if _, err := b.Build(); err == nil {
+			t.Error(name, "expected Build to report the error")
+		}
+	}
+
+	// the error describes the row
+	b := NewBuilder()
+	buildSprinkler(b)
+	err := b.SetRow("wet", map[string]string{"sprinkler": "on", "rain": "no"}, .5, .6)
+	if !strings.Contains(err.Error(), "sprinkler=on, rain=no") {
+		t.Fatal(err)
+	}
+
+	// incomplete CPTs
+	b = NewBuilder()
+	b.AddNode("a", "x", "y")
+	b.AddNode("b", "x", "y")
+	if _, err := b.Build(); err == nil {
+		t.Fatal("expected an error for a node without a cpt")
+	}
+	b.SetCPT("a", []float64{.5, .5})
+	b.AddEdge("a", "b")
+	b.SetRow("b", map[string]string{"a": "x"}, .5, .5)
+	_, err = b.Build()
+	if err == nil || !strings.Contains(err.Error(), "a=y") {
+		t.Fatal(err)
+	}
+}
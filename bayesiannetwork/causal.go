@@ -0,0 +1,334 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// graph surgery for the intervention do(X = x) on every intervened Node: a
+// copy of the net in which the intervened Nodes have no Parents and are
+// certain of their State.  The copy has new Nodes, so the mapping from the
+// original Nodes to the copies is returned as well.
+func (net BayesianNetwork) Do(interventions map[*Node]int) (*BayesianNetwork, map[*Node]*Node) {
+	mutilated := NewBayesianNetwork()
+	copies := make(map[*Node]*Node, len(net.Nodes))
+	for _, n := range net.Nodes {
+		copies[n] = copyNode(n, n.Name)
+		mutilated.Nodes = append(mutilated.Nodes, copies[n])
+	}
+	for _, n := range net.Nodes {
+		copied := copies[n]
+		if s, intervened := interventions[n]; intervened {
+			probs := make([]float64, n.States)
+			probs[s] = 1
+			copied.cpd = []Density{NewDensity(probs...)}
+			continue
+		}
+		for _, p := range n.Parents {
+			mutilated.AddEdge(copies[p], copied)
+		}
+		copied.cpd, copied.CPD, copied.stale = n.cpd, copiedCPD(n.CPD, copies), n.stale
+	}
+	return mutilated, copies
+}
+
+// the States fixed by the interventions and the evidence, which must agree
+func interventionStates(interventions, evidence map[*Node]int) (map[*Node]int, error) {
+	if err := validateEvidence(interventions); err != nil {
+		return nil, err
+	}
+	if err := validateEvidence(evidence); err != nil {
+		return nil, err
+	}
+	fixed := make(map[*Node]int, len(interventions)+len(evidence))
+	for n, s := range evidence {
+		fixed[n] = s
+	}
+	for n, s := range interventions {
+		if observed, exists := fixed[n]; exists && observed != s {
+			return nil, fmt.Errorf(
+				"bayesiannetwork: node %q is observed in state %d but set to %d",
+				n.Name, observed, s)
+		}
+		fixed[n] = s
+	}
+	return fixed, nil
+}
+
+// P(dependent | do(interventions), evidence): the distribution of the
+// dependent Nodes when the intervened Nodes are forced to their States,
+// cutting their dependence on their Parents, and the evidence is observed
+// afterwards.  Calculated by variable elimination on the mutilated network
+// without copying it.
+func (net BayesianNetwork) InterventionalPosterior(
+	dependent []*Node,
+	interventions map[*Node]int,
+	evidence map[*Node]int) (map[*Node]Density, error) {
+
+	fixed, err := interventionStates(interventions, evidence)
+	if err != nil {
+		return nil, err
+	}
+	// the CPDs of intervened Nodes are replaced by certainty, which is 1
+	// everywhere once they are reduced
+	factors := make([]*Factor, 0, len(net.Nodes))
+	for _, n := range net.Nodes {
+		if _, intervened := interventions[n]; intervened {
+			continue
+		}
+		if n.stale {
+			return nil, staleError(n)
+		}
+		for _, f := range n.factors() {
+			factors = append(factors, f.Reduce(fixed))
+		}
+	}
+	if eliminate(factors, map[*Node]bool{}, false).Values[0] <= 0 {
+		return nil, ErrImpossibleEvidence
+	}
+
+	densities := make(map[*Node]Density, len(dependent))
+	for _, n := range dependent {
+		probs := make([]float64, n.States)
+		if s, exists := fixed[n]; exists {
+			probs[s] = 1
+		} else {
+			f := eliminate(factors, map[*Node]bool{n: true}, false)
+			f.Normalize()
+			for s := range probs {
+				probs[s] = math.Max(0, f.Value(map[*Node]int{n: s}))
+			}
+		}
+		densities[n] = NewDensity(probs...)
+	}
+	return densities, nil
+}
+
+// the average causal effect of setting the treatment to treated instead of
+// control on the probability of the outcome's State:
+// P(outcome = state | do(treatment = treated)) -
+// P(outcome = state | do(treatment = control))
+func (net BayesianNetwork) AverageCausalEffect(
+	outcome *Node,
+	state int,
+	treatment *Node,
+	treated, control int) (float64, error) {
+
+	probs := make([]float64, 2)
+	for i, s := range []int{treated, control} {
+		densities, err := net.InterventionalPosterior(
+			[]*Node{outcome}, map[*Node]int{treatment: s}, map[*Node]int{})
+		if err != nil {
+			return 0, err
+		}
+		probs[i] = densities[outcome].StateMap[state]
+	}
+	return probs[0] - probs[1], nil
+}
+
+// the Nodes reachable from n along directed edges that aren't cut
+func descendants(n *Node, cut func(p, c *Node) bool) map[*Node]bool {
+	found := make(map[*Node]bool)
+	stack := []*Node{n}
+	for len(stack) > 0 {
+		m := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, c := range m.Children {
+			if !found[c] && !cut(m, c) {
+				found[c] = true
+				stack = append(stack, c)
+			}
+		}
+	}
+	return found
+}
+
+// the Nodes with a directed path to one of the Nodes, including the Nodes
+func ancestorsOf(nodes []*Node, cut func(p, c *Node) bool) map[*Node]bool {
+	found := make(map[*Node]bool)
+	stack := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		found[n] = true
+		stack = append(stack, n)
+	}
+	for len(stack) > 0 {
+		m := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, p := range m.Parents {
+			if !found[p] && !cut(p, m) {
+				found[p] = true
+				stack = append(stack, p)
+			}
+		}
+	}
+	return found
+}
+
+// the unobserved Nodes with an active trail from one of the Nodes of xs
+// given the observed Nodes zs in the graph without the cut edges, including
+// the unobserved Nodes of xs, by the reachability (Bayes ball) algorithm of
+// Koller and Friedman (2009, algorithm 3.1)
+func reachable(xs, zs []*Node, cut func(p, c *Node) bool) map[*Node]bool {
+	observed := make(map[*Node]bool, len(zs))
+	for _, z := range zs {
+		observed[z] = true
+	}
+	ancestors := ancestorsOf(zs, cut)
+
+	// visit (Node, direction) pairs: up means reached from a child
+	type visit struct {
+		n  *Node
+		up bool
+	}
+	found := make(map[*Node]bool)
+	visited := make(map[visit]bool)
+	queue := make([]visit, 0)
+	for _, x := range xs {
+		queue = append(queue, visit{x, true})
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if visited[v] {
+			continue
+		}
+		visited[v] = true
+		if !observed[v.n] {
+			found[v.n] = true
+		}
+
+		if v.up && !observed[v.n] {
+			for _, p := range v.n.Parents {
+				if !cut(p, v.n) {
+					queue = append(queue, visit{p, true})
+				}
+			}
+			for _, c := range v.n.Children {
+				if !cut(v.n, c) {
+					queue = append(queue, visit{c, false})
+				}
+			}
+		} else if !v.up {
+			if !observed[v.n] {
+				for _, c := range v.n.Children {
+					if !cut(v.n, c) {
+						queue = append(queue, visit{c, false})
+					}
+				}
+			}
+			// a v-structure is opened by observing the Node or a descendant
+			if ancestors[v.n] {
+				for _, p := range v.n.Parents {
+					if !cut(p, v.n) {
+						queue = append(queue, visit{p, true})
+					}
+				}
+			}
+		}
+	}
+	return found
+}
+
+// whether every Node of xs is d-separated from every Node of ys given zs in
+// the graph without the cut edges
+func dSeparated(xs, ys, zs []*Node, cut func(p, c *Node) bool) bool {
+	found := reachable(xs, zs, cut)
+	for _, y := range ys {
+		if found[y] {
+			return false
+		}
+	}
+	return true
+}
+
+// whether adjusting for the Nodes of z identifies the causal effect of the
+// treatment on the outcome by the back-door criterion (Pearl, 1993): no
+// Node of z is a descendant of the treatment, and z blocks every path
+// between them that starts with an edge into the treatment
+func SatisfiesBackdoor(treatment, outcome *Node, z []*Node) bool {
+	after := descendants(treatment, uncut)
+	for _, n := range z {
+		if n == treatment || n == outcome || after[n] {
+			return false
+		}
+	}
+	// the paths starting with an edge into the treatment are the paths left
+	// after cutting the edges out of it
+	outgoing := func(p, c *Node) bool { return p == treatment }
+	return dSeparated([]*Node{treatment}, []*Node{outcome}, z, outgoing)
+}
+
+// a minimal set of Nodes satisfying the back-door criterion for the effect
+// of the treatment on the outcome, found by removing Nodes one at a time
+// from a valid set: the Parents of the treatment, or else every Node of the
+// net that isn't a descendant of the treatment
+func (net BayesianNetwork) BackdoorAdjustmentSet(treatment, outcome *Node) ([]*Node, error) {
+	after := descendants(treatment, uncut)
+	candidates := [][]*Node{append([]*Node{}, treatment.Parents...), {}}
+	for _, n := range net.Nodes {
+		if n != treatment && n != outcome && !after[n] {
+			candidates[1] = append(candidates[1], n)
+		}
+	}
+
+	for _, z := range candidates {
+		if !SatisfiesBackdoor(treatment, outcome, z) {
+			continue
+		}
+		for i := len(z) - 1; i >= 0; i-- {
+			smaller := append(append([]*Node{}, z[:i]...), z[i+1:]...)
+			if SatisfiesBackdoor(treatment, outcome, smaller) {
+				z = smaller
+			}
+		}
+		return z, nil
+	}
+	return nil, errors.New("bayesiannetwork: no set of nodes satisfies the back-door criterion")
+}
+
+// P(outcome = state | do(treatment = s)) by the back-door adjustment
+// formula, the sum over the States of z of
+// P(outcome = state | treatment = s, z) P(z), using only observational
+// queries.  Equal to the interventional probability when z satisfies the
+// back-door criterion.
+func (net BayesianNetwork) BackdoorAdjustment(
+	outcome *Node,
+	state int,
+	treatment *Node,
+	s int,
+	z []*Node) (float64, error) {
+
+	if err := net.checkStale(); err != nil {
+		return 0, err
+	}
+	factors := net.factors(map[*Node]int{})
+	keep := make(map[*Node]bool, len(z))
+	for _, n := range z {
+		keep[n] = true
+	}
+	pz := eliminate(factors, keep, false)
+
+	total := 0.0
+	configurations := NewFactor(z...)
+	for index := range configurations.Values {
+		evidence := map[*Node]int{treatment: s}
+		for i, zs := range configurations.states(index) {
+			evidence[z[i]] = zs
+		}
+		p := pz.Value(evidence)
+		if p <= 0 {
+			continue
+		}
+		densities, err := net.ExactPosterior([]*Node{outcome}, evidence)
+		if err == ErrImpossibleEvidence {
+			return 0, fmt.Errorf(
+				"bayesiannetwork: %q = %d is impossible for some states of the adjustment set",
+				treatment.Name, s)
+		} else if err != nil {
+			return 0, err
+		}
+		total += p * densities[outcome].StateMap[state]
+	}
+	return total, nil
+}
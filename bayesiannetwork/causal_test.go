@@ -0,0 +1,165 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// z confounds the effect of x on y
+func initConfounded() (*BayesianNetwork, *Node, *Node, *Node) {
+	z := &Node{Name: "z", States: 2, cpd: []Density{NewDensity(.6, .4)}}
+	x := &Node{Name: "x", States: 2, cpd: []Density{NewDensity(.8, .2), NewDensity(.3, .7)}}
+	// parents x then z
+	y := &Node{Name: "y", States: 2, cpd: []Density{
+		NewDensity(.9, .1), NewDensity(.6, .4), NewDensity(.5, .5), NewDensity(.2, .8)}}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, z, x, y)
+	net.AddEdge(z, x)
+	net.AddEdge(x, y)
+	net.AddEdge(z, y)
+	return net, z, x, y
+}
+
+func TestInterventionalPosterior(t *testing.T) {
+	net, z, x, y := initConfounded()
+	// P(y = 1 | do(x = 1)) = sum over z of P(y = 1 | x = 1, z) P(z)
+	expected := .6*.4 + .4*.8
+	densities, err := net.InterventionalPosterior(
+		[]*Node{y, z, x}, map[*Node]int{x: 1}, map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(densities[y].StateMap[1]-expected) > 1e-12 {
+		t.Fatal(densities[y].StateMap, expected)
+	}
+	// intervening doesn't change the cause
+	if math.Abs(densities[z].StateMap[1]-.4) > 1e-12 || densities[x].StateMap[1] != 1 {
+		t.Fail()
+	}
+	// unlike observing
+	observed, _ := net.ExactPosterior([]*Node{y}, map[*Node]int{x: 1})
+	if math.Abs(observed[y].StateMap[1]-expected) < 1e-3 {
+		t.Fatal("expected confounding")
+	}
+
+	// the mutilated copy gives the same answer
+	mutilated, copies := net.Do(map[*Node]int{x: 1})
+	if len(copies[x].Parents) != 0 || len(copies[z].Children) != 1 {
+		t.Fatal("expected the edge into x to be cut")
+	}
+	exact, _ := mutilated.ExactPosterior([]*Node{copies[y]}, map[*Node]int{})
+	if math.Abs(exact[copies[y]].StateMap[1]-expected) > 1e-12 {
+		t.Fail()
+	}
+
+	// evidence after the intervention
+	given, err := net.InterventionalPosterior(
+		[]*Node{y}, map[*Node]int{x: 1}, map[*Node]int{z: 0})
+	if err != nil || math.Abs(given[y].StateMap[1]-.4) > 1e-12 {
+		t.Fatal(given[y].StateMap, err)
+	}
+	if _, err := net.InterventionalPosterior(
+		[]*Node{y}, map[*Node]int{x: 1}, map[*Node]int{x: 0}); err == nil {
+		t.Fatal("expected an error for contradicting evidence")
+	}
+
+	ace, err := net.AverageCausalEffect(y, 1, x, 1, 0)
+	if err != nil || math.Abs(ace-(expected-(.6*.1+.4*.5))) > 1e-12 {
+		t.Fatal(ace, err)
+	}
+}
+
+func TestBackdoor(t *testing.T) {
+	net, z, x, y := initConfounded()
+	set, err := net.BackdoorAdjustmentSet(x, y)
+	if err != nil || len(set) != 1 || set[0] != z {
+		t.Fatal(set, err)
+	}
+	for s := 0; s < 2; s++ {
+		adjusted, err := net.BackdoorAdjustment(y, 1, x, s, set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		densities, _ := net.InterventionalPosterior(
+			[]*Node{y}, map[*Node]int{x: s}, map[*Node]int{})
+		if math.Abs(adjusted-densities[y].StateMap[1]) > 1e-12 {
+			t.Fatal(adjusted, densities[y].StateMap)
+		}
+	}
+
+	// the M graph x <- a -> m <- b -> y with x -> m2 -> y: adjusting for
+	// the collider m opens a path and the mediator m2 is a descendant
+	a := &Node{Name: "a", States: 2}
+	b := &Node{Name: "b", States: 2}
+	m := &Node{Name: "m", States: 2}
+	m2 := &Node{Name: "m2", States: 2}
+	x = &Node{Name: "x", States: 2}
+	y = &Node{Name: "y", States: 2}
+	net = NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, a, b, m, m2, x, y)
+	net.AddEdge(a, x)
+	net.AddEdge(a, m)
+	net.AddEdge(b, m)
+	net.AddEdge(b, y)
+	net.AddEdge(x, m2)
+	net.AddEdge(m2, y)
+
+	if !SatisfiesBackdoor(x, y, []*Node{}) {
+		t.Fail()
+	}
+	if SatisfiesBackdoor(x, y, []*Node{m}) {
+		t.Fatal("the collider opens a back-door path")
+	}
+	if !SatisfiesBackdoor(x, y, []*Node{m, a}) || !SatisfiesBackdoor(x, y, []*Node{m, b}) {
+		t.Fail()
+	}
+	if SatisfiesBackdoor(x, y, []*Node{m2}) {
+		t.Fatal("the mediator is a descendant of the treatment")
+	}
+	set, err = net.BackdoorAdjustmentSet(x, y)
+	if err != nil || len(set) != 0 {
+		t.Fatal(set, err)
+	}
+}
+
+func TestDoTree(t *testing.T) {
+	net, a, b, _, y := initTreeNetwork()
+	y.CPD = &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{
+		{Probs: []float64{.9, .1}},
+		{Split: b, Branches: []*TreeNode{
+			{Probs: []float64{.8, .2}},
+			{Probs: []float64{.5, .5}},
+			{Probs: []float64{.1, .9}}}}}}}
+	// the same network with a table
+	tabled, ta, _, _, ty := initTreeNetwork()
+	rows, err := probabilityRows(y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setRows(ty, rows)
+
+	mutilated, copies := net.Do(map[*Node]int{b: 2})
+	if split := copies[y].CPD.(*TreeCPD).Root.Split; split != copies[a] {
+		t.Fatal(split)
+	}
+	// P(y = 1 | do(b = 2)) = .5 * .1 + .5 * .9
+	exact, err := mutilated.ExactPosterior([]*Node{copies[y]}, map[*Node]int{})
+	if err != nil || math.Abs(exact[copies[y]].StateMap[1]-.5) > 1e-12 {
+		t.Fatal(exact[copies[y]].StateMap, err)
+	}
+	result, err := net.Query(Query{Targets: []string{"y"}, Interventions: map[string]string{"b": "2"}})
+	if err != nil || math.Abs(result.Posteriors["y"]["1"]-.5) > 1e-12 {
+		t.Fatal(result.Posteriors, err)
+	}
+
+	// counterfactuals use the copies too
+	densities, err := net.Counterfactual([]*Node{y}, map[*Node]int{y: 1}, map[*Node]int{a: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := tabled.Counterfactual([]*Node{ty}, map[*Node]int{ty: 1}, map[*Node]int{ta: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareDensities(t, densities[y], expected[ty], y.States)
+}
@@ -0,0 +1,21 @@
+package bayesiannetwork
+
+// learn a tree shaped network with the Chow-Liu algorithm: the maximum weight
+// spanning tree over the pairwise mutual information of the Nodes.  Of all
+// the trees, it has the highest likelihood for the data.  The tree is rooted
+// at the first Node by name.
+func ChowLiu(data []map[*Node]int) *BayesianNetwork {
+	return ChowLiuFromDataset(DatasetFromInstances(data))
+}
+
+// learn a Chow-Liu tree from the columns of a Dataset; the tree is rooted at
+// the first column
+func ChowLiuFromDataset(ds *Dataset) *BayesianNetwork {
+	ds.inferNodeStates()
+	weight := func(a, b *Node) float64 {
+		return ds.MutualInformation(a, b)
+	}
+	edges := maximumSpanningTree(ds.Nodes, ds.Nodes[0], weight)
+
+	return networkFromEdges(ds, edges, 0)
+}
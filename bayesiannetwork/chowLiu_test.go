@@ -0,0 +1,41 @@
+package bayesiannetwork
+
+import "testing"
+
+func TestChowLiu(t *testing.T) {
+	// the skeleton of the student network is a tree, so it should be
+	// recovered from enough samples
+	samples := studentSamples(2000)
+	net := ChowLiu(samples)
+
+	if HasCycles(net) || len(net.Nodes) != 5 {
+		t.Fail()
+	}
+
+	solution := map[[2]string]bool{
+		{"G", "I"}: true,
+		{"D", "G"}: true,
+		{"I", "S"}: true,
+		{"G", "L"}: true}
+	edges := net.Edges()
+	if len(edges) != len(solution) {
+		t.Fatal(edges)
+	}
+	for _, e := range edges {
+		a, b := e.Parent.Name, e.Child.Name
+		if b < a {
+			a, b = b, a
+		}
+		if !solution[[2]string{a, b}] {
+			t.Fail()
+		}
+	}
+
+	// a tree is at least as likely as the network with no edges
+	empty := networkFromEdges(DatasetFromInstances(samples), []Edge{}, 0)
+	emptyLL := empty.ModelLikelihood(samples)
+	net = ChowLiu(samples)
+	if net.ModelLikelihood(samples) <= emptyLL {
+		t.Fail()
+	}
+}
@@ -0,0 +1,329 @@
+package bayesiannetwork
+
+import (
+	"context"
+	"geneticalgorithm"
+	"math"
+	"sort"
+)
+
+// criteria for scoring the topology of a network used as a classifier
+type ClassScore int
+
+const (
+	// the log probability of the true class given all other Nodes
+	ConditionalLikelihoodScore ClassScore = iota
+	// the fraction of correctly predicted classes in k-fold cross validation
+	CrossValidatedAccuracyScore
+)
+
+// the number of folds used by CrossValidatedAccuracyScore
+const classifierFolds = 5
+
+// pseudo-observations added to every State when fitting classifiers so that
+// unseen combinations of States don't rule a class out entirely
+const classifierPrior = 1.0
+
+// the distribution of the class Node given the States of every other Node in
+// the instance.  Only the class and its children depend on the class, so the
+// rest of the network cancels out.
+func (net BayesianNetwork) classPosterior(
+	instance map[*Node]int,
+	class *Node) []float64 {
+
+	states := make(map[*Node]int, len(instance))
+	for n, s := range instance {
+		states[n] = s
+	}
+
+	probs := make([]float64, class.States)
+	total := 0.0
+	for c := 0; c < class.States; c++ {
+		states[class] = c
+		p := class.conditionalProbability(states)
+		for _, child := range class.Children {
+			p *= child.conditionalProbability(states)
+		}
+		probs[c] = p
+		total += p
+	}
+	for c := range probs {
+		if total == 0 {
+			probs[c] = 1.0 / float64(class.States)
+		} else {
+			probs[c] /= total
+		}
+	}
+	return probs
+}
+
+// the distribution of the class Node given the States observed in the
+// instance: the Markov blanket shortcut when every other Node is observed
+// and variable elimination otherwise
+func (net BayesianNetwork) predictProba(
+	instance map[*Node]int,
+	class *Node) ([]float64, error) {
+
+	evidence := make(map[*Node]int)
+	for _, n := range net.Nodes {
+		if s, observed := instance[n]; observed && n != class {
+			evidence[n] = s
+		}
+	}
+	if err := validateEvidence(evidence); err != nil {
+		return nil, err
+	}
+
+	// with every other Node observed only the Markov blanket matters
+	if len(evidence) == len(net.Nodes)-1 {
+		return net.classPosterior(evidence, class), nil
+	}
+
+	densities, err := net.ExactPosterior([]*Node{class}, evidence)
+	if err != nil {
+		return nil, err
+	}
+	probs := make([]float64, class.States)
+	for s := range probs {
+		probs[s] = densities[class].StateMap[s]
+	}
+	return probs, nil
+}
+
+// the conditional log likelihood of the class Node over the observations,
+// i.e. the sum of log P(class | all other observed Nodes)
+func (net BayesianNetwork) ConditionalLogLikelihood(
+	observations []map[*Node]int,
+	class *Node) float64 {
+
+	return net.datasetConditionalLikelihood(
+		datasetOf(net.Nodes, observations), class)
+}
+
+// the conditional log likelihood of the class Node over the rows of the
+// Dataset where it isn't Missing.  Rows with impossible evidence count as
+// log(0).
+func (net BayesianNetwork) datasetConditionalLikelihood(
+	ds *Dataset,
+	class *Node) float64 {
+
+	cll := 0.0
+	for r := 0; r < ds.Len(); r++ {
+		actual := ds.Value(r, class)
+		if actual == Missing {
+			continue
+		}
+		probs, err := net.predictProba(ds.Row(r), class)
+		if err != nil {
+			return math.Inf(-1)
+		}
+		cll += math.Log(probs[actual])
+	}
+	return cll
+}
+
+// the accuracy of the current topology at predicting the class Node in
+// k-fold cross validation over the rows where the class isn't Missing.  The
+// CPDs are refit on all rows before returning.
+func (net *BayesianNetwork) crossValidatedAccuracy(
+	ds *Dataset,
+	class *Node,
+	folds int) float64 {
+
+	correct, total := 0, 0
+	for fold := 0; fold < folds; fold++ {
+		train := make([]int, 0)
+		test := make([]int, 0)
+		for r := 0; r < ds.Len(); r++ {
+			if r%folds == fold {
+				test = append(test, r)
+			} else {
+				train = append(train, r)
+			}
+		}
+		net.fitDataset(ds.Subset(train), classifierPrior)
+		for _, r := range test {
+			actual := ds.Value(r, class)
+			if actual == Missing {
+				continue
+			}
+			total++
+			probs, err := net.predictProba(ds.Row(r), class)
+			if err == nil && argmax(probs) == actual {
+				correct++
+			}
+		}
+	}
+	net.fitDataset(ds, classifierPrior)
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total)
+}
+
+// the columns of the Dataset other than the class, ordered by decreasing
+// mutual information with the class
+func featuresByRelevance(ds *Dataset, class *Node) []*Node {
+	features := make([]*Node, 0)
+	relevance := make(map[*Node]float64)
+	for _, n := range ds.Nodes {
+		if n != class {
+			features = append(features, n)
+			relevance[n] = ds.MutualInformation(n, class)
+		}
+	}
+	sort.SliceStable(features, func(i, j int) bool {
+		return relevance[features[i]] > relevance[features[j]]
+	})
+	return features
+}
+
+// a naive Bayes classifier: the class is the only parent of every other Node
+func NaiveBayes(data []map[*Node]int, class *Node) *BayesianNetwork {
+	return NaiveBayesFromDataset(DatasetFromInstances(data), class)
+}
+
+// a naive Bayes classifier over the columns of a Dataset
+func NaiveBayesFromDataset(ds *Dataset, class *Node) *BayesianNetwork {
+	edges := make([]Edge, 0)
+	for _, n := range ds.Nodes {
+		if n != class {
+			edges = append(edges, Edge{Parent: class, Child: n})
+		}
+	}
+	return networkFromEdges(ds, edges, classifierPrior)
+}
+
+// a tree augmented naive Bayes classifier: naive Bayes plus the maximum
+// spanning tree over the features weighted by their mutual information given
+// the class
+func TAN(data []map[*Node]int, class *Node) *BayesianNetwork {
+	return TANFromDataset(DatasetFromInstances(data), class)
+}
+
+// a tree augmented naive Bayes classifier over the columns of a Dataset
+func TANFromDataset(ds *Dataset, class *Node) *BayesianNetwork {
+	ds.inferNodeStates()
+	features := featuresByRelevance(ds, class)
+
+	edges := make([]Edge, 0)
+	for _, n := range features {
+		edges = append(edges, Edge{Parent: class, Child: n})
+	}
+	if len(features) > 0 {
+		weight := func(a, b *Node) float64 {
+			return ds.ConditionalMutualInformation(a, b, class)
+		}
+		tree := maximumSpanningTree(features, features[0], weight)
+		edges = append(edges, tree...)
+	}
+	return networkFromEdges(ds, edges, classifierPrior)
+}
+
+// a k-dependence Bayesian classifier (Sahami, 1996): features are added in
+// order of their mutual information with the class, and each has the class
+// plus up to k of the previously added features with the highest mutual
+// information given the class as parents
+func KDB(data []map[*Node]int, class *Node, k int) *BayesianNetwork {
+	return KDBFromDataset(DatasetFromInstances(data), class, k)
+}
+
+// a k-dependence Bayesian classifier over the columns of a Dataset
+func KDBFromDataset(ds *Dataset, class *Node, k int) *BayesianNetwork {
+	ds.inferNodeStates()
+	features := featuresByRelevance(ds, class)
+
+	edges := make([]Edge, 0)
+	for i, n := range features {
+		edges = append(edges, Edge{Parent: class, Child: n})
+
+		previous := make([]*Node, i)
+		copy(previous, features[:i])
+		dependence := make(map[*Node]float64)
+		for _, p := range previous {
+			dependence[p] = ds.ConditionalMutualInformation(n, p, class)
+		}
+		sort.SliceStable(previous, func(a, b int) bool {
+			return dependence[previous[a]] > dependence[previous[b]]
+		})
+		for j := 0; j < k && j < len(previous); j++ {
+			edges = append(edges, Edge{Parent: previous[j], Child: n})
+		}
+	}
+	return networkFromEdges(ds, edges, classifierPrior)
+}
+
+// infer a bayesian net for predicting the class Node, scoring topologies by
+// how well they predict the class instead of by the model likelihood
+func InferClassifierNetwork(
+	data []map[*Node]int,
+	class *Node,
+	iterations int,
+	score ClassScore) *BayesianNetwork {
+
+	net, _ := InferClassifierNetworkContext(
+		context.Background(), data, class, iterations, score, nil)
+	return net
+}
+
+// infer a bayesian net for predicting the class Node, reporting progress to
+// observer (which may be nil).  Cancellation behaves as in
+// InferBayesianNetworkContext.
+func InferClassifierNetworkContext(
+	ctx context.Context,
+	data []map[*Node]int,
+	class *Node,
+	iterations int,
+	score ClassScore,
+	observer Observer) (*BayesianNetwork, error) {
+
+	return InferClassifierNetworkFromDataset(
+		ctx, DatasetFromInstances(data), class, iterations, score, observer)
+}
+
+// infer a bayesian net for predicting the class Node from the columns of a
+// Dataset; see InferClassifierNetworkContext
+func InferClassifierNetworkFromDataset(
+	ctx context.Context,
+	ds *Dataset,
+	class *Node,
+	iterations int,
+	score ClassScore,
+	observer Observer) (*BayesianNetwork, error) {
+
+	nodeOrder := ds.Nodes
+
+	// the States are taken from all of the data once so that the folds of
+	// cross validation agree on them
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, ds.Nodes...)
+	ds.inferNodeStates()
+
+	mutateCheckFunction := func(edges []int) (ok bool) {
+		net.binaryToTopology(nodeOrder, edges)
+		return !HasCycles(net)
+	}
+
+	scoreFunction := func(bits []int) float64 {
+		net.binaryToTopology(nodeOrder, bits)
+		if score == CrossValidatedAccuracyScore {
+			return net.crossValidatedAccuracy(ds, class, classifierFolds)
+		}
+		net.fitDataset(ds, classifierPrior)
+		return net.datasetConditionalLikelihood(ds, class)
+	}
+
+	best, err := geneticalgorithm.EvolveContext(
+		ctx,
+		scoreFunction,
+		mutateCheckFunction,
+		len(nodeOrder)*len(nodeOrder),
+		iterations,
+		false,
+		progressObserver(observer, nodeOrder))
+
+	net.binaryToTopology(nodeOrder, best)
+	net.fitDataset(ds, classifierPrior)
+
+	return net, err
+}
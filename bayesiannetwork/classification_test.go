@@ -0,0 +1,147 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// samples from the student network and its grade Node
+func studentClassData() ([]map[*Node]int, *Node) {
+	samples := studentSamples(500)
+	for n, _ := range samples[0] {
+		if n.Name == "G" {
+			return samples, n
+		}
+	}
+	return nil, nil
+}
+
+// the accuracy of always predicting the most common class
+func majorityAccuracy(data []map[*Node]int, class *Node) float64 {
+	counts := make(map[int]int)
+	best := 0
+	for _, instance := range data {
+		counts[instance[class]]++
+		if counts[instance[class]] > best {
+			best = counts[instance[class]]
+		}
+	}
+	return float64(best) / float64(len(data))
+}
+
+func accuracy(net *BayesianNetwork, data []map[*Node]int, class *Node) float64 {
+	correct := 0
+	for _, instance := range data {
+		probs, _ := net.predictProba(instance, class)
+		if argmax(probs) == instance[class] {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(data))
+}
+
+func TestNaiveBayes(t *testing.T) {
+	data, class := studentClassData()
+	net := NaiveBayes(data, class)
+
+	if len(class.Parents) != 0 || len(class.Children) != 4 {
+		t.Fail()
+	}
+	for _, n := range net.Nodes {
+		if n != class && (len(n.Parents) != 1 || n.Parents[0] != class) {
+			t.Fail()
+		}
+	}
+	if accuracy(net, data, class) <= majorityAccuracy(data, class) {
+		t.Fail()
+	}
+}
+
+func TestTAN(t *testing.T) {
+	data, class := studentClassData()
+	net := TAN(data, class)
+
+	if HasCycles(net) {
+		t.Fail()
+	}
+	// every feature has the class and at most one other feature as parents,
+	// and exactly one feature is the root of the tree
+	roots := 0
+	for _, n := range net.Nodes {
+		if n == class {
+			continue
+		}
+		if len(n.Parents) == 1 {
+			roots++
+		}
+		if len(n.Parents) > 2 || n.Parents[0] != class {
+			t.Fail()
+		}
+	}
+	if roots != 1 {
+		t.Fail()
+	}
+	if accuracy(net, data, class) <= majorityAccuracy(data, class) {
+		t.Fail()
+	}
+}
+
+func TestKDB(t *testing.T) {
+	data, class := studentClassData()
+	net := KDB(data, class, 2)
+
+	if HasCycles(net) {
+		t.Fail()
+	}
+	edges := 0
+	for _, n := range net.Nodes {
+		if n != class && len(n.Parents) > 3 {
+			t.Fail()
+		}
+		edges += len(n.Parents)
+	}
+	// 4 edges from the class, then 0, 1, 2 and 2 from previous features
+	if edges != 9 {
+		t.Fail()
+	}
+}
+
+func TestConditionalLogLikelihood(t *testing.T) {
+	data, class := studentClassData()
+	net := NaiveBayes(data, class)
+
+	cll := net.ConditionalLogLikelihood(data, class)
+	if cll >= 0 || math.IsInf(cll, 0) || math.IsNaN(cll) {
+		t.Fail()
+	}
+	// the posterior over the classes is a distribution
+	for _, instance := range data[:10] {
+		total := 0.0
+		for _, p := range net.classPosterior(instance, class) {
+			total += p
+		}
+		if math.Abs(total-1) > 1e-9 {
+			t.Fail()
+		}
+	}
+}
+
+func TestInferClassifierNetwork(t *testing.T) {
+	data, class := studentClassData()
+
+	for _, score := range []ClassScore{
+		ConditionalLikelihoodScore,
+		CrossValidatedAccuracyScore} {
+
+		net := InferClassifierNetwork(data, class, 30, score)
+		cll := net.ConditionalLogLikelihood(data, class)
+		if HasCycles(net) || len(net.Nodes) != 5 || math.IsNaN(cll) {
+			t.Fail()
+		}
+		acc := net.crossValidatedAccuracy(
+			DatasetFromInstances(data), class, classifierFolds)
+		if acc < 0 || acc > 1 {
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bayesiannetwork
+
+import "fmt"
+
+// A Classifier predicts the State of a target Node of a network from the
+// States of the other Nodes.  Predictions use exact inference, so they are
+// deterministic, and Nodes missing from an instance are marginalized out.
+type Classifier struct {
+	Network *BayesianNetwork
+	Target  *Node
+}
+
+// initialize a classifier of the target Node of net
+func NewClassifier(net *BayesianNetwork, target *Node) *Classifier {
+	return &Classifier{Network: net, Target: target}
+}
+
+// fit the CPDs of the network's current topology to the data with Laplace
+// smoothing.  The number of States of a Node grows to cover the data but
+// never shrinks, so a class missing from the data keeps a (small)
+// probability.
+func (c *Classifier) Fit(data []map[*Node]int) {
+	c.FitDataset(datasetOf(c.Network.Nodes, data))
+}
+
+// fit the CPDs of the network's current topology to the rows of a Dataset
+// with a column for every Node of the network; see Fit
+func (c *Classifier) FitDataset(ds *Dataset) {
+	for _, n := range c.Network.Nodes {
+		for r := 0; r < ds.Len(); r++ {
+			if s := ds.Value(r, n); s >= n.States {
+				n.States = s + 1
+			}
+		}
+	}
+	c.Network.fitDataset(ds, classifierPrior)
+}
+
+// the distribution of the target Node for each instance; the target's own
+// State in an instance is ignored
+func (c *Classifier) PredictProba(data []map[*Node]int) ([][]float64, error) {
+	probs := make([][]float64, len(data))
+	for i, instance := range data {
+		p, err := c.Network.predictProba(instance, c.Target)
+		if err != nil {
+			return nil, err
+		}
+		probs[i] = p
+	}
+	return probs, nil
+}
+
+// the most probable State of the target Node for each instance; ties go to
+// the lowest State
+func (c *Classifier) Predict(data []map[*Node]int) ([]int, error) {
+	probs, err := c.PredictProba(data)
+	if err != nil {
+		return nil, err
+	}
+	predicted := make([]int, len(data))
+	for i, p := range probs {
+		predicted[i] = argmax(p)
+	}
+	return predicted, nil
+}
+
+// the confusion matrix of the predictions against the target States of the
+// instances, which must all be observed and in range
+func (c *Classifier) Evaluate(data []map[*Node]int) (ConfusionMatrix, error) {
+	nClasses := c.Target.States
+	for i, instance := range data {
+		actual, observed := instance[c.Target]
+		if !observed {
+			return nil, fmt.Errorf("bayesiannetwork: instance %d has no state for target %q",
+				i, c.Target.Name)
+		}
+		if actual < 0 || actual >= nClasses {
+			return nil, fmt.Errorf(
+				"bayesiannetwork: instance %d has state %d of target %q, out of range [0, %d)",
+				i, actual, c.Target.Name, nClasses)
+		}
+	}
+	predicted, err := c.Predict(data)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(ConfusionMatrix, nClasses)
+	for i := range m {
+		m[i] = make([]int, nClasses)
+	}
+	for i, instance := range data {
+		m[instance[c.Target]][predicted[i]]++
+	}
+	return m, nil
+}
+
+// the index of the largest value; ties go to the lowest index
+func argmax(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,120 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifierPredict(t *testing.T) {
+	data, class := studentClassData()
+	classifier := NewClassifier(NaiveBayes(data, class), class)
+	classifier.Fit(data)
+
+	probs, err := classifier.PredictProba(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	predicted, _ := classifier.Predict(data)
+	for i, p := range probs {
+		total := 0.0
+		for _, v := range p {
+			total += v
+		}
+		if math.Abs(total-1) > 1e-9 || predicted[i] != argmax(p) {
+			t.Fail()
+		}
+	}
+
+	// exact inference is deterministic
+	again, _ := classifier.PredictProba(data)
+	for i := range probs {
+		for s := range probs[i] {
+			if probs[i][s] != again[i][s] {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestClassifierMissingValues(t *testing.T) {
+	data, class := studentClassData()
+	classifier := NewClassifier(NaiveBayes(data, class), class)
+
+	// remove a feature and compare against the exact posterior
+	var removed *Node
+	for n, _ := range data[0] {
+		if n != class {
+			removed = n
+			break
+		}
+	}
+	partial := make(map[*Node]int)
+	for n, s := range data[0] {
+		if n != removed && n != class {
+			partial[n] = s
+		}
+	}
+	probs, err := classifier.PredictProba([]map[*Node]int{partial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution := enumeratePosterior(classifier.Network, class, partial)
+	for s, p := range solution {
+		if math.Abs(probs[0][s]-p) > 1e-9 {
+			t.Fail()
+		}
+	}
+
+	// the fast path agrees with variable elimination
+	evidence := make(map[*Node]int)
+	for n, s := range data[0] {
+		if n != class {
+			evidence[n] = s
+		}
+	}
+	probs, _ = classifier.PredictProba([]map[*Node]int{evidence})
+	solution = enumeratePosterior(classifier.Network, class, evidence)
+	for s, p := range solution {
+		if math.Abs(probs[0][s]-p) > 1e-9 {
+			t.Fail()
+		}
+	}
+}
+
+func TestClassifierEvaluate(t *testing.T) {
+	data, class := studentClassData()
+	classifier := NewClassifier(TAN(data, class), class)
+
+	m, err := classifier.Evaluate(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, correct := 0, 0
+	for i, row := range m {
+		for j, count := range row {
+			total += count
+			if i == j {
+				correct += count
+			}
+		}
+	}
+	if total != len(data) {
+		t.Fail()
+	}
+	if math.Abs(m.Accuracy()-float64(correct)/float64(total)) > 1e-12 {
+		t.Fail()
+	}
+	if m.Accuracy() != accuracy(classifier.Network, data, class) {
+		t.Fail()
+	}
+
+	// the target must be observed and in range
+	instance := map[*Node]int{class: class.States}
+	if _, err := classifier.Evaluate([]map[*Node]int{instance}); err == nil {
+		t.Fatal("expected an error for a state out of range")
+	}
+	delete(instance, class)
+	if _, err := classifier.Evaluate([]map[*Node]int{data[0], instance}); err == nil {
+		t.Fatal("expected an error for a missing target")
+	}
+}
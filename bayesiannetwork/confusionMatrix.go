@@ -0,0 +1,66 @@
+package bayesiannetwork
+
+// Counts of classified instances.  Rows are the actual classes and columns
+// the predicted classes.
+type ConfusionMatrix [][]int
+
+// the fraction of instances that were correctly classified
+func (m ConfusionMatrix) Accuracy() float64 {
+	correct, total := 0, 0
+	for i, row := range m {
+		for j, count := range row {
+			total += count
+			if i == j {
+				correct += count
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total)
+}
+
+// the fraction of instances predicted as class k that are class k (the
+// column of k); 0 when k is never predicted
+func (m ConfusionMatrix) Precision(k int) float64 {
+	predicted := 0
+	for _, row := range m {
+		predicted += row[k]
+	}
+	if predicted == 0 {
+		return 0
+	}
+	return float64(m[k][k]) / float64(predicted)
+}
+
+// the fraction of instances of class k predicted as class k (the row of k);
+// 0 when k never occurs
+func (m ConfusionMatrix) Recall(k int) float64 {
+	actual := 0
+	for _, count := range m[k] {
+		actual += count
+	}
+	if actual == 0 {
+		return 0
+	}
+	return float64(m[k][k]) / float64(actual)
+}
+
+// the harmonic mean of the precision and recall of class k
+func (m ConfusionMatrix) F1(k int) float64 {
+	p, r := m.Precision(k), m.Recall(k)
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+// the unweighted mean of the F1 scores of all classes
+func (m ConfusionMatrix) MacroF1() float64 {
+	total := 0.0
+	for k := range m {
+		total += m.F1(k)
+	}
+	return total / float64(len(m))
+}
@@ -0,0 +1,161 @@
+package bayesiannetwork
+
+import (
+	"math/rand"
+)
+
+// the CPD of a Node in the counterfactual world of a twin network.  Its
+// Parents are the Node in the factual world, the factual Parents of the
+// Node, then the counterfactual Parents that differ from the factual ones.
+// Each joint State of the Parents has its own exogenous noise, shared by
+// both worlds, so the Node is the same as in the factual world when its
+// Parents are, and otherwise independent of it.
+type twinCPD struct {
+	factual *Node
+	// whether each Parent of the factual Node has a counterfactual twin
+	twinned []bool
+}
+
+// the States of the factual Node, its factual Parents and its
+// counterfactual Parents
+func (c *twinCPD) split(parentStates []int) (int, []int, []int) {
+	factual := parentStates[1 : 1+len(c.twinned)]
+	counterfactual := make([]int, len(c.twinned))
+	next := 1 + len(c.twinned)
+	for i, twinned := range c.twinned {
+		if twinned {
+			counterfactual[i] = parentStates[next]
+			next++
+		} else {
+			counterfactual[i] = factual[i]
+		}
+	}
+	return parentStates[0], factual, counterfactual
+}
+
+func equalStates(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *twinCPD) Probability(n *Node, parentStates []int, state int) float64 {
+	v, factual, counterfactual := c.split(parentStates)
+	if equalStates(factual, counterfactual) {
+		if state == v {
+			return 1
+		}
+		return 0
+	}
+	return c.factual.distribution().Probability(c.factual, counterfactual, state)
+}
+
+func (c *twinCPD) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	v, factual, counterfactual := c.split(parentStates)
+	if equalStates(factual, counterfactual) {
+		return v
+	}
+	return c.factual.distribution().Sample(r, c.factual, counterfactual)
+}
+
+// the parameters belong to the factual Node
+func (c *twinCPD) Fit(n *Node, ds *Dataset, alpha float64) {}
+
+func (c *twinCPD) Factors(n *Node) []*Factor {
+	return []*Factor{TableFactor(n, c)}
+}
+
+// the twin network for counterfactuals about the interventions: a copy of
+// the net for the factual world plus a counterfactual copy of the intervened
+// Nodes and their descendants, in which the interventions hold.  Nodes that
+// the interventions can't affect are shared by both worlds.  The
+// relationship between the worlds assumes independent exogenous noise for
+// each joint State of a Node's Parents; the network alone doesn't determine
+// it.  The mappings from the original Nodes to their factual and
+// counterfactual copies are returned as well.
+func (net BayesianNetwork) TwinNetwork(interventions map[*Node]int) (
+	twin *BayesianNetwork,
+	factual map[*Node]*Node,
+	counterfactual map[*Node]*Node) {
+
+	twin, factual = net.Do(map[*Node]int{})
+	counterfactual = make(map[*Node]*Node, len(net.Nodes))
+	affected := make(map[*Node]bool)
+	for n := range interventions {
+		affected[n] = true
+		for d := range descendants(n, uncut) {
+			affected[d] = true
+		}
+	}
+
+	for _, n := range net.Nodes {
+		if !affected[n] {
+			counterfactual[n] = factual[n]
+			continue
+		}
+		counterfactual[n] = copyNode(n, n.Name+"*")
+		twin.Nodes = append(twin.Nodes, counterfactual[n])
+	}
+	for _, n := range net.Nodes {
+		if !affected[n] {
+			continue
+		}
+		copied := counterfactual[n]
+		if s, intervened := interventions[n]; intervened {
+			probs := make([]float64, n.States)
+			probs[s] = 1
+			copied.cpd = []Density{NewDensity(probs...)}
+			continue
+		}
+		cpd := &twinCPD{factual: factual[n], twinned: make([]bool, len(n.Parents))}
+		twin.AddEdge(factual[n], copied)
+		for _, p := range n.Parents {
+			twin.AddEdge(factual[p], copied)
+		}
+		for i, p := range n.Parents {
+			if affected[p] {
+				cpd.twinned[i] = true
+				twin.AddEdge(counterfactual[p], copied)
+			}
+		}
+		copied.CPD = cpd
+	}
+	return twin, factual, counterfactual
+}
+
+// the distribution of the dependent Nodes in the counterfactual world where
+// the interventions had been made, given the evidence observed in the
+// factual world: "given that we observed the evidence, what would the
+// dependent Nodes have been had the interventions been made?"  See
+// TwinNetwork for the assumption relating the two worlds.
+func (net BayesianNetwork) Counterfactual(
+	dependent []*Node,
+	evidence map[*Node]int,
+	interventions map[*Node]int) (map[*Node]Density, error) {
+
+	if err := validateEvidence(interventions); err != nil {
+		return nil, err
+	}
+	twin, factual, counterfactual := net.TwinNetwork(interventions)
+	observed := make(map[*Node]int, len(evidence))
+	for n, s := range evidence {
+		observed[factual[n]] = s
+	}
+	queried := make([]*Node, len(dependent))
+	for i, n := range dependent {
+		queried[i] = counterfactual[n]
+	}
+
+	densities, err := twin.ExactPosterior(queried, observed)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[*Node]Density, len(dependent))
+	for _, n := range dependent {
+		result[n] = densities[counterfactual[n]]
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCounterfactual(t *testing.T) {
+	net, z, x, y := initConfounded()
+
+	// observed x = 1 and y = 0: had x been 0, y is drawn again from
+	// P(y | x = 0, z) with z from its posterior given the observations
+	densities, err := net.Counterfactual(
+		[]*Node{y, z}, map[*Node]int{x: 1, y: 0}, map[*Node]int{x: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	posterior, _ := net.ExactPosterior([]*Node{z}, map[*Node]int{x: 1, y: 0})
+	expected := posterior[z].StateMap[0]*.1 + posterior[z].StateMap[1]*.5
+	if math.Abs(densities[y].StateMap[1]-expected) > 1e-12 {
+		t.Fatal(densities[y].StateMap, expected)
+	}
+	// z isn't affected by the intervention
+	if math.Abs(densities[z].StateMap[1]-posterior[z].StateMap[1]) > 1e-12 {
+		t.Fail()
+	}
+
+	// the intervention that happened changes nothing
+	same, err := net.Counterfactual(
+		[]*Node{y}, map[*Node]int{x: 1, y: 0}, map[*Node]int{x: 1})
+	if err != nil || same[y].StateMap[0] != 1 {
+		t.Fatal(same[y].StateMap, err)
+	}
+
+	// without evidence a counterfactual is an intervention
+	densities, _ = net.Counterfactual([]*Node{y}, map[*Node]int{}, map[*Node]int{x: 1})
+	intervened, _ := net.InterventionalPosterior(
+		[]*Node{y}, map[*Node]int{x: 1}, map[*Node]int{})
+	if math.Abs(densities[y].StateMap[1]-intervened[y].StateMap[1]) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestTwinNetwork(t *testing.T) {
+	// x -> m -> y where the intervention on x leaves m unchanged only when
+	// x keeps its factual State
+	x := &Node{Name: "x", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	m := &Node{Name: "m", States: 2, cpd: []Density{NewDensity(.9, .1), NewDensity(.2, .8)}}
+	y := &Node{Name: "y", States: 2, cpd: []Density{NewDensity(.7, .3), NewDensity(.1, .9)}}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, x, m, y)
+	net.AddEdge(x, m)
+	net.AddEdge(m, y)
+
+	twin, factual, counterfactual := net.TwinNetwork(map[*Node]int{x: 0})
+	if len(twin.Nodes) != 6 || HasCycles(twin) {
+		t.Fatal(len(twin.Nodes))
+	}
+	// the originals are untouched
+	if len(x.Children) != 1 || len(m.Children) != 1 {
+		t.Fail()
+	}
+	if len(counterfactual[x].Parents) != 0 || factual[x] == counterfactual[x] {
+		t.Fail()
+	}
+
+	// observed x = 1, m = 1, y = 1; had x been 0, m* ~ P(m | x = 0) and y*
+	// equals y when m* = m
+	densities, err := net.Counterfactual(
+		[]*Node{m, y}, map[*Node]int{x: 1, m: 1, y: 1}, map[*Node]int{x: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(densities[m].StateMap[1]-.1) > 1e-12 {
+		t.Fatal(densities[m].StateMap)
+	}
+	if math.Abs(densities[y].StateMap[1]-(.1+.9*.3)) > 1e-12 {
+		t.Fatal(densities[y].StateMap)
+	}
+}
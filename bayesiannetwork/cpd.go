@@ -0,0 +1,110 @@
+package bayesiannetwork
+
+import (
+	"math/rand"
+)
+
+// A CPD is the distribution of a Node's State given the States of its
+// Parents.  parentStates holds a State for each of the Node's Parents in
+// order.  Set Node.CPD to use a representation other than the Node's table.
+type CPD interface {
+	// the probability of the State given the States of the Parents
+	Probability(n *Node, parentStates []int, state int) float64
+	// draw a State given the States of the Parents
+	Sample(r *rand.Rand, n *Node, parentStates []int) int
+	// learn the parameters from the rows of the Dataset where the Node and
+	// its Parents are observed, with alpha pseudo-observations of every
+	// State
+	Fit(n *Node, ds *Dataset, alpha float64)
+	// the CPD as Factors whose product, summing out any Nodes other than n
+	// and its Parents, is the CPD over n and its Parents.  TableFactor is
+	// always valid.
+	Factors(n *Node) []*Factor
+}
+
+// the CPD of a Node as a Factor over the Node and its Parents, built by
+// asking the CPD for the probability of every joint State
+func TableFactor(n *Node, cpd CPD) *Factor {
+	f := NewFactor(append([]*Node{n}, n.Parents...)...)
+	for index := range f.Values {
+		states := f.states(index)
+		f.Values[index] = cpd.Probability(n, states[1:], states[0])
+	}
+	return f
+}
+
+// A TableCPD is a full conditional probability table with a Density per
+// joint State of the Parents, the first Parent's State varying fastest.  Its
+// size grows exponentially with the number of Parents.
+type TableCPD struct {
+	Densities []Density
+}
+
+// the index of the Density for the States of the Parents
+func tableIndex(n *Node, parentStates []int) int {
+	index, stride := 0, 1
+	for i, p := range n.Parents {
+		index += parentStates[i] * stride
+		stride *= p.States
+	}
+	return index
+}
+
+func (t *TableCPD) Probability(n *Node, parentStates []int, state int) float64 {
+	return t.Densities[tableIndex(n, parentStates)].StateMap[state]
+}
+
+func (t *TableCPD) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	return t.Densities[tableIndex(n, parentStates)].sample(r)
+}
+
+// learn the Densities by counting.  Parent States that are never observed
+// get a uniform distribution.
+func (t *TableCPD) Fit(n *Node, ds *Dataset, alpha float64) {
+	// the counts of the Node vary fastest, then the Parents in the same
+	// order as the Densities
+	counts := ds.Counts(append([]*Node{n}, n.Parents...)...)
+	t.Densities = make([]Density, len(counts.Values)/n.States)
+
+	for index := range t.Densities {
+		sums := make([]float64, n.States)
+		copy(sums, counts.Values[index*n.States:])
+		t.Densities[index] = NewDensity(normalizeCounts(sums, alpha, uniform(n.States))...)
+	}
+}
+
+func (t *TableCPD) Factors(n *Node) []*Factor {
+	return []*Factor{TableFactor(n, t)}
+}
+
+// A Deterministic CPD makes the Node's State a function of the States of
+// its Parents, e.g. a logical AND or a sum of counts.  It has nothing to
+// learn.
+type Deterministic struct {
+	Function func(parentStates []int) int
+}
+
+func (d *Deterministic) Probability(n *Node, parentStates []int, state int) float64 {
+	if d.Function(parentStates) == state {
+		return 1
+	}
+	return 0
+}
+
+func (d *Deterministic) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	return d.Function(parentStates)
+}
+
+func (d *Deterministic) Fit(n *Node, ds *Dataset, alpha float64) {}
+
+func (d *Deterministic) Factors(n *Node) []*Factor {
+	return []*Factor{TableFactor(n, d)}
+}
+
+// the CPD used for the Node: its CPD when set, otherwise its table
+func (n *Node) distribution() CPD {
+	if n.CPD != nil {
+		return n.CPD
+	}
+	return &TableCPD{Densities: n.cpd}
+}
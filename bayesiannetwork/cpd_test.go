@@ -0,0 +1,144 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// a user-defined CPD: the Node copies its only Parent with probability
+// Fidelity and is uniform otherwise
+type copyCPD struct {
+	Fidelity float64
+	fitted   bool
+}
+
+func (c *copyCPD) Probability(n *Node, parentStates []int, state int) float64 {
+	p := (1 - c.Fidelity) / float64(n.States)
+	if state == parentStates[0] {
+		p += c.Fidelity
+	}
+	return p
+}
+
+func (c *copyCPD) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	if r.Float64() < c.Fidelity {
+		return parentStates[0]
+	}
+	return r.Intn(n.States)
+}
+
+func (c *copyCPD) Fit(n *Node, ds *Dataset, alpha float64) { c.fitted = true }
+
+func (c *copyCPD) Factors(n *Node) []*Factor {
+	return []*Factor{TableFactor(n, c)}
+}
+
+// check ExactPosterior against enumeration for every Node
+func checkPosteriors(t *testing.T, net *BayesianNetwork, evidence map[*Node]int) {
+	posterior, err := net.ExactPosterior(net.Nodes, evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range net.Nodes {
+		expected := enumeratePosterior(net, n, evidence)
+		for s, p := range expected {
+			if math.Abs(posterior[n].StateMap[s]-p) > 1e-9 {
+				t.Fatal(n.Name, posterior[n].StateMap, expected)
+			}
+		}
+	}
+}
+
+func TestTableCPD(t *testing.T) {
+	network := initStudentNetwork()
+	var G *Node
+	for _, n := range network.Nodes {
+		if n.Name == "G" {
+			G = n
+		}
+	}
+	evidence := map[*Node]int{G: 1}
+	before, _ := network.ExactPosterior(network.Nodes, evidence)
+
+	// the same table as an explicit CPD gives the same answers
+	for _, n := range network.Nodes {
+		n.CPD = &TableCPD{Densities: n.cpd}
+	}
+	after, _ := network.ExactPosterior(network.Nodes, evidence)
+	for _, n := range network.Nodes {
+		for s := 0; s < n.States; s++ {
+			if math.Abs(before[n].StateMap[s]-after[n].StateMap[s]) > 1e-12 {
+				t.Fail()
+			}
+		}
+	}
+
+	// and is learned the same way
+	samples := studentSamples(500)
+	network.updateWeights(samples)
+	table := G.CPD.(*TableCPD)
+	G.CPD = nil
+	network.updateWeights(samples)
+	for i, d := range table.Densities {
+		for s := 0; s < G.States; s++ {
+			if d.StateMap[s] != G.cpd[i].StateMap[s] {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestDeterministicCPD(t *testing.T) {
+	a := &Node{Name: "a", States: 2, cpd: []Density{NewDensity(.3, .7)}}
+	b := &Node{Name: "b", States: 2, cpd: []Density{NewDensity(.6, .4)}}
+	xor := &Node{Name: "xor", States: 2, CPD: &Deterministic{
+		Function: func(parentStates []int) int { return parentStates[0] ^ parentStates[1] }}}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, a, b, xor)
+	net.AddEdge(a, xor)
+	net.AddEdge(b, xor)
+
+	checkPosteriors(t, net, map[*Node]int{xor: 1})
+	checkPosteriors(t, net, map[*Node]int{xor: 0, a: 1})
+
+	r := rand.New(rand.NewSource(1))
+	samples := make([]map[*Node]int, 0)
+	for i := 0; i < 100; i++ {
+		s := net.Sample(r)
+		if s[xor] != s[a]^s[b] {
+			t.Fatal(s)
+		}
+		samples = append(samples, s)
+	}
+	// learning leaves the function alone
+	net.updateWeights(samples)
+	if xor.conditionalProbability(map[*Node]int{a: 1, b: 1, xor: 0}) != 1 {
+		t.Fail()
+	}
+}
+
+func TestCustomCPD(t *testing.T) {
+	a := &Node{Name: "a", States: 3, cpd: []Density{NewDensity(.2, .3, .5)}}
+	custom := &copyCPD{Fidelity: .8}
+	b := &Node{Name: "b", States: 3, CPD: custom}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, a, b)
+	net.AddEdge(a, b)
+
+	checkPosteriors(t, net, map[*Node]int{b: 2})
+	r := rand.New(rand.NewSource(1))
+	samples := []map[*Node]int{net.Sample(r), net.Sample(r)}
+	net.updateWeights(samples)
+	if !custom.fitted {
+		t.Fail()
+	}
+	ll := net.datasetLikelihood(DatasetFromInstances(samples))
+	expected := 0.0
+	for _, s := range samples {
+		expected += math.Log(a.conditionalProbability(s) * b.conditionalProbability(s))
+	}
+	if math.Abs(ll-expected) > 1e-9 {
+		t.Fail()
+	}
+}
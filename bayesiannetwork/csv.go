@@ -0,0 +1,323 @@
+package bayesiannetwork
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// whether the first row of a delimited file names the columns
+type HeaderMode int
+
+const (
+	// a header is detected when the first row isn't like the rows below it
+	HeaderAuto HeaderMode = iota
+	HeaderPresent
+	HeaderAbsent
+)
+
+// Options for reading delimited files.  The zero value detects the
+// delimiter and header and treats "", "?", "NA" and "NaN" as missing.
+type CSVOptions struct {
+	// the field delimiter; 0 detects a tab or comma from the first line
+	Comma  rune
+	Header HeaderMode
+	// fields that are missing values, compared after trimming spaces
+	MissingValues []string
+	// columns (by name, or by number from 0 without a header) that are
+	// always categorical or always numeric; other columns are numeric when
+	// every value that isn't missing parses as a number
+	Categorical []string
+	Numeric     []string
+}
+
+var defaultMissingValues = []string{"", "?", "NA", "NaN"}
+
+// A Column of a delimited file.  Categorical columns have a State for each
+// row (Missing when missing) indexing Categories, which are in order of first
+// appearance; numeric columns have Values with NaN when missing.
+type Column struct {
+	Name       string
+	Categories []string
+	States     []int
+	Values     []float64
+}
+
+// whether the Column holds categories rather than numbers
+func (c *Column) Categorical() bool {
+	return c.States != nil
+}
+
+// A Table of columns read from a delimited file
+type Table struct {
+	Columns []*Column
+	Rows    int
+}
+
+// the Column with the given name, or nil
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// make a Dataset of the Table, with a Node for every column whose State
+// labels are the categories.  Numeric columns must be discretized first.
+func (t *Table) Dataset() (*Dataset, error) {
+	nodes := make([]*Node, len(t.Columns))
+	for i, c := range t.Columns {
+		if !c.Categorical() {
+			return nil, fmt.Errorf(
+				"bayesiannetwork: column %q is numeric and must be discretized", c.Name)
+		}
+		nodes[i] = &Node{Name: c.Name, States: len(c.Categories), StateLabels: c.Categories}
+	}
+
+	ds := NewDataset(nodes...)
+	for i, c := range t.Columns {
+		column := make([]int32, len(c.States))
+		for r, s := range c.States {
+			column[r] = int32(s)
+		}
+		ds.columns[i] = column
+	}
+	return ds, nil
+}
+
+// A ParseError is a problem with a single field (or, when Column is 0, a
+// whole row) of a delimited file.  Rows are lines of the file and Column is
+// the field's position in its row, both counting from 1.  Malformed quoting
+// and row lengths are problems with the whole row; for quoting, Err gives
+// the character position within the line.
+type ParseError struct {
+	Row    int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %d: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// every ParseError found in a file
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "bayesiannetwork: " + strings.Join(messages, "; ")
+}
+
+// read a delimited file; ".tsv" files are tab delimited unless the options
+// say otherwise
+func LoadCSV(path string, opts CSVOptions) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if opts.Comma == 0 && strings.EqualFold(filepath.Ext(path), ".tsv") {
+		opts.Comma = '\t'
+	}
+	return ReadCSV(f, opts)
+}
+
+// read a delimited table, inferring which columns are categorical.  Every
+// row with the wrong number of fields and every malformed or (in a Numeric
+// column) non-numeric field is reported in a ParseErrors.
+func ReadCSV(r io.Reader, opts CSVOptions) (*Table, error) {
+	reader := bufio.NewReader(r)
+	if opts.Comma == 0 {
+		opts.Comma = ','
+		if first, err := reader.Peek(reader.Size()); len(first) > 0 || err == nil {
+			line := string(first)
+			if i := strings.IndexByte(line, '\n'); i >= 0 {
+				line = line[:i]
+			}
+			if strings.Contains(line, "\t") {
+				opts.Comma = '\t'
+			}
+		}
+	}
+	if opts.MissingValues == nil {
+		opts.MissingValues = defaultMissingValues
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = opts.Comma
+	csvReader.FieldsPerRecord = -1
+
+	// read every record, keeping the line it started on
+	records := make([][]string, 0)
+	lines := make([]int, 0)
+	errs := make(ParseErrors, 0)
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		var csvErr *csv.ParseError
+		if errors.As(err, &csvErr) {
+			errs = append(errs, &ParseError{Row: csvErr.Line,
+				Err: fmt.Errorf("character %d: %w", csvErr.Column, csvErr.Err)})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		line, _ := csvReader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		if len(errs) > 0 {
+			return nil, errs
+		}
+		return nil, errors.New("bayesiannetwork: no rows")
+	}
+
+	nCols := len(records[0])
+	for i, record := range records {
+		if len(record) != nCols {
+			errs = append(errs, &ParseError{
+				Row: lines[i],
+				Err: fmt.Errorf("%d fields, expected %d", len(record), nCols)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	missing := make(map[string]bool)
+	for _, m := range opts.MissingValues {
+		missing[m] = true
+	}
+
+	header := opts.Header == HeaderPresent ||
+		(opts.Header == HeaderAuto && detectHeader(records, missing))
+	names := make([]string, nCols)
+	for c := range names {
+		names[c] = strconv.Itoa(c)
+		if header {
+			names[c] = records[0][c]
+		}
+	}
+	if header {
+		records, lines = records[1:], lines[1:]
+	}
+
+	table := &Table{Columns: make([]*Column, nCols), Rows: len(records)}
+	for c := 0; c < nCols; c++ {
+		column := &Column{Name: names[c]}
+		numeric := contains(opts.Numeric, names[c]) ||
+			(!contains(opts.Categorical, names[c]) && isNumericColumn(records, c, missing))
+		if numeric {
+			column.Values = make([]float64, len(records))
+			for r, record := range records {
+				if missing[record[c]] {
+					column.Values[r] = math.NaN()
+					continue
+				}
+				v, err := strconv.ParseFloat(record[c], 64)
+				if err != nil {
+					errs = append(errs, &ParseError{Row: lines[r], Column: c + 1,
+						Err: fmt.Errorf("%q is not a number", record[c])})
+				}
+				column.Values[r] = v
+			}
+		} else {
+			column.Categories = make([]string, 0)
+			column.States = make([]int, len(records))
+			index := make(map[string]int)
+			for r, record := range records {
+				if missing[record[c]] {
+					column.States[r] = Missing
+					continue
+				}
+				s, exists := index[record[c]]
+				if !exists {
+					s = len(column.Categories)
+					index[record[c]] = s
+					column.Categories = append(column.Categories, record[c])
+				}
+				column.States[r] = s
+			}
+		}
+		table.Columns[c] = column
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return table, nil
+}
+
+// whether every value of column c that isn't missing is a number
+func isNumericColumn(records [][]string, c int, missing map[string]bool) bool {
+	for _, record := range records {
+		if missing[record[c]] {
+			continue
+		}
+		if _, err := strconv.ParseFloat(record[c], 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// the first row is a header when none of its fields are missing or numbers,
+// and either a column is numeric below the first row, or no value of the
+// first row appears again in its column
+func detectHeader(records [][]string, missing map[string]bool) bool {
+	if len(records) < 2 {
+		return false
+	}
+	for _, field := range records[0] {
+		if _, err := strconv.ParseFloat(field, 64); missing[field] || err == nil {
+			return false
+		}
+	}
+
+	repeated := false
+	for c, field := range records[0] {
+		if isNumericColumn(records[1:], c, missing) {
+			return true
+		}
+		for _, record := range records[1:] {
+			if record[c] == field {
+				repeated = true
+			}
+		}
+	}
+	return !repeated
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package bayesiannetwork
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := `size, weight, species
+small, 1.5, cat
+large, 30, dog
+?, 2.25, cat
+medium, NA, bird
+`
+	table, err := ReadCSV(strings.NewReader(input), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Rows != 4 || len(table.Columns) != 3 {
+		t.Fatal(table.Rows, len(table.Columns))
+	}
+
+	size := table.Column("size")
+	if !size.Categorical() || len(size.Categories) != 3 {
+		t.Fail()
+	}
+	if size.States[0] != 0 || size.States[2] != Missing || size.Categories[2] != "medium" {
+		t.Fail()
+	}
+
+	weight := table.Column("weight")
+	if weight.Categorical() || weight.Values[1] != 30 || !math.IsNaN(weight.Values[3]) {
+		t.Fail()
+	}
+
+	// numeric columns have to be discretized first
+	if _, err := table.Dataset(); err == nil {
+		t.Fail()
+	}
+	table.Columns = []*Column{size, table.Column("species")}
+	ds, err := table.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	species := ds.Node("species")
+	if species.States != 3 || ds.Label(species, ds.Value(3, species)) != "bird" {
+		t.Fail()
+	}
+}
+
+func TestReadCSVHeaderDetection(t *testing.T) {
+	// numbers are never a header
+	table, err := ReadCSV(strings.NewReader("1,2\n3,4\n"), CSVOptions{})
+	if err != nil || table.Rows != 2 || table.Column("0") == nil {
+		t.Fail()
+	}
+
+	// a categorical first row whose values repeat is data
+	table, _ = ReadCSV(strings.NewReader("a,x\nb,x\na,y\n"), CSVOptions{})
+	if table.Rows != 3 {
+		t.Fail()
+	}
+	// unless the options say otherwise
+	table, _ = ReadCSV(strings.NewReader("a,x\nb,x\na,y\n"),
+		CSVOptions{Header: HeaderPresent})
+	if table.Rows != 2 || table.Column("x") == nil {
+		t.Fail()
+	}
+
+	// unique names above categorical columns are a header
+	table, _ = ReadCSV(strings.NewReader("color,shape\nred,round\nblue,round\n"),
+		CSVOptions{})
+	if table.Rows != 2 || table.Column("shape") == nil {
+		t.Fail()
+	}
+}
+
+func TestReadCSVOptions(t *testing.T) {
+	input := "a\tb\n1\t-\n2\t3\n"
+	table, err := ReadCSV(strings.NewReader(input), CSVOptions{
+		MissingValues: []string{"-"},
+		Categorical:   []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := table.Column("a"), table.Column("b")
+	if !a.Categorical() || a.Categories[1] != "2" {
+		t.Fail()
+	}
+	if b.Categorical() || !math.IsNaN(b.Values[0]) {
+		t.Fail()
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	input := "x,y\n1,2\n3\n4,five\n6,7,8\n"
+	_, err := ReadCSV(strings.NewReader(input), CSVOptions{})
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 2 {
+		t.Fatal(err)
+	}
+	if parseErrors[0].Row != 3 || parseErrors[1].Row != 5 {
+		t.Fail()
+	}
+
+	input = "x,y\n1,2\n3,4\n4,five\n"
+	_, err = ReadCSV(strings.NewReader(input), CSVOptions{Numeric: []string{"y"}})
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 {
+		t.Fatal(err)
+	}
+	if parseErrors[0].Row != 4 || parseErrors[0].Column != 2 {
+		t.Fail()
+	}
+	if !strings.Contains(err.Error(), "row 4, column 2") {
+		t.Fail()
+	}
+
+	// malformed quoting is a problem with the whole row
+	input = "x,y\n1,2\n3,\"4\"5\n"
+	_, err = ReadCSV(strings.NewReader(input), CSVOptions{})
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 {
+		t.Fatal(err)
+	}
+	if parseErrors[0].Row != 3 || parseErrors[0].Column != 0 || !errors.Is(parseErrors[0], csv.ErrQuote) {
+		t.Fatal(parseErrors[0])
+	}
+
+	if _, err := ReadCSV(strings.NewReader(""), CSVOptions{}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	if _, err := LoadCSV(filepath.Join(t.TempDir(), "missing.csv"), CSVOptions{}); err == nil {
+		t.Fail()
+	}
+
+	path := filepath.Join(t.TempDir(), "data.tsv")
+	os.WriteFile(path, []byte("a b\tc\nx y\t1\n"), 0644)
+	table, err := LoadCSV(path, CSVOptions{Header: HeaderPresent})
+	if err != nil || table.Column("a b") == nil || table.Column("c") == nil {
+		t.Fail()
+	}
+}
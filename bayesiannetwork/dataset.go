@@ -0,0 +1,243 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"sort"
+)
+
+// the State of a value that wasn't observed
+const Missing = -1
+
+// A Dataset holds discrete observations by column, one column per Node.
+// Compared to []map[*Node]int it stores 4 bytes per value instead of a map
+// per row, and counting the joint States of a few Nodes only touches their
+// columns.
+type Dataset struct {
+	Nodes   []*Node
+	columns [][]int32
+	index   map[*Node]int
+}
+
+// initialize an empty Dataset with a column for each Node
+func NewDataset(nodes ...*Node) *Dataset {
+	ds := &Dataset{
+		Nodes:   nodes,
+		columns: make([][]int32, len(nodes)),
+		index:   make(map[*Node]int, len(nodes))}
+	for i, n := range nodes {
+		ds.columns[i] = make([]int32, 0)
+		ds.index[n] = i
+	}
+	return ds
+}
+
+// make a Dataset from a matrix of States (Missing for unobserved values) with
+// a new Node for each column.  The States of each Node cover the largest
+// observed State and the labels for the column, if given.
+func DatasetFromMatrix(
+	data [][]int,
+	featureNames []string,
+	stateLabels ...[]string) (*Dataset, error) {
+
+	nodes := make([]*Node, len(featureNames))
+	for i, name := range featureNames {
+		nodes[i] = &Node{Name: name}
+		if i < len(stateLabels) {
+			nodes[i].StateLabels = stateLabels[i]
+			nodes[i].States = len(stateLabels[i])
+		}
+	}
+
+	ds := NewDataset(nodes...)
+	for r, row := range data {
+		if err := ds.Append(row...); err != nil {
+			return nil, fmt.Errorf("bayesiannetwork: row %d: %v", r, err)
+		}
+	}
+	ds.inferNodeStates()
+	return ds, nil
+}
+
+// make a Dataset from observations keyed by Node.  There is a column for
+// every Node in any observation, ordered by name, and Nodes absent from an
+// observation are Missing.  The Nodes are shared with the observations and
+// their States are left unchanged.
+func DatasetFromInstances(data []map[*Node]int) *Dataset {
+	seen := make(map[*Node]bool)
+	nodes := make([]*Node, 0)
+	for _, instance := range data {
+		for n, _ := range instance {
+			if !seen[n] {
+				seen[n] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return datasetOf(nodes, data)
+}
+
+// a Dataset with a column for each of the Nodes from the observations
+func datasetOf(nodes []*Node, data []map[*Node]int) *Dataset {
+	ds := NewDataset(nodes...)
+	for i, n := range nodes {
+		column := make([]int32, len(data))
+		for r, instance := range data {
+			if s, observed := instance[n]; observed {
+				column[r] = int32(s)
+			} else {
+				column[r] = Missing
+			}
+		}
+		ds.columns[i] = column
+	}
+	return ds
+}
+
+// the number of rows
+func (ds *Dataset) Len() int {
+	if len(ds.columns) == 0 {
+		return 0
+	}
+	return len(ds.columns[0])
+}
+
+// the Node of the column with the given name, or nil
+func (ds *Dataset) Node(name string) *Node {
+	for _, n := range ds.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// whether the Dataset has a column for n
+func (ds *Dataset) Has(n *Node) bool {
+	_, exists := ds.index[n]
+	return exists
+}
+
+// add a row with a State (or Missing) for every column in order
+func (ds *Dataset) Append(states ...int) error {
+	if len(states) != len(ds.Nodes) {
+		return fmt.Errorf(
+			"bayesiannetwork: %d states for %d columns", len(states), len(ds.Nodes))
+	}
+	for i, s := range states {
+		if s < Missing {
+			return fmt.Errorf(
+				"bayesiannetwork: invalid state %d of node %q", s, ds.Nodes[i].Name)
+		}
+	}
+	for i, s := range states {
+		ds.columns[i] = append(ds.columns[i], int32(s))
+	}
+	return nil
+}
+
+// the State of n in a row; Missing if n isn't a column
+func (ds *Dataset) Value(row int, n *Node) int {
+	i, exists := ds.index[n]
+	if !exists {
+		return Missing
+	}
+	return int(ds.columns[i][row])
+}
+
+// the observed States of a row keyed by Node; Missing values are left out
+func (ds *Dataset) Row(row int) map[*Node]int {
+	instance := make(map[*Node]int, len(ds.Nodes))
+	for i, n := range ds.Nodes {
+		if s := ds.columns[i][row]; s != Missing {
+			instance[n] = int(s)
+		}
+	}
+	return instance
+}
+
+// convert the Dataset to observations keyed by Node
+func (ds *Dataset) Instances() []map[*Node]int {
+	instances := make([]map[*Node]int, ds.Len())
+	for r := range instances {
+		instances[r] = ds.Row(r)
+	}
+	return instances
+}
+
+// the label of a State of n, or the State's number when n has no labels
+func (ds *Dataset) Label(n *Node, state int) string {
+	if state == Missing {
+		return "?"
+	}
+	return n.StateLabel(state)
+}
+
+// the rows in [start, end), sharing memory with the Dataset
+func (ds *Dataset) Slice(start, end int) *Dataset {
+	out := &Dataset{Nodes: ds.Nodes, columns: make([][]int32, len(ds.columns)), index: ds.index}
+	for i, column := range ds.columns {
+		out.columns[i] = column[start:end:end]
+	}
+	return out
+}
+
+// a copy of the given rows, in order
+func (ds *Dataset) Subset(rows []int) *Dataset {
+	out := &Dataset{Nodes: ds.Nodes, columns: make([][]int32, len(ds.columns)), index: ds.index}
+	for i, column := range ds.columns {
+		out.columns[i] = make([]int32, len(rows))
+		for j, r := range rows {
+			out.columns[i][j] = column[r]
+		}
+	}
+	return out
+}
+
+// count the rows with each joint State of the Nodes.  Rows where any of the
+// Nodes is Missing aren't counted.  Panics if a Node has no column or a
+// value isn't one of its States.
+func (ds *Dataset) Counts(nodes ...*Node) *Factor {
+	counts := NewFactor(nodes...)
+	strides := counts.strides()
+	columns := make([][]int32, len(nodes))
+	for i, n := range nodes {
+		c, exists := ds.index[n]
+		if !exists {
+			panic(fmt.Sprintf("bayesiannetwork: the dataset has no column for node %q", n.Name))
+		}
+		columns[i] = ds.columns[c]
+	}
+
+rows:
+	for r := 0; r < ds.Len(); r++ {
+		index := 0
+		for i, column := range columns {
+			s := column[r]
+			if s == Missing {
+				continue rows
+			}
+			if int(s) >= nodes[i].States {
+				panic(fmt.Sprintf("bayesiannetwork: row %d has state %d of node %q, out of range [0, %d)",
+					r, s, nodes[i].Name, nodes[i].States))
+			}
+			index += int(s) * strides[i]
+		}
+		counts.Values[index]++
+	}
+	return counts
+}
+
+// set the States of every Node to cover its largest observed State and its
+// labels
+func (ds *Dataset) inferNodeStates() {
+	for i, n := range ds.Nodes {
+		states := len(n.StateLabels)
+		for _, s := range ds.columns[i] {
+			if int(s)+1 > states {
+				states = int(s) + 1
+			}
+		}
+		n.States = states
+	}
+}
@@ -0,0 +1,177 @@
+package bayesiannetwork
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func makeDataset(t *testing.T) *Dataset {
+	data := [][]int{
+		{0, 0, 1},
+		{1, 0, 2},
+		{0, Missing, 0},
+		{1, 1, 2},
+		{1, 1, Missing}}
+	ds, err := DatasetFromMatrix(data, []string{"A", "B", "C"},
+		[]string{"low", "high"}, nil, []string{"x", "y", "z", "w"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestDatasetFromMatrix(t *testing.T) {
+	ds := makeDataset(t)
+	A, B, C := ds.Node("A"), ds.Node("B"), ds.Node("C")
+
+	if ds.Len() != 5 || A == nil || ds.Node("D") != nil {
+		t.Fatal(ds.Len())
+	}
+	// States come from the labels when given, otherwise from the data
+	if A.States != 2 || B.States != 2 || C.States != 4 {
+		t.Fail()
+	}
+	if ds.Value(1, C) != 2 || ds.Value(2, B) != Missing {
+		t.Fail()
+	}
+	if ds.Label(A, 1) != "high" || ds.Label(B, 1) != "1" || ds.Label(C, Missing) != "?" {
+		t.Fail()
+	}
+
+	if _, err := DatasetFromMatrix([][]int{{0, 1}}, []string{"A"}); err == nil {
+		t.Fail()
+	}
+	if _, err := DatasetFromMatrix([][]int{{-2}}, []string{"A"}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestDatasetCounts(t *testing.T) {
+	ds := makeDataset(t)
+	A, B := ds.Node("A"), ds.Node("B")
+
+	// the row where B is missing isn't counted
+	counts := ds.Counts(A, B)
+	solution := map[[2]int]float64{{0, 0}: 1, {1, 0}: 1, {0, 1}: 0, {1, 1}: 2}
+	for states, count := range solution {
+		if counts.Value(map[*Node]int{A: states[0], B: states[1]}) != count {
+			t.Fail()
+		}
+	}
+	if ds.Counts(A).Values[1] != 3 {
+		t.Fail()
+	}
+
+	// a Node without a column and a State out of range panic
+	outside := &Node{Name: "outside", States: 2}
+	B.States = 1
+	for _, nodes := range [][]*Node{{A, outside}, {A, B}} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("expected a panic counting", nodes)
+				}
+			}()
+			ds.Counts(nodes...)
+		}()
+	}
+}
+
+func TestDatasetSliceSubset(t *testing.T) {
+	ds := makeDataset(t)
+	C := ds.Node("C")
+
+	slice := ds.Slice(1, 3)
+	if slice.Len() != 2 || slice.Value(0, C) != 2 {
+		t.Fail()
+	}
+	// appending to the original doesn't change the slice
+	ds.Append(0, 0, 3)
+	if slice.Len() != 2 {
+		t.Fail()
+	}
+
+	subset := ds.Subset([]int{4, 0})
+	if subset.Len() != 2 || subset.Value(0, C) != Missing || subset.Value(1, C) != 1 {
+		t.Fail()
+	}
+}
+
+func TestDatasetInstances(t *testing.T) {
+	ds := makeDataset(t)
+	instances := ds.Instances()
+	if len(instances) != 5 || len(instances[2]) != 2 {
+		t.Fail()
+	}
+
+	back := DatasetFromInstances(instances)
+	if back.Len() != 5 || len(back.Nodes) != 3 {
+		t.Fatal(back.Nodes)
+	}
+	for r := 0; r < ds.Len(); r++ {
+		for _, n := range ds.Nodes {
+			if ds.Value(r, n) != back.Value(r, n) {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestDatasetMutualInformation(t *testing.T) {
+	samples := studentSamples(500)
+	ds := DatasetFromInstances(samples)
+	ds.inferNodeStates()
+	for i, a := range ds.Nodes {
+		for _, b := range ds.Nodes[i+1:] {
+			if math.Abs(ds.MutualInformation(a, b)-MutualInformation(samples, a, b)) > 1e-9 {
+				t.Fail()
+			}
+			c := ds.Nodes[(i+3)%5]
+			if c == b {
+				continue
+			}
+			if math.Abs(ds.ConditionalMutualInformation(a, b, c)-
+				ConditionalMutualInformation(samples, a, b, c)) > 1e-9 {
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestLearnFromDataset(t *testing.T) {
+	ds := DatasetFromInstances(studentSamples(500))
+	class := ds.Node("G")
+
+	net, err := InferBayesianNetworkFromDataset(context.Background(), ds, 20, nil)
+	if err != nil || HasCycles(net) || len(net.Nodes) != 5 {
+		t.Fail()
+	}
+	// the likelihood from counts matches the likelihood of the instances
+	ll := net.ModelLikelihood(ds.Instances())
+	if math.Abs(net.datasetLikelihood(ds)-ll) > 1e-6 {
+		t.Fail()
+	}
+
+	classifier := NewClassifier(TANFromDataset(ds, class), class)
+	classifier.FitDataset(ds.Slice(0, 250))
+	m, err := classifier.Evaluate(ds.Slice(250, 500).Instances())
+	if err != nil || m.Accuracy() <= 0 {
+		t.Fail()
+	}
+
+	if len(ChowLiuFromDataset(ds).Edges()) != 4 {
+		t.Fail()
+	}
+	if len(KDBFromDataset(ds, class, 1).Edges()) != 7 {
+		t.Fail()
+	}
+	if len(NaiveBayesFromDataset(ds, class).Edges()) != 4 {
+		t.Fail()
+	}
+	net, err = InferClassifierNetworkFromDataset(context.Background(), ds, class,
+		10, ConditionalLikelihoodScore, nil)
+	if err != nil || HasCycles(net) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,448 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A DBN is a dynamic bayesian network: the same Nodes repeated in every
+// time slice, a prior network for the first slice and a two-slice
+// transition network for each later slice given the one before it.
+// Evidence and data are given per slice, keyed by the Nodes passed to
+// NewDBN; the Nodes of the prior and transition networks are copies that
+// hold the CPDs (see PriorNode and TransitionNode).
+type DBN struct {
+	Nodes []*Node
+
+	prior      *BayesianNetwork
+	transition *BayesianNetwork
+	// the copy of each Node in the prior network, in the current slice of
+	// the transition network, and in its previous slice
+	priorNode    map[*Node]*Node
+	currentNode  map[*Node]*Node
+	previousNode map[*Node]*Node
+}
+
+// copy the name and States of a Node without its topology or CPD
+func copyNode(n *Node, name string) *Node {
+	return &Node{Name: name, States: n.States, StateLabels: n.StateLabels, Position: n.Position}
+}
+
+// initialize a DBN over the Nodes of a slice, without edges
+func NewDBN(nodes ...*Node) *DBN {
+	d := &DBN{
+		Nodes:        nodes,
+		prior:        NewBayesianNetwork(),
+		transition:   NewBayesianNetwork(),
+		priorNode:    make(map[*Node]*Node),
+		currentNode:  make(map[*Node]*Node),
+		previousNode: make(map[*Node]*Node)}
+	for _, n := range nodes {
+		d.priorNode[n] = copyNode(n, n.Name)
+		d.currentNode[n] = copyNode(n, n.Name)
+		d.previousNode[n] = copyNode(n, n.Name+"'")
+		d.prior.Nodes = append(d.prior.Nodes, d.priorNode[n])
+		d.transition.Nodes = append(d.transition.Nodes, d.currentNode[n])
+	}
+	return d
+}
+
+// the Node of n in the first slice, whose CPD is given the Parents within
+// the first slice
+func (d *DBN) PriorNode(n *Node) *Node { return d.priorNode[n] }
+
+// the Node of n in later slices, whose CPD is given the Parents within the
+// slice and in the previous slice
+func (d *DBN) TransitionNode(n *Node) *Node { return d.currentNode[n] }
+
+// add an edge between Nodes within every slice
+func (d *DBN) AddEdge(p, c *Node) {
+	d.prior.AddEdge(d.priorNode[p], d.priorNode[c])
+	d.transition.AddEdge(d.currentNode[p], d.currentNode[c])
+}
+
+// add an edge from p in each slice to c in the next slice
+func (d *DBN) AddTemporalEdge(p, c *Node) {
+	d.transition.AddEdge(d.previousNode[p], d.currentNode[c])
+}
+
+// check that the slices are acyclic and that every Node has a CPD that
+// isn't stale
+func (d *DBN) check() error {
+	if HasCycles(d.prior) || HasCycles(d.transition) {
+		return errors.New("bayesiannetwork: dynamic network has cycles within a slice")
+	}
+	for _, n := range d.Nodes {
+		for _, copied := range []*Node{d.priorNode[n], d.currentNode[n]} {
+			if copied.CPD == nil && len(copied.cpd) == 0 {
+				return fmt.Errorf("bayesiannetwork: node %q has no CPD", n.Name)
+			}
+			if copied.stale {
+				return staleError(n)
+			}
+		}
+	}
+	return nil
+}
+
+// the copies of the Nodes used for slice t
+func (d *DBN) role(t int) map[*Node]*Node {
+	if t == 0 {
+		return d.priorNode
+	}
+	return d.currentNode
+}
+
+// the Nodes of a slice that are Parents of the next slice; the forward and
+// backward messages are over these
+func (d *DBN) interfaceNodes() []*Node {
+	nodes := make([]*Node, 0)
+	for _, n := range d.Nodes {
+		if len(d.previousNode[n].Children) > 0 {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// a copy of a Factor over other Nodes with the same States
+func rename(f *Factor, mapping map[*Node]*Node) *Factor {
+	nodes := make([]*Node, len(f.Nodes))
+	for i, n := range f.Nodes {
+		nodes[i] = mapping[n]
+	}
+	return &Factor{Nodes: nodes, Values: f.Values}
+}
+
+// from the copies in one role to the copies in another
+func between(from, to map[*Node]*Node) map[*Node]*Node {
+	mapping := make(map[*Node]*Node, len(from))
+	for n, copied := range from {
+		mapping[copied] = to[n]
+	}
+	return mapping
+}
+
+// the CPDs of slice t as Factors reduced by the evidence of the slice
+func (d *DBN) sliceFactors(t int, evidence map[*Node]int) ([]*Factor, error) {
+	role := d.role(t)
+	reduced := make(map[*Node]int, len(evidence))
+	for n, s := range evidence {
+		reduced[role[n]] = s
+	}
+	if err := validateEvidence(reduced); err != nil {
+		return nil, err
+	}
+	nodes := d.transition.Nodes
+	if t == 0 {
+		nodes = d.prior.Nodes
+	}
+	factors := make([]*Factor, 0, len(nodes))
+	for _, n := range nodes {
+		for _, f := range n.factors() {
+			factors = append(factors, f.Reduce(reduced))
+		}
+	}
+	return factors, nil
+}
+
+// one step of the interface algorithm: the Factors of slice t including the
+// message from the slice before it (nil at the start), and the normalized
+// message to the next slice over the previous copies of the interface
+// Nodes, with the log of its normalizing constant
+func (d *DBN) forwardStep(
+	t int,
+	evidence map[*Node]int,
+	message *Factor) ([]*Factor, *Factor, float64, error) {
+
+	factors, err := d.sliceFactors(t, evidence)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if message != nil {
+		factors = append(factors, message)
+	}
+	role := d.role(t)
+	keep := make(map[*Node]bool)
+	for _, n := range d.interfaceNodes() {
+		keep[role[n]] = true
+	}
+	next := eliminate(factors, keep, false)
+	z := next.Normalize()
+	if z <= 0 {
+		return nil, nil, 0, ErrImpossibleEvidence
+	}
+	return factors, rename(next, between(role, d.previousNode)), math.Log(z), nil
+}
+
+// the distribution of every Node of slice t from its Factors
+func (d *DBN) marginals(
+	t int,
+	factors []*Factor,
+	evidence map[*Node]int) map[*Node]Density {
+
+	role := d.role(t)
+	densities := make(map[*Node]Density, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if s, observed := evidence[n]; observed {
+			probs := make([]float64, n.States)
+			probs[s] = 1
+			densities[n] = NewDensity(probs...)
+			continue
+		}
+		f := eliminate(factors, map[*Node]bool{role[n]: true}, false)
+		f.Normalize()
+		probs := make([]float64, n.States)
+		for s := range probs {
+			probs[s] = math.Max(0, f.Value(map[*Node]int{role[n]: s}))
+		}
+		densities[n] = NewDensity(probs...)
+	}
+	return densities
+}
+
+// filtering: the distribution of every Node in each slice given the
+// evidence of that slice and the slices before it
+func (d *DBN) Filter(evidence []map[*Node]int) ([]map[*Node]Density, error) {
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	filtered := make([]map[*Node]Density, len(evidence))
+	var message *Factor
+	for t := range evidence {
+		factors, next, _, err := d.forwardStep(t, evidence[t], message)
+		if err != nil {
+			return nil, err
+		}
+		filtered[t] = d.marginals(t, factors, evidence[t])
+		message = next
+	}
+	return filtered, nil
+}
+
+// the log probability of the evidence of every slice
+func (d *DBN) LogLikelihood(evidence []map[*Node]int) (float64, error) {
+	if err := d.check(); err != nil {
+		return 0, err
+	}
+	ll := 0.0
+	var message *Factor
+	for t := range evidence {
+		_, next, logZ, err := d.forwardStep(t, evidence[t], message)
+		if err != nil {
+			return 0, err
+		}
+		ll += logZ
+		message = next
+	}
+	return ll, nil
+}
+
+// smoothing: the distribution of every Node in each slice given the
+// evidence of all slices, by the forward-backward interface algorithm
+func (d *DBN) Smooth(evidence []map[*Node]int) ([]map[*Node]Density, error) {
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	// forward, keeping the Factors of each slice with its incoming message
+	slices := make([][]*Factor, len(evidence))
+	var message *Factor
+	for t := range evidence {
+		factors, next, _, err := d.forwardStep(t, evidence[t], message)
+		if err != nil {
+			return nil, err
+		}
+		slices[t] = factors
+		message = next
+	}
+
+	// backward, from the last slice: the message to slice t - 1 is over the
+	// previous copies of the interface Nodes
+	keep := make(map[*Node]bool)
+	for _, n := range d.interfaceNodes() {
+		keep[d.previousNode[n]] = true
+	}
+	smoothed := make([]map[*Node]Density, len(evidence))
+	var backward *Factor
+	for t := len(evidence) - 1; t >= 0; t-- {
+		factors := slices[t]
+		if backward != nil {
+			factors = append(factors, rename(backward, between(d.previousNode, d.role(t))))
+		}
+		smoothed[t] = d.marginals(t, factors, evidence[t])
+
+		if t > 0 {
+			// the slice without the forward message
+			sliceFactors, _ := d.sliceFactors(t, evidence[t])
+			if backward != nil {
+				sliceFactors = append(sliceFactors,
+					rename(backward, between(d.previousNode, d.currentNode)))
+			}
+			backward = eliminate(sliceFactors, keep, false)
+			backward.Normalize()
+		}
+	}
+	return smoothed, nil
+}
+
+// prediction: the distribution of every Node in each of the steps slices
+// that follow the evidence
+func (d *DBN) Predict(evidence []map[*Node]int, steps int) ([]map[*Node]Density, error) {
+	if err := d.check(); err != nil {
+		return nil, err
+	}
+	var message *Factor
+	for t := range evidence {
+		_, next, _, err := d.forwardStep(t, evidence[t], message)
+		if err != nil {
+			return nil, err
+		}
+		message = next
+	}
+	predicted := make([]map[*Node]Density, steps)
+	for k := 0; k < steps; k++ {
+		t := len(evidence) + k
+		factors, next, _, err := d.forwardStep(t, map[*Node]int{}, message)
+		if err != nil {
+			return nil, err
+		}
+		predicted[k] = d.marginals(t, factors, map[*Node]int{})
+		message = next
+	}
+	return predicted, nil
+}
+
+// unroll the DBN into a bayesian net with a copy of the Nodes for each of T
+// slices.  slices[t][i] is the copy of Nodes[i] in slice t.  The copies
+// share the CPDs of the DBN, except for trees, which are copied to split on
+// the copies of their Parents.
+func (d *DBN) Unroll(T int) (net *BayesianNetwork, slices [][]*Node) {
+	net = NewBayesianNetwork()
+	slices = make([][]*Node, T)
+	unrolled := make([]map[*Node]*Node, T)
+	for t := 0; t < T; t++ {
+		slices[t] = make([]*Node, len(d.Nodes))
+		unrolled[t] = make(map[*Node]*Node)
+		for i, n := range d.Nodes {
+			copied := copyNode(n, fmt.Sprintf("%s_%d", n.Name, t))
+			slices[t][i] = copied
+			unrolled[t][n] = copied
+			net.Nodes = append(net.Nodes, copied)
+		}
+	}
+
+	// the slice and Node that each copy in the prior and transition networks
+	// stands for
+	for t := 0; t < T; t++ {
+		// from the copies in the prior or transition network to the slices
+		mapping := make(map[*Node]*Node, 2*len(d.Nodes))
+		for _, m := range d.Nodes {
+			mapping[d.role(t)[m]] = unrolled[t][m]
+			if t > 0 {
+				mapping[d.previousNode[m]] = unrolled[t-1][m]
+			}
+		}
+		for _, n := range d.Nodes {
+			source := d.role(t)[n]
+			for _, p := range source.Parents {
+				if copied, exists := mapping[p]; exists {
+					net.AddEdge(copied, unrolled[t][n])
+				}
+			}
+			unrolled[t][n].cpd = source.cpd
+			unrolled[t][n].CPD = copiedCPD(source.CPD, mapping)
+		}
+	}
+	return net, slices
+}
+
+// the most likely joint State of every Node in every slice given the
+// evidence (the Viterbi path when there is a single hidden Node per slice),
+// and its log probability jointly with the evidence
+func (d *DBN) Viterbi(evidence []map[*Node]int) ([]map[*Node]int, float64, error) {
+	if err := d.check(); err != nil {
+		return nil, 0, err
+	}
+	net, slices := d.Unroll(len(evidence))
+	unrolled := make(map[*Node]int)
+	for t, e := range evidence {
+		for i, n := range d.Nodes {
+			if s, observed := e[n]; observed {
+				unrolled[slices[t][i]] = s
+			}
+		}
+	}
+	assignment, p, err := net.MostProbableExplanation(unrolled)
+	if err != nil {
+		return nil, 0, err
+	}
+	path := make([]map[*Node]int, len(evidence))
+	for t := range path {
+		path[t] = make(map[*Node]int, len(d.Nodes))
+		for i, n := range d.Nodes {
+			path[t][n] = assignment[slices[t][i]]
+		}
+	}
+	return path, math.Log(p), nil
+}
+
+// learn the CPDs of the prior network from the first slice of each sequence
+// and the CPDs of the transition network from each pair of consecutive
+// slices, with alpha pseudo-observations of every State.  Nodes gain the
+// States seen in the sequences.  Slices where a Node or one of its Parents
+// is missing are ignored for that Node.
+func (d *DBN) Fit(sequences [][]map[*Node]int, alpha float64) error {
+	if HasCycles(d.prior) || HasCycles(d.transition) {
+		return errors.New("bayesiannetwork: dynamic network has cycles within a slice")
+	}
+	for _, n := range d.Nodes {
+		for _, sequence := range sequences {
+			for _, instance := range sequence {
+				if s, observed := instance[n]; observed && s+1 > n.States {
+					n.States = s + 1
+				}
+			}
+		}
+		d.priorNode[n].States = n.States
+		d.currentNode[n].States = n.States
+		d.previousNode[n].States = n.States
+	}
+
+	first := make([]map[*Node]int, 0, len(sequences))
+	pairs := make([]map[*Node]int, 0)
+	for _, sequence := range sequences {
+		for t, instance := range sequence {
+			if t == 0 {
+				row := make(map[*Node]int)
+				for n, s := range instance {
+					row[d.priorNode[n]] = s
+				}
+				first = append(first, row)
+				continue
+			}
+			row := make(map[*Node]int)
+			for n, s := range instance {
+				row[d.currentNode[n]] = s
+			}
+			for n, s := range sequence[t-1] {
+				row[d.previousNode[n]] = s
+			}
+			pairs = append(pairs, row)
+		}
+	}
+
+	priorData := datasetOf(d.prior.Nodes, first)
+	for _, n := range d.prior.Nodes {
+		n.fit(priorData, alpha)
+	}
+	transitionNodes := append([]*Node{}, d.transition.Nodes...)
+	for _, n := range d.Nodes {
+		transitionNodes = append(transitionNodes, d.previousNode[n])
+	}
+	transitionData := datasetOf(transitionNodes, pairs)
+	for _, n := range d.transition.Nodes {
+		n.fit(transitionData, alpha)
+	}
+	return nil
+}
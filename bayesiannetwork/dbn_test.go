@@ -0,0 +1,283 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// a hidden Markov model with a second hidden chain: w -> h within the
+// slice, h and w persist over time and o observes h
+func initDBN() (*DBN, *Node, *Node, *Node) {
+	h := &Node{Name: "h", States: 2}
+	w := &Node{Name: "w", States: 2}
+	o := &Node{Name: "o", States: 3}
+	d := NewDBN(h, w, o)
+	d.AddEdge(w, h)
+	d.AddEdge(h, o)
+	d.AddTemporalEdge(h, h)
+	d.AddTemporalEdge(w, w)
+
+	d.PriorNode(w).cpd = []Density{NewDensity(.6, .4)}
+	d.PriorNode(h).cpd = []Density{NewDensity(.7, .3), NewDensity(.2, .8)}
+	d.PriorNode(o).cpd = []Density{NewDensity(.7, .2, .1), NewDensity(.1, .3, .6)}
+	// parents of h in the transition: w then h'
+	d.TransitionNode(w).cpd = []Density{NewDensity(.9, .1), NewDensity(.3, .7)}
+	d.TransitionNode(h).cpd = []Density{
+		NewDensity(.8, .2), NewDensity(.4, .6), NewDensity(.3, .7), NewDensity(.1, .9)}
+	d.TransitionNode(o).cpd = d.PriorNode(o).cpd
+	return d, h, w, o
+}
+
+func dbnEvidence(o *Node) []map[*Node]int {
+	return []map[*Node]int{{o: 0}, {o: 2}, {}, {o: 2}, {o: 1}}
+}
+
+// the evidence for the unrolled network
+func unrolledEvidence(d *DBN, slices [][]*Node, evidence []map[*Node]int) map[*Node]int {
+	unrolled := make(map[*Node]int)
+	for t, e := range evidence {
+		for i, n := range d.Nodes {
+			if s, observed := e[n]; observed {
+				unrolled[slices[t][i]] = s
+			}
+		}
+	}
+	return unrolled
+}
+
+func compareDensities(t *testing.T, a, b Density, states int) {
+	for s := 0; s < states; s++ {
+		if math.Abs(a.StateMap[s]-b.StateMap[s]) > 1e-9 {
+			t.Fatal(a.StateMap, b.StateMap)
+		}
+	}
+}
+
+func TestDBNUnroll(t *testing.T) {
+	d, _, _, _ := initDBN()
+	net, slices := d.Unroll(3)
+	if len(net.Nodes) != 9 || HasCycles(net) {
+		t.Fatal("expected 3 acyclic slices")
+	}
+	// h_2 has w_2 and h_1 as parents, in the order of its CPD
+	h2 := slices[2][0]
+	if len(h2.Parents) != 2 || h2.Parents[0] != slices[2][1] || h2.Parents[1] != slices[1][0] {
+		t.Fatal(h2.Parents)
+	}
+	if len(slices[0][0].Parents) != 1 {
+		t.Fail()
+	}
+}
+
+func TestDBNFilterSmoothPredict(t *testing.T) {
+	d, _, _, o := initDBN()
+	evidence := dbnEvidence(o)
+	T := len(evidence)
+	net, slices := d.Unroll(T + 2)
+	all := unrolledEvidence(d, slices, evidence)
+
+	smoothed, err := d.Smooth(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered, err := d.Filter(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for step := 0; step < T; step++ {
+		// smoothing uses all of the evidence
+		exact, _ := net.ExactPosterior(slices[step], all)
+		for i, n := range d.Nodes {
+			compareDensities(t, smoothed[step][n], exact[slices[step][i]], n.States)
+		}
+		// filtering uses the evidence up to the slice
+		past, _ := net.ExactPosterior(slices[step],
+			unrolledEvidence(d, slices, evidence[:step+1]))
+		for i, n := range d.Nodes {
+			compareDensities(t, filtered[step][n], past[slices[step][i]], n.States)
+		}
+	}
+
+	predicted, err := d.Predict(evidence, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k := 0; k < 2; k++ {
+		exact, _ := net.ExactPosterior(slices[T+k], all)
+		for i, n := range d.Nodes {
+			compareDensities(t, predicted[k][n], exact[slices[T+k][i]], n.States)
+		}
+	}
+
+	ll, err := d.LogLikelihood(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := net.EvidenceProbability(all)
+	if math.Abs(ll-math.Log(p)) > 1e-9 {
+		t.Fatal(ll, math.Log(p))
+	}
+}
+
+func TestDBNViterbi(t *testing.T) {
+	d, h, w, o := initDBN()
+	evidence := dbnEvidence(o)
+	path, logP, err := d.Viterbi(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// brute force over every joint State of the hidden Nodes
+	net, slices := d.Unroll(len(evidence))
+	best := math.Inf(-1)
+	for joint := 0; joint < 1<<uint(2*len(evidence)); joint++ {
+		instance := unrolledEvidence(d, slices, evidence)
+		missing := false
+		for step := range evidence {
+			instance[slices[step][0]] = joint >> uint(2*step) & 1
+			instance[slices[step][1]] = joint >> uint(2*step+1) & 1
+			if _, observed := instance[slices[step][2]]; !observed {
+				missing = true
+			}
+		}
+		if missing {
+			// the unobserved o is maximized over too
+			for s := 0; s < o.States; s++ {
+				instance[slices[2][2]] = s
+				best = math.Max(best, math.Log(net.Likelihood([]map[*Node]int{instance})[0]))
+			}
+		} else {
+			best = math.Max(best, math.Log(net.Likelihood([]map[*Node]int{instance})[0]))
+		}
+	}
+	if math.Abs(best-logP) > 1e-9 {
+		t.Fatal(best, logP)
+	}
+
+	// the path has the probability reported
+	instance := make(map[*Node]int)
+	for step := range path {
+		instance[slices[step][0]] = path[step][h]
+		instance[slices[step][1]] = path[step][w]
+		instance[slices[step][2]] = path[step][o]
+	}
+	if math.Abs(math.Log(net.Likelihood([]map[*Node]int{instance})[0])-logP) > 1e-9 {
+		t.Fail()
+	}
+	for step, e := range evidence {
+		if s, observed := e[o]; observed && path[step][o] != s {
+			t.Fail()
+		}
+	}
+}
+
+func TestDBNViterbiTree(t *testing.T) {
+	d, h, _, o := initDBN()
+	evidence := dbnEvidence(o)
+	expected, expectedP, err := d.Viterbi(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same transition of h as a tree, split on w then h'
+	current := d.TransitionNode(h)
+	w, previous := current.Parents[0], current.Parents[1]
+	current.cpd = nil
+	current.CPD = &TreeCPD{Root: &TreeNode{Split: w, Branches: []*TreeNode{
+		{Split: previous, Branches: []*TreeNode{{Probs: []float64{.8, .2}}, {Probs: []float64{.3, .7}}}},
+		{Split: previous, Branches: []*TreeNode{{Probs: []float64{.4, .6}}, {Probs: []float64{.1, .9}}}}}}}
+	path, logP, err := d.Viterbi(evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(logP-expectedP) > 1e-9 {
+		t.Fatal(logP, expectedP)
+	}
+	for step := range path {
+		for _, n := range d.Nodes {
+			if path[step][n] != expected[step][n] {
+				t.Fatal(step, path, expected)
+			}
+		}
+	}
+	// the unrolled copies split on the Parents in their slices
+	_, slices := d.Unroll(3)
+	if split := slices[2][0].CPD.(*TreeCPD).Root.Split; split != slices[2][1] {
+		t.Fatal(split)
+	}
+}
+
+func TestDBNFit(t *testing.T) {
+	truth, h, w, o := initDBN()
+	net, slices := truth.Unroll(20)
+	net.topologicalSort()
+	r := rand.New(rand.NewSource(1))
+	sequences := make([][]map[*Node]int, 0)
+	for i := 0; i < 500; i++ {
+		sample := net.Sample(r)
+		sequence := make([]map[*Node]int, len(slices))
+		for step := range slices {
+			sequence[step] = map[*Node]int{
+				h: sample[slices[step][0]],
+				w: sample[slices[step][1]],
+				o: sample[slices[step][2]]}
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	learned := NewDBN(&Node{Name: "h"}, &Node{Name: "w"}, &Node{Name: "o"})
+	lh, lw, lo := learned.Nodes[0], learned.Nodes[1], learned.Nodes[2]
+	learned.AddEdge(lw, lh)
+	learned.AddEdge(lh, lo)
+	learned.AddTemporalEdge(lh, lh)
+	learned.AddTemporalEdge(lw, lw)
+	translated := make([][]map[*Node]int, len(sequences))
+	for i, sequence := range sequences {
+		for _, instance := range sequence {
+			translated[i] = append(translated[i],
+				map[*Node]int{lh: instance[h], lw: instance[w], lo: instance[o]})
+		}
+	}
+	if err := learned.Fit(translated, 0); err != nil {
+		t.Fatal(err)
+	}
+	if lo.States != 3 {
+		t.Fatal(lo.States)
+	}
+	for i, density := range truth.TransitionNode(h).cpd {
+		if math.Abs(learned.TransitionNode(lh).cpd[i].StateMap[1]-density.StateMap[1]) > .03 {
+			t.Fatal(i, learned.TransitionNode(lh).cpd[i].StateMap, density.StateMap)
+		}
+	}
+	if math.Abs(learned.PriorNode(lw).cpd[0].StateMap[0]-.6) > .06 {
+		t.Fatal(learned.PriorNode(lw).cpd[0].StateMap)
+	}
+}
+
+func TestDBNErrors(t *testing.T) {
+	h := &Node{Name: "h", States: 2}
+	d := NewDBN(h)
+	if _, err := d.Filter([]map[*Node]int{{}}); err == nil {
+		t.Fatal("expected an error without CPDs")
+	}
+	d.PriorNode(h).cpd = []Density{NewDensity(1, 0)}
+	d.TransitionNode(h).cpd = []Density{NewDensity(1, 0)}
+	if _, err := d.Filter([]map[*Node]int{{h: 1}}); err != ErrImpossibleEvidence {
+		t.Fatal(err)
+	}
+	if _, err := d.Smooth([]map[*Node]int{{h: 2}}); err == nil {
+		t.Fatal("expected an error for a state out of range")
+	}
+
+	// o gains a Parent after its CPDs were set
+	d, _, w, o := initDBN()
+	d.AddEdge(w, o)
+	if _, err := d.Filter(dbnEvidence(o)); err == nil || !strings.Contains(err.Error(), `"o"`) {
+		t.Fatal(err)
+	}
+	if _, _, err := d.Viterbi(dbnEvidence(o)); err == nil {
+		t.Fatal("expected an error for a stale CPD")
+	}
+}
@@ -0,0 +1,351 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+)
+
+// how an edit updates the CPD of a Node whose Parents change.  Policies
+// may be combined, e.g. ExtendUniformly | MarginalizeParent for ReverseEdge;
+// a change that no policy covers leaves the CPD stale.
+type CPDPolicy int
+
+const (
+	// leave the CPD stale, to be refitted or set later
+	MarkStale CPDPolicy = 0
+	// when a Parent is added, repeat each row of the table for every State
+	// of the Parent, so the Parent has no effect until the CPD is refitted
+	ExtendUniformly CPDPolicy = 1 << iota
+	// when a Parent is removed, sum it out of the table weighted by its
+	// distribution given the other Parents, so the Node's distribution given
+	// its remaining Parents is unchanged
+	MarginalizeParent
+	// refit the CPD from EditOptions.Data
+	Refit
+)
+
+// Options for editing a network
+type EditOptions struct {
+	Policy CPDPolicy
+	// the data and pseudo-observations per State for Refit
+	Data  *Dataset
+	Alpha float64
+}
+
+// check the options before an edit that may refit the CPDs of the Nodes,
+// which with their Parents must be in the data
+func (opts EditOptions) check(nodes ...*Node) error {
+	if opts.Policy&Refit == 0 {
+		return nil
+	}
+	if opts.Data == nil {
+		return errors.New("bayesiannetwork: refitting CPDs needs data")
+	}
+	for _, n := range nodes {
+		for _, m := range append([]*Node{n}, n.Parents...) {
+			if !opts.Data.Has(m) {
+				return fmt.Errorf("bayesiannetwork: no data for node %q", m.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// the Nodes whose CPDs are stale because their Parents changed, in the
+// order of the net.  Assigning a Node's CPD directly doesn't make it fresh;
+// use SetCPD or RefitStale.
+func (net BayesianNetwork) StaleNodes() []*Node {
+	stale := make([]*Node, 0)
+	for _, n := range net.Nodes {
+		if n.stale {
+			stale = append(stale, n)
+		}
+	}
+	return stale
+}
+
+// mark the CPD of n stale if it fit the Parents of n before they changed
+// and no longer does.  CPDs that validateCPD doesn't check, like
+// Deterministic ones, are assumed to still fit.
+func (n *Node) markStale(fitted bool) {
+	n.stale = n.stale || (fitted && validateCPD(n) != nil)
+}
+
+// set the CPD of a Node of the net, which must fit the States of the Node
+// and its Parents, and clear its staleness
+func (net *BayesianNetwork) SetCPD(n *Node, cpd CPD) error {
+	if !containsNode(net.Nodes, n) {
+		return fmt.Errorf("bayesiannetwork: node %q isn't in the network", n.Name)
+	}
+	previous, previousTable := n.CPD, n.cpd
+	n.CPD, n.cpd = cpd, nil
+	if err := validateCPD(n); err != nil {
+		n.CPD, n.cpd = previous, previousTable
+		return err
+	}
+	n.stale = false
+	return nil
+}
+
+// refit the stale CPDs from the Dataset with alpha pseudo-observations of
+// every State
+func (net *BayesianNetwork) RefitStale(ds *Dataset, alpha float64) {
+	for _, n := range net.StaleNodes() {
+		n.refit(ds, alpha)
+	}
+}
+
+// fit the CPD of n after its Parents changed.  A tree is regrown; other
+// CPDs can't follow a change of Parents and are replaced by tables.
+func (n *Node) refit(ds *Dataset, alpha float64) {
+	if tree, isTree := n.CPD.(*TreeCPD); isTree {
+		tree.Root = nil
+	} else {
+		n.CPD = nil
+	}
+	n.fit(ds, alpha)
+}
+
+// the probabilities of n for each joint State of its Parents, or nil when
+// it has no CPD that fits them
+func currentRows(n *Node) [][]float64 {
+	if !n.fitted() || n.stale || validateCPD(n) != nil {
+		return nil
+	}
+	rows, _ := probabilityRows(n)
+	return rows
+}
+
+// the joint distribution of the nodes, from the CPDs of their ancestors,
+// or nil when one of those is stale or missing
+func (net BayesianNetwork) jointOf(nodes []*Node) *Factor {
+	ancestors := ancestorsOf(nodes, uncut)
+	factors := make([]*Factor, 0, len(ancestors))
+	for _, n := range net.Nodes {
+		if !ancestors[n] {
+			continue
+		}
+		if currentRows(n) == nil {
+			return nil
+		}
+		factors = append(factors, n.factors()...)
+	}
+	keep := make(map[*Node]bool, len(nodes))
+	for _, n := range nodes {
+		keep[n] = true
+	}
+	joint := eliminate(factors, keep, false)
+	// in the order of the nodes
+	ordered := NewFactor(nodes...)
+	for index := range ordered.Values {
+		states := make(map[*Node]int, len(nodes))
+		for i, s := range ordered.states(index) {
+			states[nodes[i]] = s
+		}
+		ordered.Values[index] = joint.Value(states)
+	}
+	return ordered
+}
+
+// add the edge from p to c and update the CPD of c
+func (net *BayesianNetwork) addEdge(p, c *Node, opts EditOptions) {
+	rows := currentRows(c)
+	net.AddEdge(p, c)
+	switch {
+	case opts.Policy&Refit != 0:
+		c.refit(opts.Data, opts.Alpha)
+	case opts.Policy&ExtendUniformly != 0 && rows != nil:
+		// p is the last Parent, whose State varies slowest
+		extended := make([][]float64, 0, len(rows)*p.States)
+		for s := 0; s < p.States; s++ {
+			for _, probs := range rows {
+				extended = append(extended, append([]float64{}, probs...))
+			}
+		}
+		setRows(c, extended)
+	}
+}
+
+// remove the edge from p to c and update the CPD of c
+func (net *BayesianNetwork) removeEdge(p, c *Node, opts EditOptions) {
+	var marginalized [][]float64
+	rows := currentRows(c)
+	if opts.Policy&(MarginalizeParent|Refit) == MarginalizeParent && rows != nil {
+		marginalized = net.marginalizeParent(c, p, rows)
+	}
+	fitted := c.fitted() && validateCPD(c) == nil
+	c.Parents = removeNode(c.Parents, p)
+	p.Children = removeNode(p.Children, c)
+	c.markStale(fitted)
+
+	switch {
+	case opts.Policy&Refit != 0:
+		c.refit(opts.Data, opts.Alpha)
+	case marginalized != nil:
+		setRows(c, marginalized)
+	}
+}
+
+// the rows of c without its Parent p: the rows for each State of p,
+// weighted by the probability of the State given the other Parents (or
+// equally when the other Parents' States are impossible), or nil when the
+// weights can't be calculated
+func (net BayesianNetwork) marginalizeParent(c, p *Node, rows [][]float64) [][]float64 {
+	others := removeNode(append([]*Node{}, c.Parents...), p)
+	joint := net.jointOf(append([]*Node{p}, others...))
+	if joint == nil {
+		return nil
+	}
+	position := 0
+	for i, q := range c.Parents {
+		if q == p {
+			position = i
+		}
+	}
+
+	configurations := NewFactor(others...)
+	marginalized := make([][]float64, len(configurations.Values))
+	for j := range marginalized {
+		otherStates := configurations.states(j)
+		weights := make([]float64, p.States)
+		total := 0.0
+		for s := range weights {
+			weights[s] = joint.Values[s+p.States*j]
+			total += weights[s]
+		}
+		marginalized[j] = make([]float64, c.States)
+		for s, w := range weights {
+			if total > 0 {
+				w /= total
+			} else {
+				w = 1 / float64(p.States)
+			}
+			parentStates := make([]int, 0, len(c.Parents))
+			parentStates = append(parentStates, otherStates[:position]...)
+			parentStates = append(parentStates, s)
+			parentStates = append(parentStates, otherStates[position:]...)
+			for k, prob := range rows[tableIndex(c, parentStates)] {
+				marginalized[j][k] += w * prob
+			}
+		}
+	}
+	return marginalized
+}
+
+// check that the Nodes are in the net
+func (net BayesianNetwork) checkNodes(nodes ...*Node) error {
+	for _, n := range nodes {
+		if !containsNode(net.Nodes, n) {
+			return fmt.Errorf("bayesiannetwork: node %q isn't in the network", n.Name)
+		}
+	}
+	return nil
+}
+
+// add a Node without edges to the net, fitting its CPD under the Refit
+// policy
+func (net *BayesianNetwork) AddNode(n *Node, opts EditOptions) error {
+	if err := opts.check(n); err != nil {
+		return err
+	}
+	if n.Name == "" || n.States <= 0 {
+		return errors.New("bayesiannetwork: a node needs a name and states")
+	}
+	for _, m := range net.Nodes {
+		if m == n || m.Name == n.Name {
+			return fmt.Errorf("bayesiannetwork: duplicate node name %q", n.Name)
+		}
+	}
+	if len(n.Parents) > 0 || len(n.Children) > 0 {
+		return fmt.Errorf("bayesiannetwork: node %q already has edges", n.Name)
+	}
+	if n.fitted() {
+		if err := validateCPD(n); err != nil {
+			return err
+		}
+	}
+	net.Nodes = append(net.Nodes, n)
+	if opts.Policy&Refit != 0 {
+		n.refit(opts.Data, opts.Alpha)
+	}
+	return nil
+}
+
+// remove a Node and its edges from the net, updating the CPDs of its
+// children by the policy
+func (net *BayesianNetwork) RemoveNode(n *Node, opts EditOptions) error {
+	if err := opts.check(n.Children...); err != nil {
+		return err
+	}
+	if err := net.checkNodes(n); err != nil {
+		return err
+	}
+	for _, c := range append([]*Node{}, n.Children...) {
+		net.removeEdge(n, c, opts)
+	}
+	for _, p := range append([]*Node{}, n.Parents...) {
+		p.Children = removeNode(p.Children, n)
+	}
+	n.Parents = nil
+	net.Nodes = removeNode(net.Nodes, n)
+	return nil
+}
+
+// add an edge from p to c, which must not make a cycle, updating the CPD
+// of c by the policy.  The new Parent is the last Parent of c, and the
+// Nodes are put back in topological order.
+func (net *BayesianNetwork) InsertEdge(p, c *Node, opts EditOptions) error {
+	if err := opts.check(p, c); err != nil {
+		return err
+	}
+	if err := net.checkNodes(p, c); err != nil {
+		return err
+	}
+	switch {
+	case p == c:
+		return fmt.Errorf("bayesiannetwork: edge from %q to itself", p.Name)
+	case containsNode(c.Parents, p):
+		return fmt.Errorf("bayesiannetwork: duplicate edge from %q to %q", p.Name, c.Name)
+	case descendants(c, uncut)[p]:
+		return fmt.Errorf("bayesiannetwork: edge from %q to %q would make a cycle", p.Name, c.Name)
+	}
+	net.addEdge(p, c, opts)
+	net.stableTopologicalSort()
+	return nil
+}
+
+// remove the edge from p to c, updating the CPD of c by the policy
+func (net *BayesianNetwork) RemoveEdge(p, c *Node, opts EditOptions) error {
+	if err := opts.check(c); err != nil {
+		return err
+	}
+	if !containsNode(c.Parents, p) {
+		return fmt.Errorf("bayesiannetwork: no edge from %q to %q", p.Name, c.Name)
+	}
+	net.removeEdge(p, c, opts)
+	return nil
+}
+
+// replace the edge from p to c with an edge from c to p, which must not make
+// a cycle, updating both CPDs by the policy: c loses a Parent and p gains
+// one.  The Nodes are put back in topological order.
+func (net *BayesianNetwork) ReverseEdge(p, c *Node, opts EditOptions) error {
+	if err := opts.check(p, c); err != nil {
+		return err
+	}
+	if err := net.checkNodes(p, c); err != nil {
+		return err
+	}
+	if !containsNode(c.Parents, p) {
+		return fmt.Errorf("bayesiannetwork: no edge from %q to %q", p.Name, c.Name)
+	}
+	direct := func(from, to *Node) bool { return from == p && to == c }
+	if descendants(p, direct)[c] {
+		return fmt.Errorf("bayesiannetwork: reversing the edge from %q to %q would make a cycle",
+			p.Name, c.Name)
+	}
+	net.removeEdge(p, c, opts)
+	net.addEdge(c, p, opts)
+	net.stableTopologicalSort()
+	return nil
+}
@@ -0,0 +1,297 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func initSprinkler(t *testing.T) (*BayesianNetwork, *Node, *Node, *Node, *Node) {
+	b := NewBuilder()
+	buildSprinkler(b)
+	network, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network, network.Nodes[0], network.Nodes[1], network.Nodes[2], network.Nodes[3]
+}
+
+// the marginal distribution of n
+func marginalOf(t *testing.T, net *BayesianNetwork, n *Node, evidence map[*Node]int) Density {
+	densities, err := net.ExactPosterior([]*Node{n}, evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return densities[n]
+}
+
+func TestEditErrors(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	outside := &Node{Name: "outside", States: 2}
+	opts := EditOptions{}
+	if network.InsertEdge(wet, cloudy, opts) == nil {
+		t.Fatal("expected an error for a cycle")
+	}
+	if network.InsertEdge(wet, wet, opts) == nil {
+		t.Fatal("expected an error for a self edge")
+	}
+	if network.InsertEdge(cloudy, rain, opts) == nil {
+		t.Fatal("expected an error for a duplicate edge")
+	}
+	if network.InsertEdge(outside, wet, opts) == nil {
+		t.Fatal("expected an error for a node outside the network")
+	}
+	if network.RemoveEdge(cloudy, wet, opts) == nil {
+		t.Fatal("expected an error for a missing edge")
+	}
+	if network.InsertEdge(cloudy, wet, opts) != nil {
+		t.Fatal("unexpected error")
+	}
+	// cloudy -> sprinkler -> wet
+	if network.ReverseEdge(cloudy, wet, opts) == nil {
+		t.Fatal("expected an error for a cycle")
+	}
+	// an edge into a node the network doesn't have
+	stray := &Node{Name: "stray", States: 2, Parents: []*Node{wet}}
+	if network.ReverseEdge(wet, stray, opts) == nil {
+		t.Fatal("expected an error for a node outside the network")
+	}
+	if network.AddNode(&Node{Name: "rain", States: 2}, opts) == nil {
+		t.Fatal("expected an error for a duplicate name")
+	}
+	if network.AddNode(sprinkler, opts) == nil {
+		t.Fatal("expected an error for a node already in the network")
+	}
+	if network.RemoveEdge(cloudy, rain, EditOptions{Policy: Refit}) == nil {
+		t.Fatal("expected an error for refitting without data")
+	}
+}
+
+func TestStaleNodes(t *testing.T) {
+	network, cloudy, _, rain, wet := initSprinkler(t)
+	if len(network.StaleNodes()) != 0 {
+		t.Fatal(network.StaleNodes())
+	}
+	// data for refitting, sampled before any CPD is stale
+	data := make([]map[*Node]int, 0)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		data = append(data, network.Sample(r))
+	}
+	if err := network.InsertEdge(cloudy, wet, EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if stale := network.StaleNodes(); len(stale) != 1 || stale[0] != wet {
+		t.Fatal(stale)
+	}
+	if network.Validate() == nil {
+		t.Fatal("expected an error for a stale cpd")
+	}
+	// AddEdge also marks the child stale
+	network.AddEdge(wet, rain)
+	network.RemoveEdge(wet, rain, EditOptions{})
+	if stale := network.StaleNodes(); len(stale) != 2 || stale[0] != rain {
+		t.Fatal(stale)
+	}
+
+	if network.SetCPD(wet, &TableCPD{}) == nil {
+		t.Fatal("expected an error for an empty table")
+	}
+	rows := make([][]float64, 8)
+	for j := range rows {
+		rows[j] = []float64{.5, .5}
+	}
+	cpd := &TableCPD{}
+	for _, probs := range rows {
+		cpd.Densities = append(cpd.Densities, NewDensity(probs...))
+	}
+	if err := network.SetCPD(wet, cpd); err != nil {
+		t.Fatal(err)
+	}
+	if stale := network.StaleNodes(); len(stale) != 1 || stale[0] != rain {
+		t.Fatal(stale)
+	}
+	network.RefitStale(datasetOf(network.Nodes, data), 1)
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStaleInference(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	if err := network.InsertEdge(sprinkler, rain, EditOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// every query that depends on rain names it
+	named := func(err error) {
+		t.Helper()
+		if err == nil || !strings.Contains(err.Error(), `"rain"`) {
+			t.Fatal(err)
+		}
+	}
+	_, err := network.ExactPosterior([]*Node{wet}, map[*Node]int{})
+	named(err)
+	_, err = network.EvidenceProbability(map[*Node]int{rain: 0})
+	named(err)
+	_, _, err = network.MostProbableExplanation(map[*Node]int{})
+	named(err)
+	_, err = network.SampledPosterior(rand.New(rand.NewSource(1)), []*Node{wet}, map[*Node]int{})
+	named(err)
+	_, err = network.InterventionalPosterior([]*Node{wet}, map[*Node]int{sprinkler: 0}, map[*Node]int{})
+	named(err)
+	_, err = network.BackdoorAdjustment(wet, 1, sprinkler, 0, []*Node{cloudy})
+	named(err)
+	// queries that don't depend on it still work
+	if d := marginalOf(t, network, sprinkler, map[*Node]int{cloudy: 0}); math.Abs(d.StateMap[0]-.1) > 1e-12 {
+		t.Fatal(d.StateMap)
+	}
+	// nor do interventions that replace the stale CPD
+	if _, err := network.InterventionalPosterior(
+		[]*Node{wet}, map[*Node]int{rain: 0}, map[*Node]int{}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), `"rain"`) {
+			t.Fatal(r)
+		}
+	}()
+	network.Sample(rand.New(rand.NewSource(1)))
+}
+
+func TestEditOrder(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	opts := EditOptions{Policy: ExtendUniformly | MarginalizeParent}
+	// rain comes after sprinkler once it is a Parent
+	if err := network.InsertEdge(rain, sprinkler, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !sameNodes(network.Nodes, []*Node{cloudy, rain, sprinkler, wet}) {
+		t.Fatal(network.Nodes)
+	}
+	if err := network.ReverseEdge(cloudy, rain, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !sameNodes(network.Nodes, []*Node{rain, cloudy, sprinkler, wet}) {
+		t.Fatal(network.Nodes)
+	}
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// so sampling follows the edges
+	r := rand.New(rand.NewSource(1))
+	data := make([]map[*Node]int, 0)
+	for i := 0; i < 2000; i++ {
+		data = append(data, network.Sample(r))
+	}
+	expected := marginalOf(t, network, wet, map[*Node]int{})
+	count := 0
+	for _, sample := range data {
+		if sample[wet] == 0 {
+			count++
+		}
+	}
+	if p := float64(count) / 2000; math.Abs(p-expected.StateMap[0]) > .04 {
+		t.Fatal(p, expected.StateMap)
+	}
+}
+
+func TestExtendUniformly(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	before := marginalOf(t, network, wet, map[*Node]int{sprinkler: 1})
+	opts := EditOptions{Policy: ExtendUniformly}
+	if err := network.InsertEdge(cloudy, wet, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.StaleNodes()) != 0 || len(wet.Parents) != 3 {
+		t.Fatal(network.StaleNodes(), wet.Parents)
+	}
+	// the new Parent has no effect
+	for s := 0; s < 2; s++ {
+		p := wet.conditionalProbability(map[*Node]int{sprinkler: 1, rain: 0, cloudy: s, wet: 0})
+		if p != .9 {
+			t.Fatal(s, p)
+		}
+	}
+	after := marginalOf(t, network, wet, map[*Node]int{sprinkler: 1})
+	compareDensities(t, before, after, 2)
+}
+
+func TestMarginalizeParent(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	before := marginalOf(t, network, wet, map[*Node]int{})
+	beforeGiven := marginalOf(t, network, wet, map[*Node]int{sprinkler: 0})
+	opts := EditOptions{Policy: MarginalizeParent}
+	if err := network.RemoveEdge(rain, wet, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.StaleNodes()) != 0 || len(wet.Parents) != 1 || len(rain.Children) != 0 {
+		t.Fatal(network.StaleNodes(), wet.Parents, rain.Children)
+	}
+	// the distribution of wet given sprinkler is unchanged
+	compareDensities(t, before, marginalOf(t, network, wet, map[*Node]int{}), 2)
+	compareDensities(t, beforeGiven, marginalOf(t, network, wet, map[*Node]int{sprinkler: 0}), 2)
+
+	// removing cloudy, the common parent of sprinkler and rain
+	network, cloudy, sprinkler, rain, _ = initSprinkler(t)
+	beforeRain := marginalOf(t, network, rain, map[*Node]int{})
+	if err := network.RemoveNode(cloudy, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 3 || len(sprinkler.Parents) != 0 || network.Validate() != nil {
+		t.Fatal(network.Nodes, sprinkler.Parents, network.Validate())
+	}
+	compareDensities(t, beforeRain, marginalOf(t, network, rain, map[*Node]int{}), 2)
+	if p := sprinkler.conditionalProbability(map[*Node]int{sprinkler: 0}); math.Abs(p-.3) > 1e-12 {
+		t.Fatal(p)
+	}
+}
+
+func TestReverseEdge(t *testing.T) {
+	network, cloudy, _, rain, _ := initSprinkler(t)
+	opts := EditOptions{Policy: ExtendUniformly | MarginalizeParent}
+	if err := network.ReverseEdge(cloudy, rain, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(rain.Parents) != 0 || len(cloudy.Parents) != 1 || cloudy.Parents[0] != rain {
+		t.Fatal(rain.Parents, cloudy.Parents)
+	}
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// P(rain = yes) = .5
+	if p := marginalOf(t, network, rain, map[*Node]int{}).StateMap[0]; math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+}
+
+func TestRefit(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	data := make([]map[*Node]int, 0)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		data = append(data, network.Sample(r))
+	}
+	opts := EditOptions{Policy: Refit, Data: datasetOf(network.Nodes, data), Alpha: 1}
+	if err := network.RemoveEdge(sprinkler, wet, opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.InsertEdge(cloudy, wet, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(network.StaleNodes()) != 0 || network.Validate() != nil {
+		t.Fatal(network.StaleNodes(), network.Validate())
+	}
+	// P(wet = yes | rain = no, cloudy = no) = P(sprinkler = on | cloudy = no) * .9
+	p := wet.conditionalProbability(map[*Node]int{rain: 1, cloudy: 1, wet: 0})
+	if math.Abs(p-.45) > .05 {
+		t.Fatal(p)
+	}
+
+	added := &Node{Name: "added", States: 2}
+	if network.AddNode(added, opts) == nil {
+		t.Fatal("expected an error for a node without data")
+	}
+}
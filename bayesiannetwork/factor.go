@@ -0,0 +1,200 @@
+package bayesiannetwork
+
+// A Factor is a table of values over the joint States of a set of Nodes,
+// normally non-negative.  The first Node's State varies fastest in Values.
+type Factor struct {
+	Nodes  []*Node
+	Values []float64
+}
+
+// initialize a Factor of zeros over the Nodes
+func NewFactor(nodes ...*Node) *Factor {
+	size := 1
+	for _, n := range nodes {
+		size *= n.States
+	}
+	return &Factor{Nodes: nodes, Values: make([]float64, size)}
+}
+
+// the distance in Values between consecutive States of each Node
+func (f *Factor) strides() []int {
+	strides := make([]int, len(f.Nodes))
+	size := 1
+	for i, n := range f.Nodes {
+		strides[i] = size
+		size *= n.States
+	}
+	return strides
+}
+
+// the index in Values of the given States of the Factor's Nodes
+func (f *Factor) Index(states map[*Node]int) int {
+	index := 0
+	for i, stride := range f.strides() {
+		index += states[f.Nodes[i]] * stride
+	}
+	return index
+}
+
+// the value of the Factor at the given States of its Nodes
+func (f *Factor) Value(states map[*Node]int) float64 {
+	return f.Values[f.Index(states)]
+}
+
+// the States of the Factor's Nodes at an index of Values
+func (f *Factor) states(index int) []int {
+	states := make([]int, len(f.Nodes))
+	for i, n := range f.Nodes {
+		states[i] = index % n.States
+		index /= n.States
+	}
+	return states
+}
+
+// whether the Factor has n among its Nodes
+func (f *Factor) contains(n *Node) bool {
+	for _, m := range f.Nodes {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// the pointwise product of two Factors over the union of their Nodes
+func (f *Factor) Product(g *Factor) *Factor {
+	nodes := make([]*Node, len(f.Nodes))
+	copy(nodes, f.Nodes)
+	for _, n := range g.Nodes {
+		if !f.contains(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	out := NewFactor(nodes...)
+
+	// position of the Nodes of f and g in the product
+	fStrides := make([]int, len(nodes))
+	gStrides := make([]int, len(nodes))
+	for i, stride := range f.strides() {
+		fStrides[i] = stride
+	}
+	for i, stride := range g.strides() {
+		for j, n := range nodes {
+			if n == g.Nodes[i] {
+				gStrides[j] = stride
+			}
+		}
+	}
+
+	for index := range out.Values {
+		fi, gi := 0, 0
+		for j, s := range out.states(index) {
+			fi += s * fStrides[j]
+			gi += s * gStrides[j]
+		}
+		out.Values[index] = f.Values[fi] * g.Values[gi]
+	}
+	return out
+}
+
+// combine the entries of the Factor that differ only in the State of n
+func (f *Factor) marginalize(n *Node, combine func(a, b float64) float64) *Factor {
+	nodes := make([]*Node, 0, len(f.Nodes))
+	position := -1
+	for i, m := range f.Nodes {
+		if m == n {
+			position = i
+		} else {
+			nodes = append(nodes, m)
+		}
+	}
+	if position == -1 {
+		return f
+	}
+	out := NewFactor(nodes...)
+	filled := make([]bool, len(out.Values))
+	outStrides := out.strides()
+
+	for index, v := range f.Values {
+		outIndex, j := 0, 0
+		for i, s := range f.states(index) {
+			if i != position {
+				outIndex += s * outStrides[j]
+				j++
+			}
+		}
+		if filled[outIndex] {
+			out.Values[outIndex] = combine(out.Values[outIndex], v)
+		} else {
+			out.Values[outIndex] = v
+			filled[outIndex] = true
+		}
+	}
+	return out
+}
+
+// sum the Factor over the States of n, removing n from the Factor
+func (f *Factor) SumOut(n *Node) *Factor {
+	return f.marginalize(n, func(a, b float64) float64 { return a + b })
+}
+
+// maximize the Factor over the States of n, removing n from the Factor
+func (f *Factor) MaxOut(n *Node) *Factor {
+	return f.marginalize(n, func(a, b float64) float64 {
+		if b > a {
+			return b
+		}
+		return a
+	})
+}
+
+// fix the States of the evidence Nodes, removing them from the Factor
+func (f *Factor) Reduce(evidence map[*Node]int) *Factor {
+	nodes := make([]*Node, 0, len(f.Nodes))
+	for _, n := range f.Nodes {
+		if _, observed := evidence[n]; !observed {
+			nodes = append(nodes, n)
+		}
+	}
+	if len(nodes) == len(f.Nodes) {
+		return f
+	}
+
+	out := NewFactor(nodes...)
+	states := make(map[*Node]int, len(f.Nodes))
+	for n, s := range evidence {
+		states[n] = s
+	}
+	for index := range out.Values {
+		for i, s := range out.states(index) {
+			states[nodes[i]] = s
+		}
+		out.Values[index] = f.Value(states)
+	}
+	return out
+}
+
+// scale the values of the Factor to sum to 1; returns the original sum
+func (f *Factor) Normalize() float64 {
+	total := 0.0
+	for _, v := range f.Values {
+		total += v
+	}
+	if total > 0 {
+		for i := range f.Values {
+			f.Values[i] /= total
+		}
+	}
+	return total
+}
+
+// the CPD of the Node as a Factor over the Node and its Parents
+func (n *Node) factor() *Factor {
+	return TableFactor(n, n.distribution())
+}
+
+// the CPD of the Node as the Factors given by its CPD, which may be smaller
+// than the table but are only valid for summing out
+func (n *Node) factors() []*Factor {
+	return n.distribution().Factors(n)
+}
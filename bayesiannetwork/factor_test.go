@@ -0,0 +1,97 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFactorProduct(t *testing.T) {
+	A := &Node{Name: "A", States: 2}
+	B := &Node{Name: "B", States: 3}
+	C := &Node{Name: "C", States: 2}
+
+	f := &Factor{Nodes: []*Node{A, B}, Values: []float64{1, 2, 3, 4, 5, 6}}
+	g := &Factor{Nodes: []*Node{C, B}, Values: []float64{1, 10, 2, 20, 3, 30}}
+
+	product := f.Product(g)
+	if len(product.Values) != 12 {
+		t.Fatal(product.Nodes)
+	}
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 3; b++ {
+			for c := 0; c < 2; c++ {
+				states := map[*Node]int{A: a, B: b, C: c}
+				if product.Value(states) != f.Value(states)*g.Value(states) {
+					t.Fail()
+				}
+			}
+		}
+	}
+}
+
+func TestFactorSumOutMaxOut(t *testing.T) {
+	A := &Node{Name: "A", States: 2}
+	B := &Node{Name: "B", States: 3}
+	f := &Factor{Nodes: []*Node{A, B}, Values: []float64{1, 2, 3, 4, 5, 6}}
+
+	summed := f.SumOut(A)
+	if len(summed.Nodes) != 1 || summed.Nodes[0] != B {
+		t.Fail()
+	}
+	solution := []float64{3, 7, 11}
+	for i, v := range summed.Values {
+		if v != solution[i] {
+			t.Fail()
+		}
+	}
+
+	maxed := f.MaxOut(B)
+	if maxed.Values[0] != 5 || maxed.Values[1] != 6 {
+		t.Fail()
+	}
+
+	// Nodes that aren't in the factor are ignored
+	if f.SumOut(&Node{Name: "C", States: 2}) != f {
+		t.Fail()
+	}
+}
+
+func TestFactorReduce(t *testing.T) {
+	A := &Node{Name: "A", States: 2}
+	B := &Node{Name: "B", States: 3}
+	f := &Factor{Nodes: []*Node{A, B}, Values: []float64{1, 2, 3, 4, 5, 6}}
+
+	reduced := f.Reduce(map[*Node]int{B: 1})
+	if len(reduced.Nodes) != 1 || reduced.Values[0] != 3 || reduced.Values[1] != 4 {
+		t.Fail()
+	}
+
+	total := f.Normalize()
+	if total != 21 || math.Abs(f.Values[5]-6.0/21) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestNodeFactor(t *testing.T) {
+	network := initStudentNetwork()
+	G := network.Nodes[4]
+	f := G.factor()
+
+	if len(f.Values) != 12 || f.Nodes[0] != G {
+		t.Fail()
+	}
+	// each parent State is a distribution over G
+	for _, parents := range []map[*Node]int{
+		{G.Parents[0]: 0, G.Parents[1]: 0},
+		{G.Parents[0]: 1, G.Parents[1]: 0},
+		{G.Parents[0]: 1, G.Parents[1]: 1}} {
+
+		cpdIndex := G.getCPDIndex(parents)
+		for s := 0; s < 3; s++ {
+			parents[G] = s
+			if f.Value(parents) != G.cpd[cpdIndex].StateMap[s] {
+				t.Fail()
+			}
+		}
+	}
+}
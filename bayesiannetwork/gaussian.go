@@ -0,0 +1,234 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"math"
+)
+
+// the smallest variance a fitted LinearGaussian can have, so that a
+// regression that fits its few observations exactly still has a density
+const minVariance = 1e-9
+
+// A ContinuousNode has a real valued State.  It is Gaussian with a mean that
+// is a linear function of its continuous Parents, and each joint State of
+// its DiscreteParents selects a different regression (a conditional linear
+// Gaussian).  A ContinuousNode without Parents is a Gaussian root.
+type ContinuousNode struct {
+	Name            string
+	Parents         []*ContinuousNode
+	DiscreteParents []*Node
+	Children        []*ContinuousNode
+	// one per joint State of the DiscreteParents, indexed like the CPD of a
+	// Node with the same Parents
+	Regressions []LinearGaussian
+}
+
+// A LinearGaussian is N(Intercept + sum(Weights[i] * parent[i]), Variance)
+type LinearGaussian struct {
+	Intercept float64
+	// one per continuous Parent, in order
+	Weights  []float64
+	Variance float64
+}
+
+// the mean of the regression given the values of the continuous Parents
+func (g LinearGaussian) Mean(parents []float64) float64 {
+	mean := g.Intercept
+	for i, w := range g.Weights {
+		mean += w * parents[i]
+	}
+	return mean
+}
+
+// the density of a normal distribution
+func normalDensity(x, mean, variance float64) float64 {
+	d := x - mean
+	return math.Exp(-d*d/(2*variance)) / math.Sqrt(2*math.Pi*variance)
+}
+
+// the index of the regression selected by the States of the discrete Parents
+func (n *ContinuousNode) regressionIndex(states map[*Node]int) int {
+	index, stride := 0, 1
+	for _, p := range n.DiscreteParents {
+		index += states[p] * stride
+		stride *= p.States
+	}
+	return index
+}
+
+// the number of joint States of the discrete Parents
+func (n *ContinuousNode) configurations() int {
+	size := 1
+	for _, p := range n.DiscreteParents {
+		size *= p.States
+	}
+	return size
+}
+
+// least squares regression of y on the columns of x with an intercept; the
+// variance is the mean squared residual
+func fitLinearGaussian(x [][]float64, y []float64, nWeights int) LinearGaussian {
+	if len(y) == 0 {
+		return LinearGaussian{Weights: make([]float64, nWeights), Variance: 1}
+	}
+
+	// the normal equations, with a tiny ridge so that collinear or constant
+	// parents still have a solution
+	size := nWeights + 1
+	xtx := make([][]float64, size)
+	for i := range xtx {
+		xtx[i] = make([]float64, size)
+		if i > 0 {
+			xtx[i][i] = 1e-9
+		}
+	}
+	xty := make([]float64, size)
+	for r, row := range x {
+		features := append([]float64{1}, row...)
+		for i := 0; i < size; i++ {
+			xty[i] += features[i] * y[r]
+			for j := 0; j < size; j++ {
+				xtx[i][j] += features[i] * features[j]
+			}
+		}
+	}
+	beta, err := solve(xtx, xty)
+	if err != nil {
+		beta = make([]float64, size)
+		for _, v := range y {
+			beta[0] += v / float64(len(y))
+		}
+	}
+
+	g := LinearGaussian{Intercept: beta[0], Weights: beta[1:]}
+	for r, row := range x {
+		d := y[r] - g.Mean(row)
+		g.Variance += d * d / float64(len(y))
+	}
+	g.Variance = math.Max(g.Variance, minVariance)
+	return g
+}
+
+var errSingular = errors.New("bayesiannetwork: singular matrix")
+
+// solve a x = b by Gaussian elimination with partial pivoting
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-300 {
+			return nil, errSingular
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			f := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= f * m[col][k]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		x[row] = m[row][n]
+		for k := row + 1; k < n; k++ {
+			x[row] -= m[row][k] * x[k]
+		}
+		x[row] /= m[row][row]
+	}
+	return x, nil
+}
+
+// the Cholesky factor L of a symmetric positive definite matrix, a = L L'
+func cholesky(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, errSingular
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// solve L L' x = b given the Cholesky factor L
+func choleskySolve(l [][]float64, b []float64) []float64 {
+	n := len(b)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		y[i] = b[i]
+		for k := 0; k < i; k++ {
+			y[i] -= l[i][k] * y[k]
+		}
+		y[i] /= l[i][i]
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		x[i] = y[i]
+		for k := i + 1; k < n; k++ {
+			x[i] -= l[k][i] * x[k]
+		}
+		x[i] /= l[i][i]
+	}
+	return x
+}
+
+// A GaussianMixture is the distribution of a ContinuousNode in a hybrid
+// network: one weighted Gaussian per joint State of the discrete Nodes it
+// depends on
+type GaussianMixture struct {
+	Weights   []float64
+	Means     []float64
+	Variances []float64
+}
+
+// the mean of the mixture
+func (m GaussianMixture) Mean() float64 {
+	mean := 0.0
+	for i, w := range m.Weights {
+		mean += w * m.Means[i]
+	}
+	return mean
+}
+
+// the variance of the mixture, including the spread of the component means
+func (m GaussianMixture) Variance() float64 {
+	mean := m.Mean()
+	variance := 0.0
+	for i, w := range m.Weights {
+		d := m.Means[i] - mean
+		variance += w * (m.Variances[i] + d*d)
+	}
+	return variance
+}
+
+// the probability density of the mixture at x
+func (m GaussianMixture) Density(x float64) float64 {
+	density := 0.0
+	for i, w := range m.Weights {
+		density += w * normalDensity(x, m.Means[i], m.Variances[i])
+	}
+	return density
+}
@@ -0,0 +1,78 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFitLinearGaussian(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := make([][]float64, 0)
+	y := make([]float64, 0)
+	for i := 0; i < 5000; i++ {
+		a, b := r.NormFloat64(), r.NormFloat64()
+		x = append(x, []float64{a, b})
+		y = append(y, 1+2*a-3*b+0.5*r.NormFloat64())
+	}
+	g := fitLinearGaussian(x, y, 2)
+	if math.Abs(g.Intercept-1) > .05 ||
+		math.Abs(g.Weights[0]-2) > .05 ||
+		math.Abs(g.Weights[1]+3) > .05 {
+		t.Fatal("regression not recovered", g)
+	}
+	if math.Abs(g.Variance-.25) > .03 {
+		t.Fatal("variance not recovered", g.Variance)
+	}
+
+	// without observations the regression is a standard normal
+	empty := fitLinearGaussian(nil, nil, 1)
+	if empty.Intercept != 0 || empty.Variance != 1 || len(empty.Weights) != 1 {
+		t.Fail()
+	}
+	// an exact fit still has a positive variance
+	exact := fitLinearGaussian([][]float64{{0}, {1}}, []float64{1, 3}, 1)
+	if exact.Variance < minVariance || math.Abs(exact.Weights[0]-2) > 1e-6 {
+		t.Fail()
+	}
+}
+
+func TestCholeskySolve(t *testing.T) {
+	a := [][]float64{{4, 2, 0}, {2, 5, 1}, {0, 1, 3}}
+	b := []float64{1, 2, 3}
+	l, err := cholesky(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := choleskySolve(l, b)
+	for i := range a {
+		sum := 0.0
+		for j := range x {
+			sum += a[i][j] * x[j]
+		}
+		if math.Abs(sum-b[i]) > 1e-9 {
+			t.Fail()
+		}
+	}
+	if _, err := cholesky([][]float64{{1, 2}, {2, 1}}); err == nil {
+		t.Fatal("expected an error for an indefinite matrix")
+	}
+}
+
+func TestGaussianMixture(t *testing.T) {
+	m := GaussianMixture{
+		Weights:   []float64{.25, .75},
+		Means:     []float64{0, 4},
+		Variances: []float64{1, 2}}
+	if math.Abs(m.Mean()-3) > 1e-9 {
+		t.Fail()
+	}
+	// E[X^2] = .25 * 1 + .75 * (2 + 16) = 13.75
+	if math.Abs(m.Variance()-(13.75-9)) > 1e-9 {
+		t.Fail()
+	}
+	expected := .25*normalDensity(1, 0, 1) + .75*normalDensity(1, 4, 2)
+	if math.Abs(m.Density(1)-expected) > 1e-12 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,193 @@
+package bayesiannetwork
+
+import (
+	"strings"
+)
+
+// no edges are cut
+func uncut(p, c *Node) bool { return false }
+
+// the Nodes of the set in the order of the net
+func (net BayesianNetwork) inOrder(set map[*Node]bool) []*Node {
+	nodes := make([]*Node, 0, len(set))
+	for _, n := range net.Nodes {
+		if set[n] {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// whether every Node of xs is d-separated from every Node of ys given the
+// Nodes of zs, so that the structure implies xs and ys are independent
+// given zs for any CPDs
+func (net BayesianNetwork) DSeparated(xs, ys, zs []*Node) bool {
+	return dSeparated(xs, ys, zs, uncut)
+}
+
+// the unobserved Nodes that are d-connected to one of the Nodes of xs given
+// the Nodes of zs (including the unobserved Nodes of xs), in the order of
+// the net: the Nodes whose distributions may change when xs are observed
+func (net BayesianNetwork) DConnected(xs, zs []*Node) []*Node {
+	return net.inOrder(reachable(xs, zs, uncut))
+}
+
+// the Nodes with a directed path to n, in the order of the net
+func (net BayesianNetwork) Ancestors(n *Node) []*Node {
+	ancestors := ancestorsOf([]*Node{n}, uncut)
+	delete(ancestors, n)
+	return net.inOrder(ancestors)
+}
+
+// the Nodes with a directed path from n, in the order of the net
+func (net BayesianNetwork) Descendants(n *Node) []*Node {
+	return net.inOrder(descendants(n, uncut))
+}
+
+// the Parents, children and the children's other Parents of n, in the order
+// of the net.  Given its Markov blanket a Node is independent of every
+// other Node.
+func (net BayesianNetwork) MarkovBlanket(n *Node) []*Node {
+	blanket := make(map[*Node]bool)
+	for _, p := range n.Parents {
+		blanket[p] = true
+	}
+	for _, c := range n.Children {
+		blanket[c] = true
+		for _, p := range c.Parents {
+			blanket[p] = true
+		}
+	}
+	delete(blanket, n)
+	return net.inOrder(blanket)
+}
+
+// the moral graph of the net: the undirected graph connecting every Node
+// to its Parents and the Parents of a common child to each other, as the
+// neighbours of each Node in the order of the net
+func (net BayesianNetwork) MoralGraph() map[*Node][]*Node {
+	neighbours := make(map[*Node]map[*Node]bool, len(net.Nodes))
+	for _, n := range net.Nodes {
+		neighbours[n] = make(map[*Node]bool)
+	}
+	// Parents outside the net are ignored
+	connect := func(a, b *Node) {
+		if neighbours[a] != nil && neighbours[b] != nil {
+			neighbours[a][b], neighbours[b][a] = true, true
+		}
+	}
+	for _, n := range net.Nodes {
+		for i, p := range n.Parents {
+			connect(p, n)
+			for _, q := range n.Parents[:i] {
+				connect(p, q)
+			}
+		}
+	}
+	moral := make(map[*Node][]*Node, len(neighbours))
+	for n, adjacent := range neighbours {
+		moral[n] = net.inOrder(adjacent)
+	}
+	return moral
+}
+
+// A conditional independence X ⊥ Y | Given
+type Independence struct {
+	X, Y  []*Node
+	Given []*Node
+}
+
+func names(nodes []*Node) string {
+	named := make([]string, len(nodes))
+	for i, n := range nodes {
+		named[i] = n.Name
+	}
+	return strings.Join(named, ", ")
+}
+
+// e.g. "rain ⊥ sprinkler | cloudy"
+func (ind Independence) String() string {
+	s := names(ind.X) + " ⊥ " + names(ind.Y)
+	if len(ind.Given) > 0 {
+		s += " | " + names(ind.Given)
+	}
+	return s
+}
+
+// the local Markov independencies of the structure: each Node is
+// independent of the Nodes that aren't its descendants or Parents given its
+// Parents.  Every independence implied by the structure follows from these.
+func (net BayesianNetwork) LocalIndependencies() []Independence {
+	independencies := make([]Independence, 0)
+	for _, n := range net.Nodes {
+		excluded := descendants(n, uncut)
+		excluded[n] = true
+		for _, p := range n.Parents {
+			excluded[p] = true
+		}
+		others := make([]*Node, 0)
+		for _, m := range net.Nodes {
+			if !excluded[m] {
+				others = append(others, m)
+			}
+		}
+		if len(others) > 0 {
+			independencies = append(independencies, Independence{
+				X:     []*Node{n},
+				Y:     others,
+				Given: append([]*Node{}, n.Parents...)})
+		}
+	}
+	return independencies
+}
+
+// every independence X ⊥ Y | Given between two Nodes implied by the
+// structure, given up to maxGiven other Nodes (any number when maxGiven is
+// negative), in the order of the net.  The number of conditioning sets grows
+// exponentially with maxGiven.
+func (net BayesianNetwork) Independencies(maxGiven int) []Independence {
+	if maxGiven < 0 || maxGiven > len(net.Nodes)-2 {
+		maxGiven = len(net.Nodes) - 2
+	}
+	independencies := make([]Independence, 0)
+	for i, x := range net.Nodes {
+		for _, y := range net.Nodes[i+1:] {
+			others := make([]*Node, 0, len(net.Nodes)-2)
+			for _, n := range net.Nodes {
+				if n != x && n != y {
+					others = append(others, n)
+				}
+			}
+			for size := 0; size <= maxGiven; size++ {
+				subsets(others, size, func(given []*Node) {
+					if dSeparated([]*Node{x}, []*Node{y}, given, uncut) {
+						independencies = append(independencies, Independence{
+							X:     []*Node{x},
+							Y:     []*Node{y},
+							Given: append([]*Node{}, given...)})
+					}
+				})
+			}
+		}
+	}
+	return independencies
+}
+
+// call visit with every subset of the Nodes of the size, in lexicographic
+// order of their positions
+func subsets(nodes []*Node, size int, visit func([]*Node)) {
+	chosen := make([]*Node, 0, size)
+	var choose func(start int)
+	choose = func(start int) {
+		if len(chosen) == size {
+			visit(chosen)
+			return
+		}
+		for i := start; i <= len(nodes)-(size-len(chosen)); i++ {
+			chosen = append(chosen, nodes[i])
+			choose(i + 1)
+			chosen = chosen[:len(chosen)-1]
+		}
+	}
+	choose(0)
+}
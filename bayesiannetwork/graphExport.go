@@ -0,0 +1,319 @@
+package bayesiannetwork
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// how the edges of a drawing are weighted
+type StrengthMeasure int
+
+const (
+	// edges aren't weighted
+	NoStrength StrengthMeasure = iota
+	// the empirical mutual information (in nats) between the Parent and the
+	// child
+	MutualInformationStrength
+	// how much the log likelihood of the data drops when the edge is
+	// removed and the child's CPD is refitted, the change in the score used
+	// by InferBayesianNetwork
+	ScoreStrength
+)
+
+// Options for drawing a network
+type GraphOptions struct {
+	// draw each Node's CPD as a table in its label
+	CPDs bool
+	// the digits after the point of probabilities in CPD tables; 0 is 3
+	Precision int
+	// how to weight the edges, which needs Data
+	Strength StrengthMeasure
+	Data     *Dataset
+}
+
+// the maximum log likelihood of the States of n in the Dataset given the
+// States of the parents
+func familyLikelihood(ds *Dataset, n *Node, parents []*Node) float64 {
+	counts := ds.Counts(append([]*Node{n}, parents...)...)
+	ll := 0.0
+	for j := 0; j < len(counts.Values); j += n.States {
+		total := 0.0
+		for _, count := range counts.Values[j : j+n.States] {
+			total += count
+		}
+		for _, count := range counts.Values[j : j+n.States] {
+			if count > 0 {
+				ll += count * math.Log(count/total)
+			}
+		}
+	}
+	return ll
+}
+
+// the strength of each edge of the net in the Dataset, which needs a column
+// for every Node of an edge
+func (net BayesianNetwork) EdgeStrengths(ds *Dataset, measure StrengthMeasure) (map[Edge]float64, error) {
+	if measure != MutualInformationStrength && measure != ScoreStrength {
+		return nil, fmt.Errorf("bayesiannetwork: unknown strength measure %d", measure)
+	}
+	edges := net.Edges()
+	for _, e := range edges {
+		for _, n := range []*Node{e.Parent, e.Child} {
+			if !ds.Has(n) {
+				return nil, fmt.Errorf("bayesiannetwork: no data for node %q", n.Name)
+			}
+		}
+	}
+	strengths := make(map[Edge]float64)
+	for _, e := range edges {
+		switch measure {
+		case MutualInformationStrength:
+			strengths[e] = ds.MutualInformation(e.Parent, e.Child)
+		case ScoreStrength:
+			others := removeNode(append([]*Node{}, e.Child.Parents...), e.Parent)
+			strengths[e] = familyLikelihood(ds, e.Child, e.Child.Parents) -
+				familyLikelihood(ds, e.Child, others)
+		}
+	}
+	return strengths, nil
+}
+
+// the strengths of the edges for the options, or nil when they aren't
+// weighted
+func (net BayesianNetwork) graphStrengths(opts GraphOptions) (map[Edge]float64, error) {
+	if opts.Strength == NoStrength {
+		return nil, nil
+	}
+	if opts.Data == nil {
+		return nil, errors.New("bayesiannetwork: edge strengths need data")
+	}
+	return net.EdgeStrengths(opts.Data, opts.Strength)
+}
+
+// the rows of a Node's CPD table: the States of the Parents, then the
+// probabilities of the Node's States, or nil when it hasn't been fitted
+func cpdTable(n *Node, precision int) [][]string {
+	rows, err := probabilityRows(n)
+	if err != nil {
+		return nil
+	}
+	if precision <= 0 {
+		precision = 3
+	}
+	configurations := NewFactor(n.Parents...)
+	table := make([][]string, len(rows))
+	for j, probs := range rows {
+		for i, s := range configurations.states(j) {
+			table[j] = append(table[j], n.Parents[i].StateLabel(s))
+		}
+		for _, p := range probs {
+			table[j] = append(table[j], strconv.FormatFloat(p, 'f', precision, 64))
+		}
+	}
+	return table
+}
+
+// a Graphviz HTML-like label with the CPD of n
+func htmlCPDLabel(n *Node, table [][]string) string {
+	var b strings.Builder
+	cell := func(text string) {
+		b.WriteString("<TD>" + html.EscapeString(text) + "</TD>")
+	}
+	b.WriteString(`<<TABLE BORDER="0" CELLBORDER="1" CELLSPACING="0">`)
+	fmt.Fprintf(&b, `<TR><TD COLSPAN="%d"><B>%s</B></TD></TR>`,
+		len(n.Parents)+n.States, html.EscapeString(n.Name))
+	b.WriteString("<TR>")
+	for _, p := range n.Parents {
+		cell(p.Name)
+	}
+	for s := 0; s < n.States; s++ {
+		cell(n.StateLabel(s))
+	}
+	b.WriteString("</TR>")
+	for _, row := range table {
+		b.WriteString("<TR>")
+		for _, text := range row {
+			cell(text)
+		}
+		b.WriteString("</TR>")
+	}
+	b.WriteString("</TABLE>>")
+	return b.String()
+}
+
+// a DOT string
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func statesText(n *Node) string {
+	if n.States == 1 {
+		return "1 state"
+	}
+	return fmt.Sprintf("%d states", n.States)
+}
+
+// the largest strength, for scaling edges
+func maxStrength(strengths map[Edge]float64) float64 {
+	max := 0.0
+	for _, s := range strengths {
+		max = math.Max(max, s)
+	}
+	return max
+}
+
+// write the topology of the net in the Graphviz DOT language, with each
+// Node labelled by its name and number of States, or by its CPD table.
+// Weighted edges are labelled with their strength and drawn with widths
+// from 1 to 5 in proportion to it.
+func (net BayesianNetwork) WriteDOT(w io.Writer, opts GraphOptions) error {
+	strengths, err := net.graphStrengths(opts)
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprint(b, "digraph network {\n")
+	for _, n := range net.Nodes {
+		table := cpdTable(n, opts.Precision)
+		if opts.CPDs && table != nil {
+			fmt.Fprintf(b, "  %s [shape=plaintext, label=%s];\n", dotQuote(n.Name), htmlCPDLabel(n, table))
+		} else {
+			fmt.Fprintf(b, "  %s [label=%s];\n", dotQuote(n.Name), dotQuote(n.Name+"\n"+statesText(n)))
+		}
+	}
+	max := maxStrength(strengths)
+	for _, e := range net.Edges() {
+		fmt.Fprintf(b, "  %s -> %s", dotQuote(e.Parent.Name), dotQuote(e.Child.Name))
+		if s, weighted := strengths[e]; weighted {
+			width := 1.0
+			if max > 0 {
+				width += 4 * math.Max(0, s) / max
+			}
+			fmt.Fprintf(b, " [label=%s, penwidth=%.2f]", dotQuote(strconv.FormatFloat(s, 'g', 3, 64)), width)
+		}
+		fmt.Fprint(b, ";\n")
+	}
+	fmt.Fprint(b, "}\n")
+	return b.Flush()
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data,omitempty"`
+}
+
+// write the topology of the net as GraphML, with the name, number of
+// States, State labels and position of each Node as data, its CPD as text
+// (a line per joint State of its Parents) and the strength of weighted
+// edges
+func (net BayesianNetwork) WriteGraphML(w io.Writer, opts GraphOptions) error {
+	strengths, err := net.graphStrengths(opts)
+	if err != nil {
+		return err
+	}
+	encoded := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{"label", "node", "label", "string"},
+			{"states", "node", "states", "int"},
+			{"stateLabels", "node", "stateLabels", "string"},
+			{"x", "node", "x", "double"},
+			{"y", "node", "y", "double"},
+			{"cpd", "node", "cpd", "string"},
+			{"strength", "edge", "strength", "double"}},
+		Graph: graphMLGraph{ID: "network", EdgeDefault: "directed"}}
+
+	ids := make(map[*Node]string, len(net.Nodes))
+	for i, n := range net.Nodes {
+		ids[n] = "n" + strconv.Itoa(i)
+		labels := make([]string, n.States)
+		for s := range labels {
+			labels[s] = n.StateLabel(s)
+		}
+		node := graphMLNode{ID: ids[n], Data: []graphMLData{
+			{"label", n.Name},
+			{"states", strconv.Itoa(n.States)},
+			{"stateLabels", strings.Join(labels, ",")}}}
+		if n.Position != nil {
+			node.Data = append(node.Data,
+				graphMLData{"x", formatProbability(n.Position.X)},
+				graphMLData{"y", formatProbability(n.Position.Y)})
+		}
+		if table := cpdTable(n, opts.Precision); opts.CPDs && table != nil {
+			lines := make([]string, len(table))
+			for j, row := range table {
+				parents := make([]string, len(n.Parents))
+				for i, p := range n.Parents {
+					parents[i] = p.Name + "=" + row[i]
+				}
+				lines[j] = strings.Join(row[len(n.Parents):], " ")
+				if len(parents) > 0 {
+					lines[j] = strings.Join(parents, ", ") + ": " + lines[j]
+				}
+			}
+			node.Data = append(node.Data, graphMLData{"cpd", strings.Join(lines, "\n")})
+		}
+		encoded.Graph.Nodes = append(encoded.Graph.Nodes, node)
+	}
+	for _, e := range net.Edges() {
+		if _, exists := ids[e.Parent]; !exists {
+			return fmt.Errorf("bayesiannetwork: parent %q of node %q isn't in the network",
+				e.Parent.Name, e.Child.Name)
+		}
+		edge := graphMLEdge{Source: ids[e.Parent], Target: ids[e.Child]}
+		if s, weighted := strengths[e]; weighted {
+			edge.Data = []graphMLData{{"strength", strconv.FormatFloat(s, 'g', -1, 64)}}
+		}
+		encoded.Graph.Edges = append(encoded.Graph.Edges, edge)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(encoded); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
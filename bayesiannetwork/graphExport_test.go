@@ -0,0 +1,140 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	network := initStudentNetwork()
+	network.Nodes[0].Name = `say "I"`
+	var buf bytes.Buffer
+	if err := network.WriteDOT(&buf, GraphOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	for _, line := range []string{
+		`"say \"I\"" [label="say \"I\"\n2 states"];`,
+		`"G" [label="G\n3 states"];`,
+		`"say \"I\"" -> "G";`,
+		`"G" -> "L";`} {
+		if !strings.Contains(dot, line) {
+			t.Fatal(line, dot)
+		}
+	}
+
+	buf.Reset()
+	network.Nodes[4].StateLabels = []string{"A", "B", "<C>"}
+	if err := network.WriteDOT(&buf, GraphOptions{CPDs: true, Precision: 2}); err != nil {
+		t.Fatal(err)
+	}
+	dot = buf.String()
+	// the row of L for G = <C>
+	if !strings.Contains(dot, "<TR><TD>&lt;C&gt;</TD><TD>0.99</TD><TD>0.01</TD></TR>") {
+		t.Fatal(dot)
+	}
+	if !strings.Contains(dot, `<TD COLSPAN="5"><B>G</B></TD>`) {
+		t.Fatal(dot)
+	}
+	if err := network.WriteDOT(&buf, GraphOptions{Strength: ScoreStrength}); err == nil {
+		t.Fatal("expected an error for strengths without data")
+	}
+}
+
+func TestEdgeStrengths(t *testing.T) {
+	network := initStudentNetwork()
+	r := rand.New(rand.NewSource(1))
+	samples := make([]map[*Node]int, 2000)
+	for i := range samples {
+		samples[i] = network.Sample(r)
+	}
+	ds := DatasetFromInstances(samples)
+	I, S, G := network.Nodes[0], network.Nodes[1], network.Nodes[4]
+
+	mi, err := network.EdgeStrengths(ds, MutualInformationStrength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, err := network.EdgeStrengths(ds, ScoreStrength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mi) != 4 || len(score) != 4 {
+		t.Fatal(mi, score)
+	}
+	// with one parent, removing the edge loses N times the mutual information
+	e := Edge{I, S}
+	if math.Abs(score[e]-float64(ds.Len())*mi[e]) > 1e-6 {
+		t.Fatal(score[e], mi[e])
+	}
+	// with two, it loses N times the conditional mutual information
+	e = Edge{I, G}
+	cmi := ds.ConditionalMutualInformation(I, G, network.Nodes[3])
+	if math.Abs(score[e]-float64(ds.Len())*cmi) > 1e-6 {
+		t.Fatal(score[e], cmi)
+	}
+
+	var buf bytes.Buffer
+	opts := GraphOptions{Strength: MutualInformationStrength, Data: ds}
+	if err := network.WriteDOT(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	// the strongest edge is the widest
+	if !strings.Contains(buf.String(), "penwidth=5.00") {
+		t.Fatal(buf.String())
+	}
+
+	if _, err := network.EdgeStrengths(ds, StrengthMeasure(7)); err == nil {
+		t.Fatal("expected an error for an unknown measure")
+	}
+	if err := network.WriteDOT(&buf, GraphOptions{Strength: StrengthMeasure(7), Data: ds}); err == nil {
+		t.Fatal("expected an error for an unknown measure")
+	}
+	// data without a column for D, a Parent of G
+	partial := make([]map[*Node]int, len(samples))
+	for i, sample := range samples {
+		partial[i] = map[*Node]int{I: sample[I], S: sample[S], G: sample[G]}
+	}
+	opts = GraphOptions{Strength: ScoreStrength, Data: DatasetFromInstances(partial)}
+	if err := network.WriteGraphML(&buf, opts); err == nil || !strings.Contains(err.Error(), `"D"`) {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	network := initStudentNetwork()
+	network.Nodes[0].Position = &Position{X: 1, Y: 2}
+	r := rand.New(rand.NewSource(1))
+	samples := make([]map[*Node]int, 100)
+	for i := range samples {
+		samples[i] = network.Sample(r)
+	}
+	opts := GraphOptions{CPDs: true, Strength: ScoreStrength, Data: DatasetFromInstances(samples)}
+
+	var buf bytes.Buffer
+	if err := network.WriteGraphML(&buf, opts); err != nil {
+		t.Fatal(err)
+	}
+	var read graphML
+	if err := xml.Unmarshal(buf.Bytes(), &read); err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Graph.Nodes) != 5 || len(read.Graph.Edges) != 4 {
+		t.Fatal(read.Graph)
+	}
+	// I has a position; G's CPD has a line per joint State of I and D
+	if len(read.Graph.Nodes[0].Data) != 6 || read.Graph.Nodes[0].Data[3].Value != "1" {
+		t.Fatal(read.Graph.Nodes[0].Data)
+	}
+	g := read.Graph.Nodes[4].Data
+	if cpd := g[len(g)-1]; cpd.Key != "cpd" || !strings.HasPrefix(cpd.Value, "I=0, D=0: 0.300 0.400 0.300\nI=1, D=0: 0.900 0.080 0.020\n") {
+		t.Fatal(g)
+	}
+	if read.Graph.Edges[0].Source != "n0" || len(read.Graph.Edges[0].Data) != 1 {
+		t.Fatal(read.Graph.Edges[0])
+	}
+}
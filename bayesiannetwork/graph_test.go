@@ -0,0 +1,108 @@
+package bayesiannetwork
+
+import (
+	"testing"
+)
+
+func sameNodes(a, b []*Node) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDSeparated(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	cases := []struct {
+		xs, ys, zs []*Node
+		separated  bool
+	}{
+		{[]*Node{sprinkler}, []*Node{rain}, []*Node{cloudy}, true},
+		{[]*Node{sprinkler}, []*Node{rain}, []*Node{}, false},
+		// observing the common effect opens the v-structure
+		{[]*Node{sprinkler}, []*Node{rain}, []*Node{cloudy, wet}, false},
+		{[]*Node{cloudy}, []*Node{wet}, []*Node{sprinkler, rain}, true},
+		{[]*Node{cloudy}, []*Node{wet}, []*Node{sprinkler}, false},
+	}
+	for _, c := range cases {
+		if network.DSeparated(c.xs, c.ys, c.zs) != c.separated {
+			t.Fatal(c)
+		}
+	}
+
+	if connected := network.DConnected([]*Node{sprinkler}, []*Node{cloudy}); !sameNodes(connected, []*Node{sprinkler, wet}) {
+		t.Fatal(connected)
+	}
+	if connected := network.DConnected([]*Node{sprinkler}, []*Node{cloudy, wet}); !sameNodes(connected, []*Node{sprinkler, rain}) {
+		t.Fatal(connected)
+	}
+}
+
+func TestGraphSets(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	if ancestors := network.Ancestors(wet); !sameNodes(ancestors, []*Node{cloudy, sprinkler, rain}) {
+		t.Fatal(ancestors)
+	}
+	if ancestors := network.Ancestors(cloudy); len(ancestors) != 0 {
+		t.Fatal(ancestors)
+	}
+	if descendants := network.Descendants(cloudy); !sameNodes(descendants, []*Node{sprinkler, rain, wet}) {
+		t.Fatal(descendants)
+	}
+	if blanket := network.MarkovBlanket(sprinkler); !sameNodes(blanket, []*Node{cloudy, rain, wet}) {
+		t.Fatal(blanket)
+	}
+	if blanket := network.MarkovBlanket(cloudy); !sameNodes(blanket, []*Node{sprinkler, rain}) {
+		t.Fatal(blanket)
+	}
+
+	moral := network.MoralGraph()
+	expected := map[*Node][]*Node{
+		cloudy:    {sprinkler, rain},
+		sprinkler: {cloudy, rain, wet},
+		rain:      {cloudy, sprinkler, wet},
+		wet:       {sprinkler, rain},
+	}
+	for n, neighbours := range expected {
+		if !sameNodes(moral[n], neighbours) {
+			t.Fatal(n.Name, moral[n])
+		}
+	}
+}
+
+func TestIndependencies(t *testing.T) {
+	network, _, _, _, _ := initSprinkler(t)
+	local := network.LocalIndependencies()
+	expected := []string{
+		"sprinkler ⊥ rain | cloudy",
+		"rain ⊥ sprinkler | cloudy",
+		"wet ⊥ cloudy | sprinkler, rain",
+	}
+	if len(local) != len(expected) {
+		t.Fatal(local)
+	}
+	for i, ind := range local {
+		if ind.String() != expected[i] {
+			t.Fatal(ind)
+		}
+	}
+
+	all := network.Independencies(-1)
+	expected = []string{"cloudy ⊥ wet | sprinkler, rain", "sprinkler ⊥ rain | cloudy"}
+	if len(all) != len(expected) {
+		t.Fatal(all)
+	}
+	for i, ind := range all {
+		if ind.String() != expected[i] {
+			t.Fatal(ind)
+		}
+	}
+	if one := network.Independencies(1); len(one) != 1 || one[0].String() != expected[1] {
+		t.Fatal(one)
+	}
+}
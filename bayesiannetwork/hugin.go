@@ -0,0 +1,373 @@
+package bayesiannetwork
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the syntax of Hugin .net files
+var huginSyntax = syntax{
+	format:      "hugin",
+	punctuation: "{}()=;|",
+	lineComment: "%"}
+
+// Node attributes that only matter to Hugin
+var huginIgnored = map[string]bool{"label": true, "subtype": true, "state_values": true}
+
+type huginParser struct {
+	parser
+	net    *BayesianNetwork
+	byName map[string]*Node
+	// the kind of each node that isn't supported
+	skipped  map[string]string
+	defined  map[*Node]bool
+	warnings []string
+}
+
+func (p *huginParser) warnf(format string, args ...interface{}) {
+	line := 0
+	if p.pos > 0 {
+		line = p.tokens[p.pos-1].line
+	}
+	p.warnings = append(p.warnings, fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, args...)))
+}
+
+// the text of a quoted string, or the word
+func unquote(text string) string {
+	if !strings.HasPrefix(text, `"`) {
+		return text
+	}
+	if s, err := strconv.Unquote(text); err == nil {
+		return s
+	}
+	return strings.Trim(text, `"`)
+}
+
+// the value of an attribute: a word, or the words of a (nested) list
+func (p *huginParser) value() ([]string, error) {
+	if p.peek() != "(" {
+		w, err := p.word()
+		return []string{unquote(w)}, err
+	}
+	words := make([]string, 0)
+	for depth := 0; ; {
+		text, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		default:
+			words = append(words, unquote(text))
+		}
+		if depth == 0 {
+			return words, nil
+		}
+	}
+}
+
+// the name and value of each attribute of a block
+func (p *huginParser) attributes(each func(name string, value []string) error) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		name, err := p.word()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("="); err != nil {
+			return err
+		}
+		value, err := p.value()
+		if err != nil {
+			return err
+		}
+		if err := p.expect(";"); err != nil {
+			return err
+		}
+		if err := each(name, value); err != nil {
+			return err
+		}
+	}
+	p.pos++
+	return nil
+}
+
+func (p *huginParser) node(kind string) error {
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+	if _, exists := p.byName[name]; exists || p.skipped[name] != "" {
+		return p.errorf("duplicate node %q", name)
+	}
+	if kind != "node" {
+		p.warnf("%s node %q isn't supported and is skipped", kind, name)
+		p.skipped[name] = kind
+		return p.skipBlock()
+	}
+
+	n := &Node{Name: name}
+	err = p.attributes(func(attribute string, value []string) error {
+		switch {
+		case attribute == "states":
+			n.States, n.StateLabels = len(value), value
+		case attribute == "position":
+			if len(value) != 2 {
+				return p.errorf("node %q has an invalid position", name)
+			}
+			x, xErr := strconv.ParseFloat(value[0], 64)
+			y, yErr := strconv.ParseFloat(value[1], 64)
+			if xErr != nil || yErr != nil {
+				return p.errorf("node %q has an invalid position", name)
+			}
+			n.Position = &Position{X: x, Y: y}
+		case huginIgnored[attribute] || strings.HasPrefix(attribute, "HR_"):
+		default:
+			p.warnf("ignoring attribute %q of node %q", attribute, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n.States == 0 {
+		return p.errorf("node %q has no states", name)
+	}
+	p.byName[name] = n
+	p.net.Nodes = append(p.net.Nodes, n)
+	return nil
+}
+
+func (p *huginParser) potential() error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	heads := []string{}
+	for p.peek() != "|" && p.peek() != ")" {
+		w, err := p.word()
+		if err != nil {
+			return err
+		}
+		heads = append(heads, w)
+	}
+	names := []string{}
+	var err error
+	if p.peek() == "|" {
+		p.pos++
+		if names, err = p.list(")", ""); err != nil {
+			return err
+		}
+	} else {
+		p.pos++
+	}
+	if len(heads) != 1 {
+		return p.errorf("potentials over %d nodes aren't supported", len(heads))
+	}
+	if kind := p.skipped[heads[0]]; kind != "" {
+		return p.skipBlock()
+	}
+	n, exists := p.byName[heads[0]]
+	if !exists {
+		return p.errorf("potential for unknown node %q", heads[0])
+	}
+	if p.defined[n] {
+		return p.errorf("node %q has two potentials", n.Name)
+	}
+	p.defined[n] = true
+	for _, name := range names {
+		if kind := p.skipped[name]; kind != "" {
+			return p.errorf("node %q depends on %q, a %s node, which isn't supported",
+				n.Name, name, kind)
+		}
+		parent, exists := p.byName[name]
+		if !exists {
+			return p.errorf("node %q has unknown parent %q", n.Name, name)
+		}
+		if parent == n || containsNode(n.Parents, parent) {
+			return p.errorf("node %q can't have %q as a parent", n.Name, name)
+		}
+		p.net.AddEdge(parent, n)
+	}
+
+	var data []string
+	err = p.attributes(func(attribute string, value []string) error {
+		switch attribute {
+		case "data":
+			data = value
+		case "model_nodes", "model_data":
+			p.warnf("ignoring the %s of %q; its data table is used", attribute, n.Name)
+		case "experience", "fading":
+		default:
+			p.warnf("ignoring attribute %q of the potential of %q", attribute, n.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return p.errorf("the potential of %q has no data", n.Name)
+	}
+	values, err := parseTable(n, data)
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	setRows(n, rowsFromFlat(n, values))
+	return nil
+}
+
+// read a network in the Hugin .net format, with the Nodes in topological
+// order (otherwise in the order they are declared), their states as State labels and their positions.
+// Decision, utility, continuous and function nodes aren't supported: they
+// are skipped with a warning, as are attributes that can't be represented,
+// and a chance node that depends on one is an error.
+func ReadHugin(r io.Reader) (*BayesianNetwork, []string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := tokenize(string(data), huginSyntax)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &huginParser{
+		parser:  parser{tokens: tokens, syntax: huginSyntax},
+		net:     NewBayesianNetwork(),
+		byName:  make(map[string]*Node),
+		skipped: make(map[string]string),
+		defined: make(map[*Node]bool)}
+	for !p.eof() {
+		text, _ := p.next()
+		switch text {
+		case "net":
+			err = p.skipBlock()
+		case "class":
+			err = p.errorf("object-oriented classes aren't supported")
+		case "discrete":
+			if kind := p.peek(); kind == "node" || kind == "decision" || kind == "function" {
+				continue
+			}
+			err = p.errorf("expected a node after \"discrete\"")
+		case "continuous":
+			if _, err = p.next(); err == nil {
+				err = p.node(text)
+			}
+		case "node", "decision", "utility", "function":
+			err = p.node(text)
+		case "potential":
+			err = p.potential()
+		default:
+			p.pos--
+			err = p.errorf("unexpected %q", text)
+		}
+		if err != nil {
+			return nil, p.warnings, err
+		}
+	}
+	for _, n := range p.net.Nodes {
+		if !p.defined[n] {
+			return nil, p.warnings, fmt.Errorf("bayesiannetwork: hugin node %q has no potential", n.Name)
+		}
+	}
+	p.net.stableTopologicalSort()
+	if err := p.net.Validate(); err != nil {
+		return nil, p.warnings, err
+	}
+	return p.net, p.warnings, nil
+}
+
+// load a network from a Hugin .net file
+func LoadHugin(path string) (*BayesianNetwork, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadHugin(f)
+}
+
+// whether s is a name in Hugin and GeNIe: a letter or underscore followed
+// by letters, digits and underscores
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		letter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// the values as nested lists, the first dimension outermost
+func nestValues(values []string, dims []int) string {
+	if len(dims) == 1 {
+		return "(" + strings.Join(values, " ") + ")"
+	}
+	size := len(values) / dims[0]
+	nested := make([]string, dims[0])
+	for i := range nested {
+		nested[i] = nestValues(values[i*size:(i+1)*size], dims[1:])
+	}
+	return "(" + strings.Join(nested, " ") + ")"
+}
+
+// write the network in the Hugin .net format.  Any CPD is written as its
+// table.  Node names must be identifiers.
+func (net BayesianNetwork) WriteHugin(w io.Writer) error {
+	if err := net.Validate(); err != nil {
+		return err
+	}
+	for _, n := range net.Nodes {
+		if !isIdentifier(n.Name) {
+			return fmt.Errorf("bayesiannetwork: node name %q can't be written in hugin", n.Name)
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprint(b, "net\n{\n}\n")
+	for _, n := range net.Nodes {
+		labels := make([]string, n.States)
+		for s := range labels {
+			labels[s] = strconv.Quote(n.StateLabel(s))
+		}
+		fmt.Fprintf(b, "\nnode %s\n{\n", n.Name)
+		if n.Position != nil {
+			fmt.Fprintf(b, "    position = (%s %s);\n",
+				formatProbability(n.Position.X), formatProbability(n.Position.Y))
+		}
+		fmt.Fprintf(b, "    states = (%s);\n}\n", strings.Join(labels, " "))
+	}
+	for _, n := range net.Nodes {
+		rows, err := probabilityRows(n)
+		if err != nil {
+			return err
+		}
+		dims := make([]int, 0, len(n.Parents)+1)
+		parents := ""
+		for _, p := range n.Parents {
+			parents += " " + p.Name
+			dims = append(dims, p.States)
+		}
+		if parents != "" {
+			parents = " |" + parents
+		}
+		values := strings.Fields(joinProbabilities(flatFromRows(n, rows), " "))
+		fmt.Fprintf(b, "\npotential (%s%s)\n{\n    data = %s;\n}\n",
+			n.Name, parents, nestValues(values, append(dims, n.States)))
+	}
+	return b.Flush()
+}
+
+// save the network to a Hugin .net file
+func (net BayesianNetwork) SaveHugin(path string) error {
+	return saveFile(path, net.WriteHugin)
+}
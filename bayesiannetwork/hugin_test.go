@@ -0,0 +1,195 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testHugin = `net
+{
+    node_size = (80 40);
+    HR_Grid_X = "10";
+}
+
+% a chance node with a position
+node a
+{
+    label = "A";
+    position = (100 -50);
+    states = ("t" "f");
+    HR_Desc = "";
+}
+
+discrete node b
+{
+    states = ("x" "y" "z");
+    subtype = labelled;
+    colour = "red";
+}
+
+node c
+{
+    states = ("t" "f");
+}
+
+decision d
+{
+    states = ("go" "stop");
+}
+
+utility u
+{
+}
+
+potential (a)
+{
+    data = (0.2 0.8);
+}
+
+potential (b)
+{
+    data = (0.2 0.3 0.5);
+    experience = 10;
+}
+
+potential (c | a b)
+{
+    data = (((0.1 0.9) (0.2 0.8) (0.3 0.7))
+            ((0.4 0.6) (0.5 0.5) (0.6 0.4)));
+    model_nodes = ();
+}
+
+potential (d)
+{
+}
+
+potential (u | d)
+{
+    data = (10 -5);
+}
+`
+
+func TestReadHugin(t *testing.T) {
+	network, warnings, err := ReadHugin(strings.NewReader(testHugin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 3 {
+		t.Fatal(len(network.Nodes))
+	}
+	a, b, c := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	if *a.Position != (Position{100, -50}) || b.StateLabel(2) != "z" || c.Position != nil {
+		t.Fatal(a.Position, b.StateLabels)
+	}
+	if c.Parents[0] != a || c.Parents[1] != b {
+		t.Fatal(c.Parents)
+	}
+	// the child varies fastest, then the last parent
+	if p := c.conditionalProbability(map[*Node]int{a: 1, b: 1, c: 0}); math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+	if p := c.conditionalProbability(map[*Node]int{a: 0, b: 2, c: 1}); math.Abs(p-.7) > 1e-12 {
+		t.Fatal(p)
+	}
+	// the colour, the model, the decision and the utility
+	if len(warnings) != 4 {
+		t.Fatal(warnings)
+	}
+
+	replace := func(old, new string) string {
+		return strings.Replace(testHugin, old, new, 1)
+	}
+	cases := map[string]string{
+		"syntax":    replace("states = (\"t\" \"f\");", "states = (\"t\" \"f\")"),
+		"class":     "class c\n{\n}\n",
+		"unknown":   replace("(c | a b)", "(c | a e)"),
+		"count":     replace("(0.6 0.4)", "(0.6)"),
+		"number":    replace("(0.2 0.8)", "(0.2 x)"),
+		"decision":  replace("(c | a b)", "(c | a d)"),
+		"undefined": replace("potential (a)", "potential (e)"),
+		"data":      replace("data = (0.2 0.3 0.5);", ""),
+		"twice":     replace("potential (b)", "potential (a)"),
+		"states":    replace("states = (\"x\" \"y\" \"z\");", ""),
+	}
+	for name, data := range cases {
+		if _, _, err := ReadHugin(strings.NewReader(data)); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+}
+
+// the fraction of samples of the net in which n has State s
+func sampledFrequency(net *BayesianNetwork, n *Node, s int) float64 {
+	r := rand.New(rand.NewSource(1))
+	count := 0
+	for i := 0; i < 2000; i++ {
+		if net.Sample(r)[n] == s {
+			count++
+		}
+	}
+	return float64(count) / 2000
+}
+
+func TestHuginChildFirst(t *testing.T) {
+	// c is declared before its Parents
+	input := strings.Replace(testHugin, "node c\n{\n    states = (\"t\" \"f\");\n}\n", "", 1)
+	input = strings.Replace(input, "% a chance node", "node c\n{\n    states = (\"t\" \"f\");\n}\n\n% a chance node", 1)
+	if strings.Index(input, "node c") > strings.Index(input, "node a") {
+		t.Fatal("c isn't declared first")
+	}
+	network, _, err := ReadHugin(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	if a.Name != "a" || b.Name != "b" || c.Name != "c" {
+		t.Fatal(network.Nodes)
+	}
+	// P(c = t) = .2 * (.2 * .1 + .3 * .2 + .5 * .3) + .8 * (.2 * .4 + .3 * .5 + .5 * .6)
+	if p := sampledFrequency(network, c, 0); math.Abs(p-.47) > .04 {
+		t.Fatal(p)
+	}
+}
+
+func TestWriteHugin(t *testing.T) {
+	network, _, err := ReadHugin(strings.NewReader(testHugin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	network.Nodes[1].StateLabels[0] = `say "x"`
+	var buf bytes.Buffer
+	if err := network.WriteHugin(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, warnings, err := ReadHugin(&buf)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err, warnings)
+	}
+	checkSameNetwork(t, network, read)
+	if *read.Nodes[0].Position != *network.Nodes[0].Position {
+		t.Fatal(read.Nodes[0].Position)
+	}
+
+	asia, err := ReadBIF(strings.NewReader(asiaBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "asia.net")
+	if err := asia.SaveHugin(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _, err := LoadHugin(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, asia, loaded)
+
+	asia.Nodes[0].Name = "1asia"
+	if err := asia.WriteHugin(&buf); err == nil {
+		t.Fatal("expected an error for a name that isn't an identifier")
+	}
+}
@@ -0,0 +1,410 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A HybridNetwork is a discrete BayesianNetwork plus ContinuousNodes, which
+// may have discrete Parents.  Discrete Nodes can't have continuous Parents.
+type HybridNetwork struct {
+	Discrete   *BayesianNetwork
+	Continuous []*ContinuousNode
+}
+
+// an observation of some of the Nodes of a HybridNetwork
+type HybridInstance struct {
+	Discrete   map[*Node]int
+	Continuous map[*ContinuousNode]float64
+}
+
+// the posterior distributions from a query of a HybridNetwork
+type HybridPosterior struct {
+	Discrete   map[*Node]Density
+	Continuous map[*ContinuousNode]GaussianMixture
+}
+
+// initialize a hybrid net around a discrete net, which may be nil
+func NewHybridNetwork(discrete *BayesianNetwork) *HybridNetwork {
+	if discrete == nil {
+		discrete = NewBayesianNetwork()
+	}
+	return &HybridNetwork{Discrete: discrete, Continuous: make([]*ContinuousNode, 0)}
+}
+
+// add a directed edge between ContinuousNodes p and c, unless it would make
+// a cycle
+func (h *HybridNetwork) AddContinuousEdge(p, c *ContinuousNode) error {
+	if continuousPath(c, p, make(map[*ContinuousNode]bool)) {
+		return fmt.Errorf("bayesiannetwork: an edge from %q to %q makes a cycle", p.Name, c.Name)
+	}
+	c.Parents = append(c.Parents, p)
+	p.Children = append(p.Children, c)
+	return nil
+}
+
+// whether there is a directed path from ContinuousNode a to b
+func continuousPath(a, b *ContinuousNode, visited map[*ContinuousNode]bool) bool {
+	if a == b {
+		return true
+	}
+	visited[a] = true
+	for _, child := range a.Children {
+		if !visited[child] && continuousPath(child, b, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// add a directed edge from discrete Node p to ContinuousNode c
+func (h *HybridNetwork) AddDiscreteEdge(p *Node, c *ContinuousNode) {
+	c.DiscreteParents = append(c.DiscreteParents, p)
+}
+
+// the ContinuousNodes in topological order
+func (h *HybridNetwork) continuousOrder() ([]*ContinuousNode, error) {
+	remaining := make(map[*ContinuousNode]int)
+	ready := make([]*ContinuousNode, 0)
+	for _, n := range h.Continuous {
+		if len(n.Parents) == 0 {
+			ready = append(ready, n)
+		}
+		remaining[n] = len(n.Parents)
+	}
+	sorted := make([]*ContinuousNode, 0, len(h.Continuous))
+	for len(ready) > 0 {
+		n := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, n)
+		for _, child := range n.Children {
+			remaining[child]--
+			if remaining[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	if len(sorted) != len(h.Continuous) {
+		return nil, errors.New("bayesiannetwork: continuous nodes have cycles")
+	}
+	return sorted, nil
+}
+
+// the values of the continuous Parents of n in an instance, and whether they
+// were all observed
+func parentValues(n *ContinuousNode, values map[*ContinuousNode]float64) ([]float64, bool) {
+	x := make([]float64, len(n.Parents))
+	for i, p := range n.Parents {
+		v, observed := values[p]
+		if !observed {
+			return nil, false
+		}
+		x[i] = v
+	}
+	return x, true
+}
+
+// the ContinuousNodes in topological order, or an error if either the
+// discrete or the continuous Nodes have cycles
+func (h *HybridNetwork) checkCycles() ([]*ContinuousNode, error) {
+	if HasCycles(h.Discrete) {
+		return nil, errors.New("bayesiannetwork: discrete nodes have cycles")
+	}
+	return h.continuousOrder()
+}
+
+// draw a sample of every Node of the network
+func (h *HybridNetwork) Sample(r *rand.Rand) (HybridInstance, error) {
+	order, err := h.checkCycles()
+	if err != nil {
+		return HybridInstance{}, err
+	}
+	if err := h.Discrete.checkStale(); err != nil {
+		return HybridInstance{}, err
+	}
+	h.Discrete.topologicalSort()
+	sample := HybridInstance{
+		Discrete:   h.Discrete.Sample(r),
+		Continuous: make(map[*ContinuousNode]float64)}
+	for _, n := range order {
+		g := n.Regressions[n.regressionIndex(sample.Discrete)]
+		x, _ := parentValues(n, sample.Continuous)
+		sample.Continuous[n] = g.Mean(x) + math.Sqrt(g.Variance)*r.NormFloat64()
+	}
+	return sample, nil
+}
+
+// learn the CPDs of the discrete Nodes and the regressions of the
+// ContinuousNodes by maximum likelihood.  Each regression is fit to the
+// instances where the Node and all of its Parents are observed; a joint State
+// of the discrete Parents that is never observed gets the regression fit to
+// all of the instances.
+func (h *HybridNetwork) Fit(data []HybridInstance) error {
+	if _, err := h.checkCycles(); err != nil {
+		return err
+	}
+	if len(h.Discrete.Nodes) > 0 {
+		discrete := make([]map[*Node]int, len(data))
+		for i, instance := range data {
+			discrete[i] = instance.Discrete
+		}
+		h.Discrete.updateWeights(discrete)
+	}
+
+	for _, n := range h.Continuous {
+		xs := make([][][]float64, n.configurations())
+		ys := make([][]float64, n.configurations())
+		allX := make([][]float64, 0)
+		allY := make([]float64, 0)
+		for _, instance := range data {
+			y, observed := instance.Continuous[n]
+			x, parentsObserved := parentValues(n, instance.Continuous)
+			if !observed || !parentsObserved {
+				continue
+			}
+			allX = append(allX, x)
+			allY = append(allY, y)
+
+			discreteObserved := true
+			for _, p := range n.DiscreteParents {
+				if _, exists := instance.Discrete[p]; !exists {
+					discreteObserved = false
+				}
+			}
+			if discreteObserved {
+				index := n.regressionIndex(instance.Discrete)
+				xs[index] = append(xs[index], x)
+				ys[index] = append(ys[index], y)
+			}
+		}
+
+		pooled := fitLinearGaussian(allX, allY, len(n.Parents))
+		n.Regressions = make([]LinearGaussian, n.configurations())
+		for index := range n.Regressions {
+			if len(ys[index]) == 0 {
+				n.Regressions[index] = pooled
+			} else {
+				n.Regressions[index] = fitLinearGaussian(xs[index], ys[index], len(n.Parents))
+			}
+		}
+	}
+	return nil
+}
+
+// the log likelihood (density for the continuous part) of fully observed
+// instances
+func (h *HybridNetwork) LogLikelihood(data []HybridInstance) float64 {
+	ll := 0.0
+	for _, instance := range data {
+		for _, n := range h.Discrete.Nodes {
+			ll += math.Log(n.conditionalProbability(instance.Discrete))
+		}
+		for _, n := range h.Continuous {
+			g := n.Regressions[n.regressionIndex(instance.Discrete)]
+			x, _ := parentValues(n, instance.Continuous)
+			ll += math.Log(normalDensity(instance.Continuous[n], g.Mean(x), g.Variance))
+		}
+	}
+	return ll
+}
+
+// exact posterior distributions of discrete and continuous Nodes given
+// evidence on any Nodes.  For every joint State of the unobserved discrete
+// Nodes that the query depends on, the ContinuousNodes are jointly Gaussian,
+// so the continuous evidence is conditioned on exactly; the cost grows with
+// the number of those joint States.  Continuous posteriors are mixtures with
+// a component per joint State.
+func (h *HybridNetwork) Posterior(
+	discrete []*Node,
+	continuous []*ContinuousNode,
+	evidence HybridInstance) (*HybridPosterior, error) {
+
+	order, err := h.continuousOrder()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.Discrete.checkStale(); err != nil {
+		return nil, err
+	}
+	if err := validateEvidence(evidence.Discrete); err != nil {
+		return nil, err
+	}
+	position := make(map[*ContinuousNode]int, len(order))
+	for i, n := range order {
+		position[n] = i
+	}
+
+	// the unobserved discrete Nodes that select regressions or are queried
+	relevant := make(map[*Node]bool)
+	for _, n := range order {
+		for _, p := range n.DiscreteParents {
+			relevant[p] = true
+		}
+	}
+	for _, n := range discrete {
+		relevant[n] = true
+	}
+	for n, _ := range evidence.Discrete {
+		delete(relevant, n)
+	}
+	joint := &Factor{Nodes: []*Node{}, Values: []float64{1}}
+	if len(h.Discrete.Nodes) > 0 {
+		joint = eliminate(h.Discrete.factors(evidence.Discrete), relevant, false)
+	}
+
+	// the observed ContinuousNodes and their values
+	observed := make([]int, 0)
+	values := make([]float64, 0)
+	for _, n := range order {
+		if v, exists := evidence.Continuous[n]; exists {
+			observed = append(observed, position[n])
+			values = append(values, v)
+		}
+	}
+
+	type component struct {
+		states    map[*Node]int
+		logWeight float64
+		means     map[*ContinuousNode]float64
+		variances map[*ContinuousNode]float64
+	}
+	components := make([]component, 0)
+	maxLogWeight := math.Inf(-1)
+
+	for index, p := range joint.Values {
+		if p <= 0 {
+			continue
+		}
+		states := make(map[*Node]int)
+		for n, s := range evidence.Discrete {
+			states[n] = s
+		}
+		for i, s := range joint.states(index) {
+			states[joint.Nodes[i]] = s
+		}
+
+		mean, cov := h.gaussian(order, position, states)
+		c := component{
+			states:    states,
+			logWeight: math.Log(p),
+			means:     make(map[*ContinuousNode]float64),
+			variances: make(map[*ContinuousNode]float64)}
+
+		// condition on the continuous evidence
+		var l [][]float64
+		var alpha []float64
+		if len(observed) > 0 {
+			see := make([][]float64, len(observed))
+			diff := make([]float64, len(observed))
+			for i, a := range observed {
+				see[i] = make([]float64, len(observed))
+				for j, b := range observed {
+					see[i][j] = cov[a][b]
+				}
+				diff[i] = values[i] - mean[a]
+			}
+			if l, err = cholesky(see); err != nil {
+				return nil, err
+			}
+			alpha = choleskySolve(l, diff)
+			for i := range diff {
+				c.logWeight -= diff[i]*alpha[i]/2 + math.Log(l[i][i]) + math.Log(2*math.Pi)/2
+			}
+		}
+
+		for _, n := range continuous {
+			q := position[n]
+			if v, exists := evidence.Continuous[n]; exists {
+				c.means[n], c.variances[n] = v, 0
+				continue
+			}
+			c.means[n], c.variances[n] = mean[q], cov[q][q]
+			if len(observed) > 0 {
+				sqe := make([]float64, len(observed))
+				for i, a := range observed {
+					sqe[i] = cov[q][a]
+				}
+				gain := choleskySolve(l, sqe)
+				for i := range sqe {
+					c.means[n] += sqe[i] * alpha[i]
+					c.variances[n] -= sqe[i] * gain[i]
+				}
+			}
+		}
+		components = append(components, c)
+		maxLogWeight = math.Max(maxLogWeight, c.logWeight)
+	}
+	if len(components) == 0 || math.IsInf(maxLogWeight, -1) {
+		return nil, ErrImpossibleEvidence
+	}
+
+	// normalize the weights of the components
+	total := 0.0
+	weights := make([]float64, len(components))
+	for i, c := range components {
+		weights[i] = math.Exp(c.logWeight - maxLogWeight)
+		total += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= total
+	}
+
+	posterior := &HybridPosterior{
+		Discrete:   make(map[*Node]Density),
+		Continuous: make(map[*ContinuousNode]GaussianMixture)}
+	for _, n := range discrete {
+		if n.States == 0 {
+			return nil, fmt.Errorf("bayesiannetwork: node %q has no states", n.Name)
+		}
+		probs := make([]float64, n.States)
+		for i, c := range components {
+			probs[c.states[n]] += weights[i]
+		}
+		posterior.Discrete[n] = NewDensity(probs...)
+	}
+	for _, n := range continuous {
+		m := GaussianMixture{}
+		for i, c := range components {
+			m.Weights = append(m.Weights, weights[i])
+			m.Means = append(m.Means, c.means[n])
+			m.Variances = append(m.Variances, c.variances[n])
+		}
+		posterior.Continuous[n] = m
+	}
+	return posterior, nil
+}
+
+// the joint Gaussian of the ContinuousNodes (in topological order) given the
+// States of their discrete Parents
+func (h *HybridNetwork) gaussian(
+	order []*ContinuousNode,
+	position map[*ContinuousNode]int,
+	states map[*Node]int) ([]float64, [][]float64) {
+
+	mean := make([]float64, len(order))
+	cov := make([][]float64, len(order))
+	for i := range cov {
+		cov[i] = make([]float64, len(order))
+	}
+	for i, n := range order {
+		g := n.Regressions[n.regressionIndex(states)]
+		mean[i] = g.Intercept
+		for j, p := range n.Parents {
+			mean[i] += g.Weights[j] * mean[position[p]]
+		}
+		// covariance with the Nodes before n, then the variance of n
+		for k := 0; k < i; k++ {
+			for j, p := range n.Parents {
+				cov[i][k] += g.Weights[j] * cov[position[p]][k]
+			}
+			cov[k][i] = cov[i][k]
+		}
+		cov[i][i] = g.Variance
+		for j, p := range n.Parents {
+			cov[i][i] += g.Weights[j] * cov[position[p]][i]
+		}
+	}
+	return mean, cov
+}
@@ -0,0 +1,173 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// D -> X -> Y where D is discrete and X and Y are continuous
+func initHybridNetwork() (*HybridNetwork, *Node, *ContinuousNode, *ContinuousNode) {
+	d := &Node{Name: "D", States: 2, cpd: []Density{NewDensity(.3, .7)}}
+	discrete := NewBayesianNetwork()
+	discrete.Nodes = append(discrete.Nodes, d)
+
+	x := &ContinuousNode{Name: "X", Regressions: []LinearGaussian{
+		{Intercept: 0, Variance: 1},
+		{Intercept: 5, Variance: 1}}}
+	y := &ContinuousNode{Name: "Y"}
+
+	h := NewHybridNetwork(discrete)
+	h.Continuous = append(h.Continuous, y, x)
+	h.AddDiscreteEdge(d, x)
+	h.AddContinuousEdge(x, y)
+	y.Regressions = []LinearGaussian{
+		{Intercept: 1, Weights: []float64{2}, Variance: .5}}
+	return h, d, x, y
+}
+
+func TestHybridPosterior(t *testing.T) {
+	h, d, x, y := initHybridNetwork()
+
+	// without evidence X is a mixture of the two regressions and Y = 2X + 1
+	prior, err := h.Posterior([]*Node{d}, []*ContinuousNode{x, y}, HybridInstance{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(prior.Discrete[d].StateMap[1]-.7) > 1e-9 {
+		t.Fail()
+	}
+	if math.Abs(prior.Continuous[x].Mean()-3.5) > 1e-9 ||
+		math.Abs(prior.Continuous[y].Mean()-8) > 1e-9 {
+		t.Fail()
+	}
+
+	// Y | D ~ N(2 mu_D + 1, 4 + .5)
+	observed := 4.0
+	w0 := .3 * normalDensity(observed, 1, 4.5)
+	w1 := .7 * normalDensity(observed, 11, 4.5)
+	posterior, err := h.Posterior([]*Node{d}, []*ContinuousNode{x},
+		HybridInstance{Continuous: map[*ContinuousNode]float64{y: observed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(posterior.Discrete[d].StateMap[0]-w0/(w0+w1)) > 1e-9 {
+		t.Fatal(posterior.Discrete[d].StateMap, w0/(w0+w1))
+	}
+
+	// X | Y, D: precision 1 + 4/.5, mean (mu_D + 2 (y - 1)/.5) / 9
+	m := posterior.Continuous[x]
+	for i, mu := range []float64{0, 5} {
+		if math.Abs(m.Means[i]-(mu+4*(observed-1))/9) > 1e-9 ||
+			math.Abs(m.Variances[i]-1.0/9) > 1e-9 {
+			t.Fatal(m)
+		}
+	}
+
+	// discrete evidence selects a single regression
+	given, err := h.Posterior(nil, []*ContinuousNode{y},
+		HybridInstance{Discrete: map[*Node]int{d: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(given.Continuous[y].Mean()-11) > 1e-9 ||
+		math.Abs(given.Continuous[y].Variance()-4.5) > 1e-9 {
+		t.Fail()
+	}
+}
+
+func TestHybridSampleAndFit(t *testing.T) {
+	h, d, x, y := initHybridNetwork()
+	r := rand.New(rand.NewSource(1))
+	data := make([]HybridInstance, 0)
+	for i := 0; i < 5000; i++ {
+		sample, err := h.Sample(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, sample)
+	}
+	truth := h.LogLikelihood(data)
+
+	// refit a copy of the structure from the samples
+	learned, ld, lx, ly := initHybridNetwork()
+	ld.cpd = nil
+	lx.Regressions, ly.Regressions = nil, nil
+	translated := make([]HybridInstance, len(data))
+	for i, s := range data {
+		translated[i] = HybridInstance{
+			Discrete:   map[*Node]int{ld: s.Discrete[d]},
+			Continuous: map[*ContinuousNode]float64{lx: s.Continuous[x], ly: s.Continuous[y]}}
+	}
+	if err := learned.Fit(translated); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ld.cpd[0].StateMap[1]-.7) > .03 {
+		t.Fail()
+	}
+	if math.Abs(lx.Regressions[1].Intercept-5) > .1 ||
+		math.Abs(ly.Regressions[0].Weights[0]-2) > .05 ||
+		math.Abs(ly.Regressions[0].Variance-.5) > .05 {
+		t.Fatal(lx.Regressions, ly.Regressions)
+	}
+	// the maximum likelihood fit is at least as likely as the truth
+	if learned.LogLikelihood(translated) < truth {
+		t.Fail()
+	}
+}
+
+func TestHybridCycles(t *testing.T) {
+	a := &ContinuousNode{Name: "A"}
+	b := &ContinuousNode{Name: "B"}
+	h := NewHybridNetwork(nil)
+	h.Continuous = append(h.Continuous, a, b)
+	if err := h.AddContinuousEdge(a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddContinuousEdge(b, a); err == nil || len(a.Parents) != 0 {
+		t.Fatal("expected an error for a cycle")
+	}
+	if err := h.AddContinuousEdge(a, a); err == nil {
+		t.Fatal("expected an error for a self edge")
+	}
+
+	// a cycle made without AddContinuousEdge
+	b.Children = append(b.Children, a)
+	a.Parents = append(a.Parents, b)
+	if err := h.Fit(nil); err == nil {
+		t.Fail()
+	}
+	if _, err := h.Posterior(nil, []*ContinuousNode{a}, HybridInstance{}); err == nil {
+		t.Fail()
+	}
+	if _, err := h.Sample(rand.New(rand.NewSource(1))); err == nil {
+		t.Fail()
+	}
+
+	// a cycle among the discrete Nodes
+	c := &Node{Name: "C", States: 2}
+	d := &Node{Name: "D", States: 2}
+	h = NewHybridNetwork(nil)
+	h.Discrete.Nodes = []*Node{c, d}
+	h.Discrete.AddEdge(c, d)
+	h.Discrete.AddEdge(d, c)
+	if err := h.Fit(nil); err == nil {
+		t.Fail()
+	}
+	if _, err := h.Sample(rand.New(rand.NewSource(1))); err == nil {
+		t.Fail()
+	}
+}
+
+func TestHybridStale(t *testing.T) {
+	h, d, x, _ := initHybridNetwork()
+	e := &Node{Name: "E", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	h.Discrete.Nodes = append(h.Discrete.Nodes, e)
+	h.Discrete.AddEdge(e, d)
+	if _, err := h.Posterior([]*Node{d}, []*ContinuousNode{x}, HybridInstance{}); err == nil {
+		t.Fatal("expected an error for a stale CPD")
+	}
+	if _, err := h.Sample(rand.New(rand.NewSource(1))); err == nil {
+		t.Fatal("expected an error for a stale CPD")
+	}
+}
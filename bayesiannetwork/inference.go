@@ -0,0 +1,247 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// the evidence given to an exact query has probability zero under the network
+var ErrImpossibleEvidence = errors.New("bayesiannetwork: evidence has zero probability")
+
+// check that every observed State exists for its Node
+func validateEvidence(evidence map[*Node]int) error {
+	for n, s := range evidence {
+		if s < 0 || s >= n.States {
+			return fmt.Errorf(
+				"bayesiannetwork: state %d of node %q is out of range [0, %d)",
+				s, n.Name, n.States)
+		}
+	}
+	return nil
+}
+
+// the CPDs of all Nodes of the net as Factors reduced by the evidence
+func (net BayesianNetwork) factors(evidence map[*Node]int) []*Factor {
+	factors := make([]*Factor, 0, len(net.Nodes))
+	for _, n := range net.Nodes {
+		for _, f := range n.factors() {
+			factors = append(factors, f.Reduce(evidence))
+		}
+	}
+	return factors
+}
+
+// the number of entries in the product of the factors
+func productSize(factors []*Factor) int {
+	seen := make(map[*Node]bool)
+	size := 1
+	for _, f := range factors {
+		for _, n := range f.Nodes {
+			if !seen[n] {
+				seen[n] = true
+				size *= n.States
+			}
+		}
+	}
+	return size
+}
+
+// the Nodes of the factors that are not kept in the order that they are
+// first seen, so that ties are broken the same way every time
+func unkept(factors []*Factor, keep map[*Node]bool) []*Node {
+	nodes := make([]*Node, 0)
+	seen := make(map[*Node]bool)
+	for _, f := range factors {
+		for _, n := range f.Nodes {
+			if !seen[n] && !keep[n] {
+				nodes = append(nodes, n)
+			}
+			seen[n] = true
+		}
+	}
+	return nodes
+}
+
+// one step of variable elimination: choose the Node whose elimination
+// creates the smallest Factor, and return it, the Nodes left to eliminate,
+// the product of the Factors containing it (nil if there are none) and the
+// Factors without it
+func eliminationStep(factors []*Factor, eliminated []*Node) (
+	*Node, []*Node, *Factor, []*Factor) {
+
+	best, bestSize := 0, -1
+	for i, n := range eliminated {
+		involved := make([]*Factor, 0)
+		for _, f := range factors {
+			if f.contains(n) {
+				involved = append(involved, f)
+			}
+		}
+		if size := productSize(involved); bestSize == -1 || size < bestSize {
+			best, bestSize = i, size
+		}
+	}
+	n := eliminated[best]
+	eliminated = append(eliminated[:best], eliminated[best+1:]...)
+
+	var product *Factor
+	remaining := make([]*Factor, 0, len(factors))
+	for _, f := range factors {
+		if !f.contains(n) {
+			remaining = append(remaining, f)
+		} else if product == nil {
+			product = f
+		} else {
+			product = product.Product(f)
+		}
+	}
+	return n, eliminated, product, remaining
+}
+
+// variable elimination: combine the factors and eliminate every Node that is
+// not kept, greedily choosing the Node whose elimination creates the smallest
+// Factor.  Eliminated Nodes are summed out, or maximized out when max is
+// set.
+func eliminate(factors []*Factor, keep map[*Node]bool, max bool) *Factor {
+	eliminated := unkept(factors, keep)
+	for len(eliminated) > 0 {
+		var n *Node
+		var product *Factor
+		n, eliminated, product, factors = eliminationStep(factors, eliminated)
+		if product != nil {
+			if max {
+				product = product.MaxOut(n)
+			} else {
+				product = product.SumOut(n)
+			}
+			factors = append(factors, product)
+		}
+	}
+
+	result := &Factor{Nodes: []*Node{}, Values: []float64{1}}
+	for _, f := range factors {
+		result = result.Product(f)
+	}
+	return result
+}
+
+// exact marginal distributions of the dependent Nodes given the evidence,
+// calculated by variable elimination on the ancestors of the dependent and
+// observed Nodes, none of which may have a stale CPD.  Unlike
+// PosteriorDistribution the result is deterministic, but the cost grows
+// with the size of the largest Factor created during elimination.
+func (net BayesianNetwork) ExactPosterior(
+	dependent []*Node,
+	evidence map[*Node]int) (map[*Node]Density, error) {
+
+	if err := validateEvidence(evidence); err != nil {
+		return nil, err
+	}
+	ancestral := net.ancestral(append(observedNodes(evidence), dependent...))
+	if err := ancestral.checkStale(); err != nil {
+		return nil, err
+	}
+	factors := ancestral.factors(evidence)
+	if eliminate(factors, map[*Node]bool{}, false).Values[0] <= 0 {
+		return nil, ErrImpossibleEvidence
+	}
+
+	densities := make(map[*Node]Density, len(dependent))
+	for _, n := range dependent {
+		probs := make([]float64, n.States)
+		if s, observed := evidence[n]; observed {
+			probs[s] = 1
+		} else {
+			f := eliminate(factors, map[*Node]bool{n: true}, false)
+			f.Normalize()
+			for s := range probs {
+				// the factors of a NoisyMax can leave rounding errors
+				// below zero
+				probs[s] = math.Max(0, f.Value(map[*Node]int{n: s}))
+			}
+		}
+		densities[n] = NewDensity(probs...)
+	}
+
+	return densities, nil
+}
+
+// the probability of the evidence under the network
+func (net BayesianNetwork) EvidenceProbability(evidence map[*Node]int) (float64, error) {
+	if err := validateEvidence(evidence); err != nil {
+		return 0, err
+	}
+	ancestral := net.ancestral(observedNodes(evidence))
+	if err := ancestral.checkStale(); err != nil {
+		return 0, err
+	}
+	factors := ancestral.factors(evidence)
+	p := eliminate(factors, map[*Node]bool{}, false).Values[0]
+	return math.Max(0, p), nil
+}
+
+// the most probable explanation: the most likely joint State of all Nodes
+// given the evidence, and its probability jointly with the evidence.
+// Calculated by max-product variable elimination followed by a traceback.
+func (net BayesianNetwork) MostProbableExplanation(
+	evidence map[*Node]int) (map[*Node]int, float64, error) {
+
+	if err := validateEvidence(evidence); err != nil {
+		return nil, 0, err
+	}
+	if err := net.checkStale(); err != nil {
+		return nil, 0, err
+	}
+	// the factorized CPDs are only valid for summing out, so use the tables
+	factors := make([]*Factor, 0, len(net.Nodes))
+	for _, n := range net.Nodes {
+		factors = append(factors, n.factor().Reduce(evidence))
+	}
+
+	assignment, p := maxProduct(factors)
+	if p <= 0 {
+		return nil, 0, ErrImpossibleEvidence
+	}
+	for n, s := range evidence {
+		assignment[n] = s
+	}
+	return assignment, p, nil
+}
+
+// maximize out every Node of the factors, keeping the product that each
+// Node was maximized out of, then assign the Nodes in reverse order of
+// elimination.  Each product only involves Nodes eliminated later, which are
+// already assigned.
+func maxProduct(factors []*Factor) (map[*Node]int, float64) {
+	eliminated := unkept(factors, map[*Node]bool{})
+	order := make([]*Node, 0, len(eliminated))
+	products := make([]*Factor, 0, len(eliminated))
+	for len(eliminated) > 0 {
+		var n *Node
+		var product *Factor
+		n, eliminated, product, factors = eliminationStep(factors, eliminated)
+		order = append(order, n)
+		products = append(products, product)
+		factors = append(factors, product.MaxOut(n))
+	}
+
+	p := 1.0
+	for _, f := range factors {
+		p *= f.Values[0]
+	}
+
+	assignment := make(map[*Node]int)
+	for i := len(order) - 1; i >= 0; i-- {
+		n, product := order[i], products[i]
+		best, bestValue := 0, -1.0
+		for s := 0; s < n.States; s++ {
+			assignment[n] = s
+			if v := product.Value(assignment); v > bestValue {
+				best, bestValue = s, v
+			}
+		}
+		assignment[n] = best
+	}
+	return assignment, p
+}
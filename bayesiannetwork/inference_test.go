@@ -0,0 +1,158 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// the posterior of n by enumerating every joint State of the network
+func enumeratePosterior(
+	network *BayesianNetwork,
+	n *Node,
+	evidence map[*Node]int) []float64 {
+
+	states := make([][]int, 0)
+	sizes := make([]int, len(network.Nodes))
+	for i, m := range network.Nodes {
+		sizes[i] = m.States
+	}
+	network.Nodes[0].getAllParentStates(&states, []int{}, sizes...)
+
+	probs := make([]float64, n.States)
+	for _, joint := range states {
+		instance := make(map[*Node]int)
+		for i, m := range network.Nodes {
+			instance[m] = joint[i]
+		}
+		pass := true
+		for m, s := range evidence {
+			if instance[m] != s {
+				pass = false
+			}
+		}
+		if pass {
+			probs[instance[n]] += network.Likelihood(
+				[]map[*Node]int{instance})[0]
+		}
+	}
+	total := 0.0
+	for _, p := range probs {
+		total += p
+	}
+	for i := range probs {
+		probs[i] /= total
+	}
+	return probs
+}
+
+func TestExactPosterior(t *testing.T) {
+	network := initStudentNetwork()
+	I, S, L, D, G := network.Nodes[0], network.Nodes[1], network.Nodes[2],
+		network.Nodes[3], network.Nodes[4]
+
+	evidences := []map[*Node]int{
+		{},
+		{L: 0},
+		{S: 1, L: 1},
+		{I: 1, D: 0},
+		{G: 2, S: 0}}
+	for _, evidence := range evidences {
+		densities, err := network.ExactPosterior(network.Nodes, evidence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range network.Nodes {
+			solution := enumeratePosterior(network, n, evidence)
+			for s, p := range solution {
+				if math.Abs(densities[n].StateMap[s]-p) > 1e-12 {
+					t.Error(n.Name, evidence, densities[n].StateMap, solution)
+				}
+			}
+		}
+	}
+
+	// the CPD of G is recovered when its Parents are observed
+	densities, _ := network.ExactPosterior(
+		[]*Node{G}, map[*Node]int{I: 1, D: 0})
+	if math.Abs(densities[G].StateMap[0]-.9) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestExactPosteriorErrors(t *testing.T) {
+	network := initStudentNetwork()
+	I := network.Nodes[0]
+	if _, err := network.ExactPosterior(
+		[]*Node{I}, map[*Node]int{I: 2}); err == nil {
+		t.Fail()
+	}
+
+	A := &Node{Name: "A", States: 2, cpd: []Density{NewDensity(1, 0)}}
+	B := &Node{Name: "B", States: 2, cpd: []Density{
+		NewDensity(.5, .5),
+		NewDensity(.5, .5)}}
+	network = NewBayesianNetwork()
+	network.Nodes = []*Node{A, B}
+	network.AddEdge(A, B)
+	if _, err := network.ExactPosterior(
+		[]*Node{B}, map[*Node]int{A: 1}); err != ErrImpossibleEvidence {
+		t.Fail()
+	}
+}
+
+func TestEvidenceProbability(t *testing.T) {
+	network := initStudentNetwork()
+	I, D := network.Nodes[0], network.Nodes[3]
+	p, err := network.EvidenceProbability(map[*Node]int{I: 1, D: 1})
+	if err != nil || math.Abs(p-.3*.4) > 1e-12 {
+		t.Fail()
+	}
+	p, _ = network.EvidenceProbability(map[*Node]int{})
+	if math.Abs(p-1) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestMostProbableExplanation(t *testing.T) {
+	network := initStudentNetwork()
+	L, G := network.Nodes[2], network.Nodes[4]
+
+	sizes := make([]int, len(network.Nodes))
+	for i, n := range network.Nodes {
+		sizes[i] = n.States
+	}
+	states := make([][]int, 0)
+	network.Nodes[0].getAllParentStates(&states, []int{}, sizes...)
+
+	for _, evidence := range []map[*Node]int{{}, {L: 0}, {G: 2, L: 1}} {
+		assignment, p, err := network.MostProbableExplanation(evidence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the most likely joint State consistent with the evidence
+		best := 0.0
+		for _, joint := range states {
+			instance := make(map[*Node]int)
+			consistent := true
+			for i, n := range network.Nodes {
+				instance[n] = joint[i]
+				if s, observed := evidence[n]; observed && s != joint[i] {
+					consistent = false
+				}
+			}
+			if consistent {
+				best = math.Max(best, network.Likelihood([]map[*Node]int{instance})[0])
+			}
+		}
+		if math.Abs(p-best) > 1e-12 {
+			t.Fatal(p, best)
+		}
+		if math.Abs(network.Likelihood([]map[*Node]int{assignment})[0]-p) > 1e-12 {
+			t.Fail()
+		}
+	}
+
+	if _, _, err := network.MostProbableExplanation(map[*Node]int{G: 7}); err == nil {
+		t.Fail()
+	}
+}
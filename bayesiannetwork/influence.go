@@ -0,0 +1,295 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// An InfluenceDiagram extends a bayesian net of chance Nodes with decision
+// Nodes, whose States are the options of a decision maker, and utility
+// Nodes.  Chance Nodes may have decisions as Parents.  The Parents of a
+// decision are its informational Parents: the Nodes whose States are known
+// when it is made.
+type InfluenceDiagram struct {
+	Chance *BayesianNetwork
+	// in the order that the decisions are made
+	Decisions []*Node
+	Utilities []*UtilityNode
+}
+
+// A UtilityNode is a utility that depends on the States of its Parents,
+// which may be chance or decision Nodes
+type UtilityNode struct {
+	Name    string
+	Parents []*Node
+	// a utility per joint State of the Parents, the first Parent's State
+	// varying fastest
+	Values []float64
+}
+
+// A Policy is a rule for making a decision given the States of its
+// informational Parents
+type Policy struct {
+	Decision *Node
+	// the chosen State of the Decision for each joint State of its Parents,
+	// the first Parent's State varying fastest
+	Choices []int
+	// the expected utility of each State of the Decision for each joint
+	// State of its Parents; 0 when the joint State is impossible
+	Utilities [][]float64
+}
+
+// the solution of an InfluenceDiagram: the optimal Policy for each decision
+// and the expected utility of following them
+type Solution struct {
+	Policies        map[*Node]*Policy
+	ExpectedUtility float64
+}
+
+// initialize an influence diagram over a net of chance Nodes
+func NewInfluenceDiagram(chance *BayesianNetwork) *InfluenceDiagram {
+	return &InfluenceDiagram{Chance: chance}
+}
+
+// add a decision, made after the decisions already added, with the Nodes
+// observed before it is made
+func (id *InfluenceDiagram) AddDecision(d *Node, informational ...*Node) {
+	for _, p := range informational {
+		id.Chance.AddEdge(p, d)
+	}
+	id.Decisions = append(id.Decisions, d)
+}
+
+// add a utility over the Parents with a value per joint State of the
+// Parents, the first Parent's State varying fastest
+func (id *InfluenceDiagram) AddUtility(
+	name string,
+	values []float64,
+	parents ...*Node) *UtilityNode {
+
+	u := &UtilityNode{Name: name, Parents: parents, Values: values}
+	id.Utilities = append(id.Utilities, u)
+	return u
+}
+
+// the utility as a Factor
+func (u *UtilityNode) factor() *Factor {
+	return &Factor{Nodes: u.Parents, Values: u.Values}
+}
+
+// the chosen State of the Decision given the States of its Parents in
+// instance
+func (p *Policy) Choose(instance map[*Node]int) int {
+	return p.Choices[tableIndex(p.Decision, p.Decision.parentStates(instance))]
+}
+
+// the Policy as a CPD of the Decision
+func (p *Policy) cpd() CPD {
+	return &Deterministic{Function: func(parentStates []int) int {
+		return p.Choices[tableIndex(p.Decision, parentStates)]
+	}}
+}
+
+// check that the diagram is acyclic and complete and that no CPD of a
+// chance Node is stale
+func (id *InfluenceDiagram) check() error {
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, id.Chance.Nodes...)
+	net.Nodes = append(net.Nodes, id.Decisions...)
+	if HasCycles(net) {
+		return errors.New("bayesiannetwork: influence diagram has cycles")
+	}
+	for _, n := range id.Chance.Nodes {
+		if n.CPD == nil && len(n.cpd) == 0 {
+			return fmt.Errorf("bayesiannetwork: chance node %q has no CPD", n.Name)
+		}
+		if n.stale {
+			return staleError(n)
+		}
+	}
+	for _, d := range id.Decisions {
+		if d.States == 0 {
+			return fmt.Errorf("bayesiannetwork: decision %q has no states", d.Name)
+		}
+	}
+	for _, u := range id.Utilities {
+		if size := len(NewFactor(u.Parents...).Values); size != len(u.Values) {
+			return fmt.Errorf("bayesiannetwork: utility %q has %d values, expected %d",
+				u.Name, len(u.Values), size)
+		}
+	}
+	return nil
+}
+
+// the Factors of the chance Nodes and the decisions, following the
+// policies of the decisions that have one and choosing uniformly at random
+// otherwise
+func (id *InfluenceDiagram) factors(policies map[*Node]*Policy) []*Factor {
+	factors := make([]*Factor, 0)
+	for _, n := range id.Chance.Nodes {
+		factors = append(factors, n.factors()...)
+	}
+	for _, d := range id.Decisions {
+		if p, exists := policies[d]; exists {
+			factors = append(factors, TableFactor(d, p.cpd()))
+			continue
+		}
+		f := NewFactor(append([]*Node{d}, d.Parents...)...)
+		for i := range f.Values {
+			f.Values[i] = 1 / float64(d.States)
+		}
+		factors = append(factors, f)
+	}
+	return factors
+}
+
+// the expected utility of following the policies, which must include one
+// for every decision
+func (id *InfluenceDiagram) ExpectedUtility(policies map[*Node]*Policy) (float64, error) {
+	if err := id.check(); err != nil {
+		return 0, err
+	}
+	for _, d := range id.Decisions {
+		if _, exists := policies[d]; !exists {
+			return 0, fmt.Errorf("bayesiannetwork: decision %q has no policy", d.Name)
+		}
+	}
+	factors := id.factors(policies)
+	eu := 0.0
+	for _, u := range id.Utilities {
+		eu += eliminate(append(factors, u.factor()), map[*Node]bool{}, false).Values[0]
+	}
+	return eu, nil
+}
+
+// find the policies that maximize the expected utility by backward
+// induction: from the last decision to the first, choose the State with the
+// highest expected utility for each joint State of the informational
+// Parents, given that later decisions follow their optimal policies.  The
+// policies are optimal when the diagram has perfect recall, i.e. every
+// decision's informational Parents include the earlier decisions and their
+// informational Parents.  Otherwise earlier decisions are treated as random
+// when choosing later ones.
+func (id *InfluenceDiagram) Solve() (*Solution, error) {
+	if err := id.check(); err != nil {
+		return nil, err
+	}
+	policies := make(map[*Node]*Policy)
+	for k := len(id.Decisions) - 1; k >= 0; k-- {
+		d := id.Decisions[k]
+		factors := id.factors(policies)
+		keep := map[*Node]bool{d: true}
+		for _, p := range d.Parents {
+			keep[p] = true
+		}
+
+		// P(d, parents) and the sum over utilities of E[u, d, parents]
+		joint := eliminate(factors, keep, false)
+		utilities := make([]*Factor, len(id.Utilities))
+		for i, u := range id.Utilities {
+			utilities[i] = eliminate(append(factors, u.factor()), keep, false)
+		}
+
+		configurations := NewFactor(d.Parents...)
+		policy := &Policy{
+			Decision:  d,
+			Choices:   make([]int, len(configurations.Values)),
+			Utilities: make([][]float64, len(configurations.Values))}
+		for index := range configurations.Values {
+			states := make(map[*Node]int)
+			for i, s := range configurations.states(index) {
+				states[d.Parents[i]] = s
+			}
+			policy.Utilities[index] = make([]float64, d.States)
+			best := math.Inf(-1)
+			for s := 0; s < d.States; s++ {
+				states[d] = s
+				p := joint.Value(states)
+				if p <= 0 {
+					continue
+				}
+				eu := 0.0
+				for _, f := range utilities {
+					eu += f.Value(states)
+				}
+				policy.Utilities[index][s] = eu / p
+				if eu/p > best {
+					best = eu / p
+					policy.Choices[index] = s
+				}
+			}
+		}
+		policies[d] = policy
+	}
+
+	eu, err := id.ExpectedUtility(policies)
+	if err != nil {
+		return nil, err
+	}
+	return &Solution{Policies: policies, ExpectedUtility: eu}, nil
+}
+
+// the expected value of perfect information: how much the optimal expected
+// utility increases when the observed chance Nodes are known before the
+// decision (and so before every later decision)
+func (id *InfluenceDiagram) ValueOfInformation(decision *Node, observed ...*Node) (float64, error) {
+	without, err := id.Solve()
+	if err != nil {
+		return 0, err
+	}
+
+	// add the observed Nodes as informational Parents, then restore the
+	// original topology
+	later := false
+	added := make(map[*Node][]*Node)
+	for _, d := range id.Decisions {
+		later = later || d == decision
+		if !later {
+			continue
+		}
+		for _, n := range observed {
+			if !containsNode(d.Parents, n) {
+				id.Chance.AddEdge(n, d)
+				added[d] = append(added[d], n)
+			}
+		}
+	}
+	if !later {
+		return 0, fmt.Errorf("bayesiannetwork: %q is not a decision", decision.Name)
+	}
+	defer func() {
+		for d, nodes := range added {
+			for _, n := range nodes {
+				d.Parents = removeNode(d.Parents, n)
+				n.Children = removeNode(n.Children, d)
+			}
+		}
+	}()
+
+	with, err := id.Solve()
+	if err != nil {
+		return 0, err
+	}
+	return with.ExpectedUtility - without.ExpectedUtility, nil
+}
+
+// whether n is in nodes
+func containsNode(nodes []*Node, n *Node) bool {
+	for _, m := range nodes {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// the Nodes without the last occurrence of n
+func removeNode(nodes []*Node, n *Node) []*Node {
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i] == n {
+			return append(nodes[:i:i], nodes[i+1:]...)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,116 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// the oil wildcatter (Raiffa, 1968): decide whether to pay 10 for a seismic
+// test, then whether to drill given its result
+func initWildcatter() (*InfluenceDiagram, *Node, *Node, *Node, *Node) {
+	oil := &Node{Name: "oil", States: 3, cpd: []Density{NewDensity(.5, .3, .2)}}
+	test := &Node{Name: "test", States: 2}
+	drill := &Node{Name: "drill", States: 2}
+	// closed, open, diffuse or no result; the parents are oil then test
+	result := &Node{Name: "result", States: 4, cpd: []Density{
+		NewDensity(0, 0, 0, 1), NewDensity(0, 0, 0, 1), NewDensity(0, 0, 0, 1),
+		NewDensity(.1, .3, .6, 0), NewDensity(.3, .4, .3, 0), NewDensity(.5, .4, .1, 0)}}
+
+	chance := NewBayesianNetwork()
+	chance.Nodes = append(chance.Nodes, oil, result)
+	id := NewInfluenceDiagram(chance)
+	id.AddDecision(test)
+	id.AddDecision(drill, test, result)
+	chance.AddEdge(oil, result)
+	chance.AddEdge(test, result)
+
+	id.AddUtility("cost", []float64{0, -10}, test)
+	id.AddUtility("payoff", []float64{0, -70, 0, 50, 0, 200}, drill, oil)
+	return id, oil, test, drill, result
+}
+
+func TestInfluenceDiagramSolve(t *testing.T) {
+	id, _, test, drill, result := initWildcatter()
+	solution, err := id.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(solution.ExpectedUtility-22.5) > 1e-9 {
+		t.Fatal(solution.ExpectedUtility)
+	}
+	if solution.Policies[test].Choose(map[*Node]int{}) != 1 {
+		t.Fatal("expected to test")
+	}
+	// drill unless the result is diffuse
+	policy := solution.Policies[drill]
+	for r, expected := range []int{1, 1, 0} {
+		if policy.Choose(map[*Node]int{test: 1, result: r}) != expected {
+			t.Fatal(r, policy.Utilities)
+		}
+	}
+	// drill without a test: .5 * -70 + .3 * 50 + .2 * 200 > 0
+	if policy.Choose(map[*Node]int{test: 0, result: 3}) != 1 {
+		t.Fail()
+	}
+	// after a closed result, including the cost of the test
+	index := tableIndex(drill, []int{1, 0})
+	if math.Abs(policy.Utilities[index][1]-(87.5-10)) > 1e-9 {
+		t.Fatal(policy.Utilities[index])
+	}
+
+	// never testing is worse
+	never := &Policy{Decision: test, Choices: []int{0}}
+	eu, err := id.ExpectedUtility(map[*Node]*Policy{test: never, drill: policy})
+	if err != nil || math.Abs(eu-20) > 1e-9 {
+		t.Fatal(eu, err)
+	}
+	if _, err := id.ExpectedUtility(map[*Node]*Policy{test: never}); err == nil {
+		t.Fail()
+	}
+}
+
+func TestInfluenceDiagramValueOfInformation(t *testing.T) {
+	id, oil, test, drill, _ := initWildcatter()
+	// knowing the oil, only drill when there is some: .3 * 50 + .2 * 200
+	evpi, err := id.ValueOfInformation(test, oil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(evpi-(55-22.5)) > 1e-9 {
+		t.Fatal(evpi)
+	}
+	// the topology is restored
+	if len(test.Parents) != 0 || len(drill.Parents) != 2 || len(oil.Children) != 1 {
+		t.Fatal(test.Parents, drill.Parents, oil.Children)
+	}
+
+	// only decisions can be made with more information
+	if _, err := id.ValueOfInformation(oil, test); err == nil {
+		t.Fatal("expected an error for a chance node")
+	}
+}
+
+func TestInfluenceDiagramErrors(t *testing.T) {
+	id, _, _, drill, _ := initWildcatter()
+	id.AddUtility("bad", []float64{1}, drill)
+	if _, err := id.Solve(); err == nil {
+		t.Fail()
+	}
+
+	id, oil, _, drill, _ := initWildcatter()
+	id.Chance.AddEdge(drill, oil)
+	if _, err := id.Solve(); err == nil {
+		t.Fatal("expected an error for a cycle")
+	}
+
+	// oil has a single row but now depends on the test
+	id, oil, test, _, _ := initWildcatter()
+	id.Chance.AddEdge(test, oil)
+	if _, err := id.Solve(); err == nil || !strings.Contains(err.Error(), `"oil"`) {
+		t.Fatal(err)
+	}
+	if _, err := id.ExpectedUtility(map[*Node]*Policy{}); err == nil {
+		t.Fatal("expected an error for a stale CPD")
+	}
+}
@@ -0,0 +1,146 @@
+package bayesiannetwork
+
+import "math"
+
+// a Dataset of the observations of the Nodes, with a column for a stand-in
+// for each Node that has a State for every observed State, so that the
+// States of the Nodes themselves don't matter and are left unchanged
+func standInDataset(data []map[*Node]int, nodes ...*Node) (*Dataset, []*Node) {
+	standIns := make(map[*Node]*Node, len(nodes))
+	columns := make([]*Node, 0, len(nodes))
+	for _, n := range nodes {
+		if standIns[n] == nil {
+			standIns[n] = &Node{Name: n.Name, States: n.States}
+			columns = append(columns, n)
+		}
+	}
+	for _, instance := range data {
+		for _, n := range columns {
+			if s, observed := instance[n]; observed && s >= standIns[n].States {
+				standIns[n].States = s + 1
+			}
+		}
+	}
+	replacements := make([]*Node, len(columns))
+	for i, n := range columns {
+		replacements[i] = standIns[n]
+	}
+	ds := NewDataset(replacements...)
+	ds.columns = datasetOf(columns, data).columns
+	replaced := make([]*Node, len(nodes))
+	for i, n := range nodes {
+		replaced[i] = standIns[n]
+	}
+	return ds, replaced
+}
+
+// the empirical mutual information (in nats) between the States of a and b
+// over the instances where both are observed; see Dataset.MutualInformation
+func MutualInformation(data []map[*Node]int, a, b *Node) float64 {
+	ds, nodes := standInDataset(data, a, b)
+	return ds.MutualInformation(nodes[0], nodes[1])
+}
+
+// the empirical mutual information (in nats) between the States of a and b
+// given the States of c over the instances where all are observed; see
+// Dataset.ConditionalMutualInformation
+func ConditionalMutualInformation(data []map[*Node]int, a, b, c *Node) float64 {
+	ds, nodes := standInDataset(data, a, b, c)
+	return ds.ConditionalMutualInformation(nodes[0], nodes[1], nodes[2])
+}
+
+// the empirical mutual information (in nats) between the States of two
+// different Nodes a and b, over the rows where neither is Missing
+func (ds *Dataset) MutualInformation(a, b *Node) float64 {
+	joint := ds.Counts(a, b)
+	pa := joint.SumOut(b)
+	pb := joint.SumOut(a)
+
+	total := 0.0
+	for _, count := range pa.Values {
+		total += count
+	}
+	mi := 0.0
+	for index, count := range joint.Values {
+		if count > 0 {
+			sa, sb := index%a.States, index/a.States
+			mi += count / total * math.Log(count*total/(pa.Values[sa]*pb.Values[sb]))
+		}
+	}
+	return mi
+}
+
+// the empirical mutual information (in nats) between the States of a and b
+// given the States of c, over the rows where none of them is Missing.  a and
+// b must be different; it is 0 when c is one of them.
+func (ds *Dataset) ConditionalMutualInformation(a, b, c *Node) float64 {
+	if c == a || c == b {
+		return 0
+	}
+	joint := ds.Counts(a, b, c)
+	pac := joint.SumOut(b)
+	pbc := joint.SumOut(a)
+	pc := pac.SumOut(a)
+
+	total := 0.0
+	for _, count := range pc.Values {
+		total += count
+	}
+	cmi := 0.0
+	for index, count := range joint.Values {
+		if count > 0 {
+			sa := index % a.States
+			sb := index / a.States % b.States
+			sc := index / (a.States * b.States)
+			ac := pac.Values[sa+sc*a.States]
+			bc := pbc.Values[sb+sc*b.States]
+			cmi += count / total * math.Log(count*pc.Values[sc]/(ac*bc))
+		}
+	}
+	return cmi
+}
+
+// Prim's algorithm for the maximum weight spanning tree over nodes.  The
+// edges are directed away from root.
+func maximumSpanningTree(
+	nodes []*Node,
+	root *Node,
+	weight func(a, b *Node) float64) []Edge {
+
+	inTree := map[*Node]bool{root: true}
+	// the best known edge into each Node not yet in the tree
+	best := make(map[*Node]float64)
+	from := make(map[*Node]*Node)
+	for _, n := range nodes {
+		if n != root {
+			best[n] = weight(root, n)
+			from[n] = root
+		}
+	}
+
+	edges := make([]Edge, 0)
+	for len(inTree) < len(nodes) {
+		var next *Node
+		for _, n := range nodes {
+			if inTree[n] {
+				continue
+			}
+			if next == nil || best[n] > best[next] {
+				next = n
+			}
+		}
+		inTree[next] = true
+		edges = append(edges, Edge{Parent: from[next], Child: next})
+
+		for _, n := range nodes {
+			if inTree[n] {
+				continue
+			}
+			if w := weight(next, n); w > best[n] {
+				best[n] = w
+				from[n] = next
+			}
+		}
+	}
+	return edges
+}
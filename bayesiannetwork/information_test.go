@@ -0,0 +1,57 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMutualInformation(t *testing.T) {
+	A := &Node{Name: "A"}
+	B := &Node{Name: "B"}
+	C := &Node{Name: "C"}
+	data := []map[*Node]int{
+		{A: 0, B: 0, C: 0},
+		{A: 0, B: 1, C: 0},
+		{A: 1, B: 0, C: 1},
+		{A: 1, B: 1, C: 1}}
+
+	// A and B are independent
+	if math.Abs(MutualInformation(data, A, B)) > 1e-12 {
+		t.Fail()
+	}
+	// A and C are identical, so share the entropy of A
+	if math.Abs(MutualInformation(data, A, C)-math.Log(2)) > 1e-12 {
+		t.Fail()
+	}
+	// knowing A leaves nothing to share between A and C
+	if math.Abs(ConditionalMutualInformation(data, A, C, A)) > 1e-12 {
+		t.Fail()
+	}
+	if math.Abs(ConditionalMutualInformation(data, A, B, C)) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestMaximumSpanningTree(t *testing.T) {
+	nodes := []*Node{{Name: "0"}, {Name: "1"}, {Name: "2"}, {Name: "3"}}
+	index := map[*Node]int{nodes[0]: 0, nodes[1]: 1, nodes[2]: 2, nodes[3]: 3}
+
+	// the heaviest edges form the chain 0 - 2 - 1 - 3
+	weights := [][]float64{
+		{0, 1, 5, 1},
+		{1, 0, 4, 3},
+		{5, 4, 0, 2},
+		{1, 3, 2, 0}}
+	weight := func(a, b *Node) float64 { return weights[index[a]][index[b]] }
+
+	edges := maximumSpanningTree(nodes, nodes[0], weight)
+	solution := [][2]int{{0, 2}, {2, 1}, {1, 3}}
+	if len(edges) != len(solution) {
+		t.Fatal(edges)
+	}
+	for i, e := range edges {
+		if index[e.Parent] != solution[i][0] || index[e.Child] != solution[i][1] {
+			t.Fail()
+		}
+	}
+}
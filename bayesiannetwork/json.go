@@ -0,0 +1,255 @@
+package bayesiannetwork
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// the version of the JSON encoding written by MarshalJSON.  Files with a
+// later version are rejected.
+const JSONVersion = 1
+
+// the kinds of CPD in the JSON encoding
+const (
+	jsonTable    = "table"
+	jsonNoisyMax = "noisy-max"
+	jsonTree     = "tree"
+)
+
+type jsonNetwork struct {
+	Version int         `json:"version"`
+	Nodes   []*jsonNode `json:"nodes"`
+}
+
+type jsonNode struct {
+	Name        string    `json:"name"`
+	States      int       `json:"states"`
+	StateLabels []string  `json:"stateLabels,omitempty"`
+	Position    *Position `json:"position,omitempty"`
+	// in the order that indexes the CPD
+	Parents []string `json:"parents,omitempty"`
+	// omitted for a Node that hasn't been fitted
+	CPD *jsonCPD `json:"cpd,omitempty"`
+}
+
+type jsonCPD struct {
+	Type string `json:"type"`
+	// a distribution per joint State of the Parents, the first Parent's
+	// State varying fastest
+	Table [][]float64 `json:"table,omitempty"`
+	// the parameters of a NoisyMax
+	Links [][][]float64 `json:"links,omitempty"`
+	Leak  []float64     `json:"leak,omitempty"`
+	Tree  *jsonTreeNode `json:"tree,omitempty"`
+}
+
+type jsonTreeNode struct {
+	Split    string          `json:"split,omitempty"`
+	Branches []*jsonTreeNode `json:"branches,omitempty"`
+	Probs    []float64       `json:"probs,omitempty"`
+}
+
+func encodeTree(t *TreeNode) *jsonTreeNode {
+	if t == nil {
+		return nil
+	}
+	if t.Split == nil {
+		return &jsonTreeNode{Probs: t.Probs}
+	}
+	encoded := &jsonTreeNode{Split: t.Split.Name}
+	for _, b := range t.Branches {
+		encoded.Branches = append(encoded.Branches, encodeTree(b))
+	}
+	return encoded
+}
+
+// encode the CPD of a Node, or nil if it hasn't been fitted
+func encodeCPD(n *Node) (*jsonCPD, error) {
+	densities := n.cpd
+	switch cpd := n.CPD.(type) {
+	case nil:
+	case *TableCPD:
+		densities = cpd.Densities
+	case *NoisyMax:
+		return &jsonCPD{Type: jsonNoisyMax, Links: cpd.Links, Leak: cpd.Leak}, nil
+	case *TreeCPD:
+		return &jsonCPD{Type: jsonTree, Tree: encodeTree(cpd.Root)}, nil
+	default:
+		return nil, fmt.Errorf("bayesiannetwork: node %q has a %T CPD, which can't be encoded",
+			n.Name, n.CPD)
+	}
+	if len(densities) == 0 {
+		return nil, nil
+	}
+	encoded := &jsonCPD{Type: jsonTable}
+	for _, d := range densities {
+		encoded.Table = append(encoded.Table, d.probabilities())
+	}
+	return encoded, nil
+}
+
+// encode the Nodes, their State labels, Parents and CPDs as JSON.  Tables,
+// trees and noisy-MAX CPDs can be encoded; other CPDs are an error.
+func (net BayesianNetwork) MarshalJSON() ([]byte, error) {
+	encoded := jsonNetwork{Version: JSONVersion, Nodes: make([]*jsonNode, 0, len(net.Nodes))}
+	for _, n := range net.Nodes {
+		node := &jsonNode{Name: n.Name, States: n.States, StateLabels: n.StateLabels, Position: n.Position}
+		for _, p := range n.Parents {
+			node.Parents = append(node.Parents, p.Name)
+		}
+		cpd, err := encodeCPD(n)
+		if err != nil {
+			return nil, err
+		}
+		node.CPD = cpd
+		encoded.Nodes = append(encoded.Nodes, node)
+	}
+	return json.Marshal(encoded)
+}
+
+func decodeTree(n *Node, t *jsonTreeNode) (*TreeNode, error) {
+	if t == nil {
+		return nil, fmt.Errorf("bayesiannetwork: node %q: tree cpd has an empty branch", n.Name)
+	}
+	if t.Split == "" {
+		return &TreeNode{Probs: t.Probs}, nil
+	}
+	decoded := &TreeNode{}
+	for _, p := range n.Parents {
+		if p.Name == t.Split {
+			decoded.Split = p
+		}
+	}
+	if decoded.Split == nil {
+		return nil, fmt.Errorf("bayesiannetwork: node %q: tree cpd splits on %q, which isn't a parent",
+			n.Name, t.Split)
+	}
+	for _, b := range t.Branches {
+		branch, err := decodeTree(n, b)
+		if err != nil {
+			return nil, err
+		}
+		decoded.Branches = append(decoded.Branches, branch)
+	}
+	return decoded, nil
+}
+
+// set the CPD of a Node from its encoding
+func decodeCPD(n *Node, encoded *jsonCPD) error {
+	switch encoded.Type {
+	case jsonTable:
+		n.cpd = make([]Density, len(encoded.Table))
+		for i, probs := range encoded.Table {
+			if err := validateDistribution(probs, n.States); err != nil {
+				return fmt.Errorf("bayesiannetwork: node %q: cpd row %d %v", n.Name, i, err)
+			}
+			n.cpd[i] = NewDensity(probs...)
+		}
+	case jsonNoisyMax:
+		n.CPD = &NoisyMax{Links: encoded.Links, Leak: encoded.Leak}
+	case jsonTree:
+		root, err := decodeTree(n, encoded.Tree)
+		if err != nil {
+			return err
+		}
+		n.CPD = &TreeCPD{Root: root}
+	default:
+		return fmt.Errorf("bayesiannetwork: node %q has an unknown cpd type %q", n.Name, encoded.Type)
+	}
+	return nil
+}
+
+// decode a network encoded by MarshalJSON, replacing the Nodes of the net.
+// The Nodes are put in topological order, as the file may list a child
+// before its Parents.  The network is checked by Validate, so malformed
+// files are reported with the Node at fault.
+func (net *BayesianNetwork) UnmarshalJSON(data []byte) error {
+	var encoded jsonNetwork
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return fmt.Errorf("bayesiannetwork: malformed json: %v", err)
+	}
+	if encoded.Version < 1 {
+		return fmt.Errorf("bayesiannetwork: json has no version")
+	}
+	if encoded.Version > JSONVersion {
+		return fmt.Errorf("bayesiannetwork: json version %d is newer than the supported version %d",
+			encoded.Version, JSONVersion)
+	}
+
+	decoded := NewBayesianNetwork()
+	byName := make(map[string]*Node, len(encoded.Nodes))
+	for _, node := range encoded.Nodes {
+		if node == nil {
+			return fmt.Errorf("bayesiannetwork: json has a null node")
+		}
+		n := &Node{Name: node.Name, States: node.States, StateLabels: node.StateLabels, Position: node.Position}
+		if _, exists := byName[n.Name]; exists {
+			return fmt.Errorf("bayesiannetwork: duplicate node name %q", n.Name)
+		}
+		byName[n.Name] = n
+		decoded.Nodes = append(decoded.Nodes, n)
+	}
+	for i, node := range encoded.Nodes {
+		n := decoded.Nodes[i]
+		for _, name := range node.Parents {
+			p, exists := byName[name]
+			if !exists {
+				return fmt.Errorf("bayesiannetwork: node %q has unknown parent %q", n.Name, name)
+			}
+			decoded.AddEdge(p, n)
+		}
+	}
+	for i, node := range encoded.Nodes {
+		if node.CPD != nil {
+			if err := decodeCPD(decoded.Nodes[i], node.CPD); err != nil {
+				return err
+			}
+		}
+	}
+	decoded.stableTopologicalSort()
+	if err := decoded.Validate(); err != nil {
+		return err
+	}
+	net.Nodes = decoded.Nodes
+	return nil
+}
+
+// write the network as JSON
+func (net BayesianNetwork) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(net, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// save the network to a JSON file
+func (net BayesianNetwork) SaveJSON(path string) error {
+	return saveFile(path, net.WriteJSON)
+}
+
+// read a network written by WriteJSON
+func ReadJSON(r io.Reader) (*BayesianNetwork, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	net := NewBayesianNetwork()
+	if err := net.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return net, nil
+}
+
+// load a network from a JSON file
+func LoadJSON(path string) (*BayesianNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadJSON(f)
+}
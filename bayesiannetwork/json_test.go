@@ -0,0 +1,145 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	network := initStudentNetwork()
+	network.Nodes[0].StateLabels = []string{"low", "high"}
+	network.Nodes[0].Position = &Position{X: 10, Y: -20}
+	// a noisy-OR and a tree alongside the tables
+	a := &Node{Name: "a", States: 2, cpd: []Density{NewDensity(.4, .6)}}
+	b := &Node{Name: "b", States: 2, CPD: NewLeakyNoisyOR(.1, .8, .5)}
+	c := &Node{Name: "c", States: 2}
+	network.Nodes = append(network.Nodes, a, b, c)
+	network.AddEdge(a, b)
+	network.AddEdge(network.Nodes[0], b)
+	network.AddEdge(a, c)
+	c.CPD = &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{
+		{Probs: []float64{.3, .7}}, {Probs: []float64{.9, .1}}}}}
+
+	var buf bytes.Buffer
+	if err := network.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Nodes) != len(network.Nodes) {
+		t.Fatal(len(loaded.Nodes))
+	}
+	if loaded.Nodes[0].StateLabel(1) != "high" || *loaded.Nodes[0].Position != (Position{10, -20}) {
+		t.Fail()
+	}
+
+	// every joint State has the same probability
+	sizes := make([]int, len(network.Nodes))
+	for i, n := range network.Nodes {
+		sizes[i] = n.States
+	}
+	states := make([][]int, 0)
+	network.Nodes[0].getAllParentStates(&states, []int{}, sizes...)
+	for _, joint := range states {
+		original := make(map[*Node]int)
+		copied := make(map[*Node]int)
+		for i := range network.Nodes {
+			original[network.Nodes[i]] = joint[i]
+			// the loaded Nodes are in topological order
+			copied[loaded.Node(network.Nodes[i].Name)] = joint[i]
+		}
+		p := network.Likelihood([]map[*Node]int{original})[0]
+		q := loaded.Likelihood([]map[*Node]int{copied})[0]
+		if math.Abs(p-q) > 1e-15 {
+			t.Fatal(joint, p, q)
+		}
+	}
+
+	// saving and loading a file
+	path := filepath.Join(t.TempDir(), "network.json")
+	if err := loaded.SaveJSON(path); err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadJSON(path)
+	if err != nil || len(again.Nodes) != len(network.Nodes) {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// user-defined CPDs can't be encoded
+	c.CPD = &copyCPD{Fidelity: .5}
+	if _, err := json.Marshal(network); err == nil {
+		t.Fail()
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	cases := map[string]string{
+		"malformed": `{"version": 1, "nodes": [`,
+		"version":   `{"nodes": []}`,
+		"newer":     `{"version": 99, "nodes": []}`,
+		"parent":    `{"version": 1, "nodes": [{"name": "a", "states": 2, "parents": ["b"]}]}`,
+		"duplicate": `{"version": 1, "nodes": [{"name": "a", "states": 2}, {"name": "a", "states": 2}]}`,
+		"rows": `{"version": 1, "nodes": [{"name": "a", "states": 2},
+			{"name": "b", "states": 2, "parents": ["a"],
+			 "cpd": {"type": "table", "table": [[0.5, 0.5]]}}]}`,
+		"sum": `{"version": 1, "nodes": [{"name": "a", "states": 2,
+			"cpd": {"type": "table", "table": [[0.5, 0.6]]}}]}`,
+		"type": `{"version": 1, "nodes": [{"name": "a", "states": 2,
+			"cpd": {"type": "magic"}}]}`,
+		"cycle": `{"version": 1, "nodes": [{"name": "a", "states": 2, "parents": ["b"]},
+			{"name": "b", "states": 2, "parents": ["a"]}]}`,
+		"labels": `{"version": 1, "nodes": [{"name": "a", "states": 2, "stateLabels": ["x"]}]}`,
+		"split": `{"version": 1, "nodes": [{"name": "a", "states": 2,
+			"cpd": {"type": "tree", "tree": {"split": "b"}}}]}`,
+	}
+	for name, data := range cases {
+		_, err := ReadJSON(strings.NewReader(data))
+		if err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+
+	// the error names the node and the sizes
+	_, err := ReadJSON(strings.NewReader(cases["rows"]))
+	if !strings.Contains(err.Error(), `"b"`) || !strings.Contains(err.Error(), "expected 2") {
+		t.Fatal(err)
+	}
+}
+
+func TestJSONChildFirst(t *testing.T) {
+	data := `{"version": 1, "nodes": [
+		{"name": "wet", "states": 2, "parents": ["rain"],
+		 "cpd": {"type": "table", "table": [[0.9, 0.1], [0.2, 0.8]]}},
+		{"name": "rain", "states": 2, "cpd": {"type": "table", "table": [[0.3, 0.7]]}}]}`
+	net, err := ReadJSON(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net.Nodes[0].Name != "rain" || net.Nodes[1].Name != "wet" {
+		t.Fatal(net.Nodes)
+	}
+	// sampling visits rain before wet
+	r := rand.New(rand.NewSource(1))
+	rainy := 0
+	for i := 0; i < 1000; i++ {
+		sample := net.Sample(r)
+		if len(sample) != 2 {
+			t.Fatal(sample)
+		}
+		rainy += 1 - sample[net.Nodes[0]]
+	}
+	if rainy < 250 || rainy > 350 {
+		t.Fatal(rainy)
+	}
+}
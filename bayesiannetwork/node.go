@@ -3,6 +3,7 @@ package bayesiannetwork
 import (
 	"math/rand"
 	"sort"
+	"strconv"
 )
 
 // Hold the PMF in sorted order by probabilty and the original indices of the
@@ -48,11 +49,26 @@ func (d Density) sample(r *rand.Rand) int {
 // Basic Node in a bayesian network.  Allows only discrete distributions. All
 // cpds must have the same size.
 type Node struct {
-	Name     string
-	States   int
-	Children []*Node
-	Parents  []*Node
-	cpd      []Density
+	Name   string
+	States int
+	// optional names of the States, e.g. "low", "mid" and "high"
+	StateLabels []string
+	Children    []*Node
+	Parents     []*Node
+	cpd         []Density
+	// the distribution used instead of cpd when set, e.g. a NoisyMax for a
+	// Node with many Parents
+	CPD CPD
+	// where the Node is drawn by graphical editors, or nil
+	Position *Position
+	// whether the Parents changed after the CPD was set
+	stale bool
+}
+
+// a point in the drawing of a network
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 func (n Node) getCPDIndex(parent_States map[*Node]int) int {
@@ -91,3 +107,50 @@ func (n Node) getAllParentStates(states *[][]int, current []int, parentStateNums
 		}
 	}
 }
+
+// the States of the Parents in instance, in order
+func (n *Node) parentStates(instance map[*Node]int) []int {
+	states := make([]int, len(n.Parents))
+	for i, parent := range n.Parents {
+		states[i] = instance[parent]
+	}
+	return states
+}
+
+// whether the Node and all of its Parents are observed in instance
+func (n *Node) observed(instance map[*Node]int) bool {
+	if _, exists := instance[n]; !exists {
+		return false
+	}
+	for _, parent := range n.Parents {
+		if _, exists := instance[parent]; !exists {
+			return false
+		}
+	}
+	return true
+}
+
+// draw the Node's State given the States of its Parents in instance
+func (n *Node) sample(r *rand.Rand, instance map[*Node]int) int {
+	return n.distribution().Sample(r, n, n.parentStates(instance))
+}
+
+// the probability of the Node's State in instance given the States of its
+// Parents in instance
+func (n *Node) conditionalProbability(instance map[*Node]int) float64 {
+	return n.distribution().Probability(n, n.parentStates(instance), instance[n])
+}
+
+// whether the Node has a CPD
+func (n *Node) fitted() bool {
+	return n.CPD != nil || len(n.cpd) > 0
+}
+
+// the label of a State of the Node, or the State's number when it has no
+// labels
+func (n *Node) StateLabel(state int) string {
+	if state >= 0 && state < len(n.StateLabels) {
+		return n.StateLabels[state]
+	}
+	return strconv.Itoa(state)
+}
@@ -0,0 +1,339 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// the most EM iterations used to learn a NoisyMax, and the relative change
+// of the log likelihood below which it stops early
+const (
+	noisyMaxIterations = 200
+	noisyMaxTolerance  = 1e-8
+)
+
+// A NoisyMax is a compact CPD for a Node with many Parents that act as
+// independent causes.  State 0 of the Node and of each Parent means absent
+// and higher States are more severe.  Each Parent in State s independently
+// produces a State of the Node drawn from Links[i][s], a leak produces one
+// drawn from Leak, and the Node takes the most severe State produced.  The
+// parameters grow linearly with the number of Parents instead of
+// exponentially.  With two States per Node it is a noisy-OR.
+type NoisyMax struct {
+	// Links[i][s] is the distribution over the Node's States produced by
+	// Parent i in State s.  Links[i][0] must be certain of State 0.
+	Links [][][]float64
+	// the distribution produced by causes outside of the network, or nil for
+	// a model without a leak
+	Leak []float64
+}
+
+// a noisy-OR for binary Nodes, where probs[i] is the probability that Parent
+// i being present (State 1) makes the Node present
+func NewNoisyOR(probs ...float64) *NoisyMax {
+	m := &NoisyMax{Links: make([][][]float64, len(probs))}
+	for i, p := range probs {
+		m.Links[i] = [][]float64{{1, 0}, {1 - p, p}}
+	}
+	return m
+}
+
+// a noisy-OR with a leak: the probability that the Node is present when
+// none of its Parents are
+func NewLeakyNoisyOR(leak float64, probs ...float64) *NoisyMax {
+	m := NewNoisyOR(probs...)
+	m.Leak = []float64{1 - leak, leak}
+	return m
+}
+
+// check that the parameters fit the Node and its Parents
+func (m *NoisyMax) Validate(n *Node) error {
+	checkDistribution := func(probs []float64, what string) error {
+		if len(probs) != n.States {
+			return fmt.Errorf("bayesiannetwork: node %q: %s has %d states, expected %d",
+				n.Name, what, len(probs), n.States)
+		}
+		total := 0.0
+		for _, p := range probs {
+			if p < 0 {
+				return fmt.Errorf("bayesiannetwork: node %q: %s has a negative probability",
+					n.Name, what)
+			}
+			total += p
+		}
+		if math.Abs(total-1) > 1e-6 {
+			return fmt.Errorf("bayesiannetwork: node %q: %s sums to %g",
+				n.Name, what, total)
+		}
+		return nil
+	}
+
+	if len(m.Links) != len(n.Parents) {
+		return fmt.Errorf("bayesiannetwork: node %q has %d parents but %d noisy-max links",
+			n.Name, len(n.Parents), len(m.Links))
+	}
+	for i, p := range n.Parents {
+		if len(m.Links[i]) != p.States {
+			return fmt.Errorf("bayesiannetwork: node %q: link from %q has %d states, expected %d",
+				n.Name, p.Name, len(m.Links[i]), p.States)
+		}
+		for s, probs := range m.Links[i] {
+			what := fmt.Sprintf("link from %q in state %d", p.Name, s)
+			if err := checkDistribution(probs, what); err != nil {
+				return err
+			}
+		}
+		if m.Links[i][0][0] != 1 {
+			return fmt.Errorf("bayesiannetwork: node %q: state 0 of %q must not cause anything",
+				n.Name, p.Name)
+		}
+	}
+	if m.Leak != nil {
+		return checkDistribution(m.Leak, "leak")
+	}
+	return nil
+}
+
+// the cumulative probability of a distribution up to and including a State
+func cumulative(probs []float64, state int) float64 {
+	total := 0.0
+	for s := 0; s <= state && s < len(probs); s++ {
+		total += probs[s]
+	}
+	return total
+}
+
+// the probability that the Node's State is at most y given the States of
+// its Parents
+func (m *NoisyMax) cdf(parentStates []int, y int) float64 {
+	if y < 0 {
+		return 0
+	}
+	p := 1.0
+	if m.Leak != nil {
+		p = cumulative(m.Leak, y)
+	}
+	for i, s := range parentStates {
+		if s > 0 {
+			p *= cumulative(m.Links[i][s], y)
+		}
+	}
+	return p
+}
+
+// the probability of State y given the States of the Parents
+func (m *NoisyMax) Probability(n *Node, parentStates []int, y int) float64 {
+	return m.cdf(parentStates, y) - m.cdf(parentStates, y-1)
+}
+
+// draw a State from a distribution
+func sampleProbs(r *rand.Rand, probs []float64) int {
+	u := r.Float64()
+	for s, p := range probs {
+		u -= p
+		if u < 0 {
+			return s
+		}
+	}
+	return len(probs) - 1
+}
+
+// draw the Node's State by drawing the effect of every cause
+func (m *NoisyMax) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	state := 0
+	if m.Leak != nil {
+		state = sampleProbs(r, m.Leak)
+	}
+	for i, s := range parentStates {
+		if s > 0 {
+			if effect := sampleProbs(r, m.Links[i][s]); effect > state {
+				state = effect
+			}
+		}
+	}
+	return state
+}
+
+// the CPD of n as Factors for variable elimination, using the factorization
+// of Díez and Galán (2003): with an auxiliary Node y' with the States of n,
+// P(n = y | parents) = sum over y' of delta(y, y') * prod_i F_i(y' | parent i)
+// where F_i is the cumulative distribution produced by parent i and delta
+// is 1 when y = y', -1 when y = y' + 1 and 0 otherwise.  Each Factor involves
+// at most two Nodes, so n can have many Parents.  The delta Factor is
+// negative in places, so the Factors are only valid for summing out.
+func (m *NoisyMax) Factors(n *Node) []*Factor {
+	aux := &Node{Name: n.Name + "'", States: n.States}
+
+	delta := NewFactor(n, aux)
+	for y := 0; y < n.States; y++ {
+		delta.Values[delta.Index(map[*Node]int{n: y, aux: y})] = 1
+		if y > 0 {
+			delta.Values[delta.Index(map[*Node]int{n: y, aux: y - 1})] = -1
+		}
+	}
+	factors := []*Factor{delta}
+
+	if m.Leak != nil {
+		leak := NewFactor(aux)
+		for y := range leak.Values {
+			leak.Values[y] = cumulative(m.Leak, y)
+		}
+		factors = append(factors, leak)
+	}
+	for i, p := range n.Parents {
+		link := NewFactor(aux, p)
+		for index := range link.Values {
+			states := link.states(index)
+			if states[1] == 0 {
+				link.Values[index] = 1
+			} else {
+				link.Values[index] = cumulative(m.Links[i][states[1]], states[0])
+			}
+		}
+		factors = append(factors, link)
+	}
+	return factors
+}
+
+// learn the parameters from the rows of the Dataset where n and its Parents
+// are observed, by expectation maximization over which cause produced each
+// State.  alpha pseudo-observations of every State are added to each
+// distribution.  The current parameters are the starting point when they fit
+// n.
+func (m *NoisyMax) Fit(n *Node, ds *Dataset, alpha float64) {
+	if m.Validate(n) != nil {
+		// start from causes that are as likely to do nothing as anything
+		m.Links = make([][][]float64, len(n.Parents))
+		for i, p := range n.Parents {
+			m.Links[i] = make([][]float64, p.States)
+			m.Links[i][0] = make([]float64, n.States)
+			m.Links[i][0][0] = 1
+			for s := 1; s < p.States; s++ {
+				m.Links[i][s] = uniform(n.States)
+			}
+		}
+		if m.Leak != nil && n.States > 1 {
+			m.Leak = make([]float64, n.States)
+			for y := range m.Leak {
+				m.Leak[y] = 0.1 / float64(n.States-1)
+			}
+			m.Leak[0] = 0.9
+		} else if m.Leak != nil {
+			m.Leak = []float64{1}
+		}
+	}
+
+	// the rows with everything observed
+	states := make([][]int, 0)
+	for r := 0; r < ds.Len(); r++ {
+		row := make([]int, len(n.Parents)+1)
+		row[0] = ds.Value(r, n)
+		observed := row[0] != Missing
+		for i, p := range n.Parents {
+			row[i+1] = ds.Value(r, p)
+			observed = observed && row[i+1] != Missing
+		}
+		if observed {
+			states = append(states, row)
+		}
+	}
+
+	previous := math.Inf(-1)
+	for iteration := 0; iteration < noisyMaxIterations; iteration++ {
+		// expected number of times each cause produced each State
+		linkCounts := make([][][]float64, len(n.Parents))
+		for i, p := range n.Parents {
+			linkCounts[i] = make([][]float64, p.States)
+			for s := range linkCounts[i] {
+				linkCounts[i][s] = make([]float64, n.States)
+			}
+		}
+		leakCounts := make([]float64, n.States)
+
+		ll := 0.0
+		for _, row := range states {
+			y, parentStates := row[0], row[1:]
+
+			// the active causes and what they produce
+			causes := make([][]float64, 0, len(parentStates)+1)
+			counts := make([][]float64, 0, len(parentStates)+1)
+			if m.Leak != nil {
+				causes = append(causes, m.Leak)
+				counts = append(counts, leakCounts)
+			}
+			for i, s := range parentStates {
+				if s > 0 {
+					causes = append(causes, m.Links[i][s])
+					counts = append(counts, linkCounts[i][s])
+				}
+			}
+
+			total := m.Probability(n, parentStates, y)
+			if total <= 0 {
+				continue
+			}
+			ll += math.Log(total)
+
+			for c, probs := range causes {
+				// the cumulative probabilities of the other causes at y and
+				// y - 1
+				at, below := 1.0, 1.0
+				if y == 0 {
+					below = 0
+				}
+				for d, other := range causes {
+					if d != c {
+						at *= cumulative(other, y)
+						below *= cumulative(other, y-1)
+					}
+				}
+				for k := 0; k < y; k++ {
+					counts[c][k] += probs[k] * (at - below) / total
+				}
+				counts[c][y] += probs[y] * at / total
+			}
+		}
+
+		// maximize
+		for i := range m.Links {
+			for s := 1; s < len(m.Links[i]); s++ {
+				m.Links[i][s] = normalizeCounts(linkCounts[i][s], alpha, m.Links[i][s])
+			}
+		}
+		if m.Leak != nil {
+			m.Leak = normalizeCounts(leakCounts, alpha, m.Leak)
+		}
+
+		if ll-previous <= noisyMaxTolerance*math.Abs(ll) {
+			break
+		}
+		previous = ll
+	}
+}
+
+// a uniform distribution over n States
+func uniform(n int) []float64 {
+	probs := make([]float64, n)
+	for i := range probs {
+		probs[i] = 1 / float64(n)
+	}
+	return probs
+}
+
+// the distribution from counts with alpha pseudo-observations of every State,
+// or the previous distribution without any observations
+func normalizeCounts(counts []float64, alpha float64, previous []float64) []float64 {
+	total := alpha * float64(len(counts))
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return previous
+	}
+	probs := make([]float64, len(counts))
+	for i, c := range counts {
+		probs[i] = (c + alpha) / total
+	}
+	return probs
+}
@@ -0,0 +1,190 @@
+package bayesiannetwork
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// causes -> effect where effect is a leaky noisy-OR of the causes
+func initNoisyORNetwork(priors, probs []float64, leak float64) (*BayesianNetwork, []*Node, *Node) {
+	net := NewBayesianNetwork()
+	effect := &Node{Name: "effect", States: 2, CPD: NewLeakyNoisyOR(leak, probs...)}
+	causes := make([]*Node, len(priors))
+	for i, p := range priors {
+		causes[i] = &Node{Name: "cause", States: 2, cpd: []Density{NewDensity(1-p, p)}}
+		net.Nodes = append(net.Nodes, causes[i])
+		net.AddEdge(causes[i], effect)
+	}
+	net.Nodes = append(net.Nodes, effect)
+	return net, causes, effect
+}
+
+func TestNoisyORProbability(t *testing.T) {
+	m := NewLeakyNoisyOR(.1, .8, .5, .3)
+	// absent only if the leak and every present cause fail
+	p := m.Probability(nil, []int{1, 0, 1}, 0)
+	if math.Abs(p-.9*.2*.7) > 1e-12 {
+		t.Fail()
+	}
+	if math.Abs(m.Probability(nil, []int{1, 0, 1}, 1)-(1-.9*.2*.7)) > 1e-12 {
+		t.Fail()
+	}
+	if math.Abs(m.Probability(nil, []int{0, 0, 0}, 1)-.1) > 1e-12 {
+		t.Fail()
+	}
+	// without a leak nothing happens without a cause
+	if NewNoisyOR(.8).Probability(nil, []int{0}, 1) != 0 {
+		t.Fail()
+	}
+}
+
+func TestNoisyMaxValidate(t *testing.T) {
+	net, causes, effect := initNoisyORNetwork([]float64{.5, .5}, []float64{.9, .4}, .05)
+	if err := effect.CPD.(*NoisyMax).Validate(effect); err != nil {
+		t.Fatal(err)
+	}
+	net.AddEdge(&Node{Name: "extra", States: 2}, effect)
+	if effect.CPD.(*NoisyMax).Validate(effect) == nil {
+		t.Fail()
+	}
+	bad := NewNoisyOR(.9, .4)
+	bad.Links[0][0] = []float64{.5, .5}
+	effect.Parents = causes
+	if bad.Validate(effect) == nil {
+		t.Fail()
+	}
+}
+
+func TestNoisyMaxInference(t *testing.T) {
+	net, causes, effect := initNoisyORNetwork(
+		[]float64{.2, .5, .1}, []float64{.9, .4, .7}, .05)
+
+	for _, evidence := range []map[*Node]int{
+		{effect: 1},
+		{effect: 0},
+		{effect: 1, causes[0]: 0},
+	} {
+		posterior, err := net.ExactPosterior(causes, evidence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range causes {
+			expected := enumeratePosterior(net, c, evidence)
+			for s, p := range expected {
+				if math.Abs(posterior[c].StateMap[s]-p) > 1e-9 {
+					t.Fatal(c.Name, posterior[c].StateMap, expected)
+				}
+			}
+		}
+	}
+
+	// a full table would have 2^31 entries
+	priors := make([]float64, 30)
+	probs := make([]float64, 30)
+	absent := .95
+	for i := range priors {
+		priors[i] = .1
+		probs[i] = .5
+		absent *= 1 - priors[i]*probs[i]
+	}
+	net, causes, effect = initNoisyORNetwork(priors, probs, .05)
+	p, err := net.EvidenceProbability(map[*Node]int{effect: 0})
+	if err != nil || math.Abs(p-absent) > 1e-9 {
+		t.Fatal(p, absent)
+	}
+	posterior, err := net.ExactPosterior([]*Node{causes[0]}, map[*Node]int{effect: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// P(cause | effect) = P(cause) P(effect | cause) / P(effect)
+	given := absent / (1 - priors[0]*probs[0]) * (1 - probs[0])
+	expected := priors[0] * (1 - given) / (1 - absent)
+	if math.Abs(posterior[causes[0]].StateMap[1]-expected) > 1e-9 {
+		t.Fatal(posterior[causes[0]].StateMap[1], expected)
+	}
+}
+
+func TestNoisyMaxSample(t *testing.T) {
+	links := [][][]float64{
+		{{1, 0, 0}, {.2, .5, .3}},
+		{{1, 0, 0}, {.6, .4, 0}, {.1, .1, .8}}}
+	m := &NoisyMax{Links: links, Leak: []float64{.9, .08, .02}}
+	a := &Node{Name: "a", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	b := &Node{Name: "b", States: 3, cpd: []Density{NewDensity(.3, .3, .4)}}
+	c := &Node{Name: "c", States: 3, CPD: m}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, a, b, c)
+	net.AddEdge(a, c)
+	net.AddEdge(b, c)
+	if err := m.Validate(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, parents := range [][]int{{0, 0}, {1, 1}, {1, 2}} {
+		total := 0.0
+		for y := 0; y < 3; y++ {
+			total += m.Probability(nil, parents, y)
+		}
+		if math.Abs(total-1) > 1e-12 {
+			t.Fail()
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	counts := make([]float64, 3)
+	n := 20000
+	for i := 0; i < n; i++ {
+		counts[net.Sample(r)[c]]++
+	}
+	marginal, _ := net.ExactPosterior([]*Node{c}, map[*Node]int{})
+	for y := range counts {
+		if math.Abs(counts[y]/float64(n)-marginal[c].StateMap[y]) > .02 {
+			t.Fatal(counts, marginal[c].StateMap)
+		}
+	}
+}
+
+func TestNoisyMaxLearning(t *testing.T) {
+	priors := []float64{.3, .4, .2, .5}
+	probs := []float64{.9, .6, .3, .7}
+	truth, _, _ := initNoisyORNetwork(priors, probs, .1)
+	r := rand.New(rand.NewSource(1))
+	samples := make([]map[*Node]int, 0)
+	for i := 0; i < 5000; i++ {
+		samples = append(samples, truth.Sample(r))
+	}
+
+	// learn a fresh noisy-OR over the same Nodes
+	net, causes, effect := initNoisyORNetwork(priors, []float64{.5, .5, .5, .5}, .5)
+	data := make([]map[*Node]int, len(samples))
+	for i, s := range samples {
+		data[i] = map[*Node]int{effect: s[truth.Nodes[4]]}
+		for j, c := range causes {
+			data[i][c] = s[truth.Nodes[j]]
+		}
+	}
+	net.updateWeights(data)
+
+	m := effect.CPD.(*NoisyMax)
+	if err := m.Validate(effect); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range probs {
+		if math.Abs(m.Links[i][1][1]-p) > .05 {
+			t.Fatal(i, m.Links[i][1], p)
+		}
+	}
+	if math.Abs(m.Leak[1]-.1) > .03 {
+		t.Fatal(m.Leak)
+	}
+
+	// structure learning replaces noisy CPDs with tables
+	if _, err := InferBayesianNetworkContext(context.Background(), data, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if effect.CPD != nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,65 @@
+package bayesiannetwork
+
+import (
+	"geneticalgorithm"
+	"time"
+)
+
+// InferenceEvent is sent to an Observer after every iteration of a structure
+// search
+type InferenceEvent struct {
+	Iteration int
+	// score of the best topology found so far
+	BestScore float64
+	// number of proposed topologies that replaced the best so far
+	Accepted int
+	Elapsed  time.Duration
+	// the edges of the best topology found so far
+	Edges []Edge
+}
+
+// Observer receives progress from a structure search.  Returning false from
+// Observe stops the search early.
+type Observer interface {
+	Observe(event InferenceEvent) bool
+}
+
+// adapt an ordinary function to the Observer interface
+type ObserverFunc func(event InferenceEvent) bool
+
+func (f ObserverFunc) Observe(event InferenceEvent) bool {
+	return f(event)
+}
+
+// convert the genome of the search into the edges between the Nodes
+func bitsToEdges(nodeOrder []*Node, bits []int) []Edge {
+	edges := make([]Edge, 0)
+	nNodes := len(nodeOrder)
+	for i, b := range bits {
+		if b == 1 {
+			edges = append(edges, Edge{
+				Parent: nodeOrder[i/nNodes],
+				Child:  nodeOrder[i%nNodes]})
+		}
+	}
+	return edges
+}
+
+// wrap an Observer so that it can watch the genetic algorithm; returns nil
+// for a nil Observer so that no events are built
+func progressObserver(
+	observer Observer,
+	nodeOrder []*Node) func(geneticalgorithm.Progress) bool {
+
+	if observer == nil {
+		return nil
+	}
+	return func(p geneticalgorithm.Progress) bool {
+		return observer.Observe(InferenceEvent{
+			Iteration: p.Iteration,
+			BestScore: p.BestScore,
+			Accepted:  p.Accepted,
+			Elapsed:   p.Elapsed,
+			Edges:     bitsToEdges(nodeOrder, p.Best)})
+	}
+}
@@ -0,0 +1,89 @@
+package bayesiannetwork
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func studentSamples(n int) []map[*Node]int {
+	network := initStudentNetwork()
+	network.topologicalSort()
+
+	source := rand.NewSource(1)
+	r := rand.New(source)
+	samples := make([]map[*Node]int, 0)
+	for i := 0; i < n; i++ {
+		samples = append(samples, network.Sample(r))
+	}
+	return samples
+}
+
+func TestInferBayesianNetworkObserver(t *testing.T) {
+	samples := studentSamples(200)
+
+	events := make([]InferenceEvent, 0)
+	observer := ObserverFunc(func(e InferenceEvent) bool {
+		events = append(events, e)
+		return true
+	})
+	inferred, err := InferBayesianNetworkContext(
+		context.Background(), samples, 20, observer)
+	if err != nil || HasCycles(inferred) {
+		t.Fail()
+	}
+	if len(events) != 20 {
+		t.Fatal("expected an event per iteration", len(events))
+	}
+	for i := 1; i < len(events); i++ {
+		// the score of the best topology never gets worse
+		if events[i].BestScore < events[i-1].BestScore {
+			t.Fail()
+		}
+		if events[i].Accepted < events[i-1].Accepted {
+			t.Fail()
+		}
+	}
+	// the last event describes the returned topology
+	last := events[len(events)-1]
+	if len(last.Edges) != len(inferred.Edges()) {
+		t.Fail()
+	}
+}
+
+func TestInferBayesianNetworkStop(t *testing.T) {
+	samples := studentSamples(200)
+
+	count := 0
+	observer := ObserverFunc(func(e InferenceEvent) bool {
+		count++
+		return e.Iteration < 4
+	})
+	inferred, err := InferBayesianNetworkContext(
+		context.Background(), samples, 100, observer)
+	if err != nil || inferred == nil {
+		t.Fail()
+	}
+	if count != 5 {
+		t.Fail()
+	}
+}
+
+func TestInferBayesianNetworkCancel(t *testing.T) {
+	samples := studentSamples(200)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	observer := ObserverFunc(func(e InferenceEvent) bool {
+		if e.Iteration == 2 {
+			cancel()
+		}
+		return true
+	})
+	inferred, err := InferBayesianNetworkContext(ctx, samples, 100, observer)
+	if err != context.Canceled {
+		t.Fail()
+	}
+	if inferred == nil || HasCycles(inferred) {
+		t.Fail()
+	}
+}
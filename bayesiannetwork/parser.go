@@ -0,0 +1,197 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the lexical syntax of a text file format for networks
+type syntax struct {
+	// the name of the format in errors
+	format string
+	// characters that are tokens by themselves
+	punctuation string
+	// the start of a comment to the end of the line
+	lineComment string
+	// whether /* */ comments are allowed
+	blockComment bool
+}
+
+type token struct {
+	text string
+	line int
+}
+
+func (s syntax) isSeparator(c byte) bool {
+	return strings.IndexByte(s.punctuation+" \t\r\n\"", c) >= 0
+}
+
+// whether text can be written as a single word
+func (s syntax) isWord(text string) bool {
+	if text == "" || strings.HasPrefix(text, s.lineComment) || strings.HasPrefix(text, "/*") {
+		return false
+	}
+	for i := 0; i < len(text); i++ {
+		if s.isSeparator(text[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// split a file into words, punctuation and quoted strings (with their
+// quotes), dropping comments
+func tokenize(data string, s syntax) ([]token, error) {
+	tokens := make([]token, 0)
+	line := 1
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(data[i:], s.lineComment):
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case s.blockComment && strings.HasPrefix(data[i:], "/*"):
+			end := strings.Index(data[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("bayesiannetwork: %s line %d: unterminated comment", s.format, line)
+			}
+			line += strings.Count(data[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end := i + 1
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(data) {
+				return nil, fmt.Errorf("bayesiannetwork: %s line %d: unterminated string", s.format, line)
+			}
+			tokens = append(tokens, token{data[i : end+1], line})
+			line += strings.Count(data[i:end+1], "\n")
+			i = end + 1
+		case strings.IndexByte(s.punctuation, c) >= 0:
+			tokens = append(tokens, token{string(c), line})
+			i++
+		default:
+			start := i
+			for i < len(data) && !s.isSeparator(data[i]) {
+				i++
+			}
+			tokens = append(tokens, token{data[start:i], line})
+		}
+	}
+	return tokens, nil
+}
+
+// a recursive descent parser over tokens
+type parser struct {
+	tokens []token
+	pos    int
+	syntax syntax
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// an error at the current token
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 1
+	if p.eof() && len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	} else if !p.eof() {
+		line = p.tokens[p.pos].line
+	}
+	return fmt.Errorf("bayesiannetwork: %s line %d: %s", p.syntax.format, line, fmt.Sprintf(format, args...))
+}
+
+// the next token, or "" at the end of the file
+func (p *parser) peek() string {
+	if p.eof() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *parser) next() (string, error) {
+	if p.eof() {
+		return "", p.errorf("unexpected end of file")
+	}
+	p.pos++
+	return p.tokens[p.pos-1].text, nil
+}
+
+func (p *parser) expect(text string) error {
+	if p.peek() != text {
+		if p.eof() {
+			return p.errorf("expected %q at the end of file", text)
+		}
+		return p.errorf("expected %q, found %q", text, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// a word or string that isn't punctuation
+func (p *parser) word() (string, error) {
+	if !p.eof() && len(p.peek()) == 1 && strings.Contains(p.syntax.punctuation, p.peek()) {
+		return "", p.errorf("expected a name, found %q", p.peek())
+	}
+	return p.next()
+}
+
+// skip to the end of a statement ending in ';'
+func (p *parser) skipStatement() error {
+	for !p.eof() {
+		if text, _ := p.next(); text == ";" {
+			return nil
+		}
+	}
+	return p.errorf("expected \";\" at the end of file")
+}
+
+// skip a block in braces
+func (p *parser) skipBlock() error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for depth := 1; depth > 0; {
+		text, err := p.next()
+		if err != nil {
+			return err
+		}
+		if text == "{" {
+			depth++
+		} else if text == "}" {
+			depth--
+		}
+	}
+	return nil
+}
+
+// words up to the closing token, separated by the separator unless it's ""
+func (p *parser) list(closing, separator string) ([]string, error) {
+	words := make([]string, 0)
+	for p.peek() != closing {
+		if len(words) > 0 && separator != "" {
+			if err := p.expect(separator); err != nil {
+				return nil, err
+			}
+		}
+		w, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w)
+	}
+	p.pos++
+	return words, nil
+}
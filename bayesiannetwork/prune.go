@@ -0,0 +1,59 @@
+package bayesiannetwork
+
+// a smaller sub-network and evidence that give the same posterior
+// distributions of the targets as the net and the evidence.  Evidence that
+// is d-separated from the targets given the rest of the evidence is
+// irrelevant and dropped, one observed Node at a time until none is; then
+// barren Nodes, which are neither targets nor observed and have no target
+// or observed descendant, are removed, as summing them out leaves the other
+// Nodes' distributions unchanged.  Removing barren Nodes doesn't change
+// which evidence is relevant.
+//
+// The sub-network keeps the order of the net and shares its Nodes, whose
+// Parents are all in the sub-network but whose children may not be, so it
+// is meant for inference rather than editing.  Evidence of zero probability
+// that is dropped isn't detected by inference on the sub-network, so
+// ExactPosterior only removes barren Nodes.
+func (net BayesianNetwork) Prune(targets []*Node, evidence map[*Node]int) (*BayesianNetwork, map[*Node]int) {
+	relevant := make(map[*Node]int, len(evidence))
+	for n, s := range evidence {
+		relevant[n] = s
+	}
+	for {
+		dropped := false
+		for _, n := range net.Nodes {
+			s, observed := relevant[n]
+			if !observed {
+				continue
+			}
+			delete(relevant, n)
+			if !dSeparated(targets, []*Node{n}, observedNodes(relevant), uncut) {
+				relevant[n] = s
+			} else {
+				dropped = true
+			}
+		}
+		if !dropped {
+			break
+		}
+	}
+
+	return net.ancestral(append(observedNodes(relevant), targets...)), relevant
+}
+
+// the sub-network of the Nodes and their ancestors, without the Nodes that
+// are barren for them
+func (net BayesianNetwork) ancestral(nodes []*Node) *BayesianNetwork {
+	pruned := NewBayesianNetwork()
+	pruned.Nodes = net.inOrder(ancestorsOf(nodes, uncut))
+	return pruned
+}
+
+// the observed Nodes of the evidence
+func observedNodes(evidence map[*Node]int) []*Node {
+	nodes := make([]*Node, 0, len(evidence))
+	for n := range evidence {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
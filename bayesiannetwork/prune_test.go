@@ -0,0 +1,78 @@
+package bayesiannetwork
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	network, cloudy, sprinkler, rain, wet := initSprinkler(t)
+	cases := []struct {
+		targets  []*Node
+		evidence map[*Node]int
+		nodes    []*Node
+		relevant []*Node
+	}{
+		// wet is barren
+		{[]*Node{rain}, map[*Node]int{}, []*Node{cloudy, rain}, []*Node{}},
+		// rain is d-separated from sprinkler given cloudy
+		{[]*Node{sprinkler}, map[*Node]int{cloudy: 0, rain: 0}, []*Node{cloudy, sprinkler}, []*Node{cloudy}},
+		// observing wet makes rain relevant
+		{[]*Node{sprinkler}, map[*Node]int{rain: 0, wet: 0},
+			[]*Node{cloudy, sprinkler, rain, wet}, []*Node{rain, wet}},
+		{[]*Node{cloudy}, map[*Node]int{sprinkler: 1, rain: 0, wet: 0},
+			[]*Node{cloudy, sprinkler, rain}, []*Node{sprinkler, rain}},
+	}
+	for i, c := range cases {
+		pruned, relevant := network.Prune(c.targets, c.evidence)
+		if !sameNodes(pruned.Nodes, c.nodes) || len(relevant) != len(c.relevant) {
+			t.Fatal(i, pruned.Nodes, relevant)
+		}
+		for _, n := range c.relevant {
+			if relevant[n] != c.evidence[n] {
+				t.Fatal(i, relevant)
+			}
+		}
+
+		// the same posteriors from the sub-network
+		expected, err := network.ExactPosterior(c.targets, c.evidence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		densities, err := pruned.ExactPosterior(c.targets, relevant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, n := range c.targets {
+			compareDensities(t, expected[n], densities[n], n.States)
+		}
+	}
+	if len(network.Nodes) != 4 || len(wet.Parents) != 2 || len(cloudy.Children) != 2 {
+		t.Fatal("pruning changed the network")
+	}
+}
+
+func TestPrunedPosteriorDistribution(t *testing.T) {
+	network, cloudy, sprinkler, rain, _ := initSprinkler(t)
+	// a long chain of barren Nodes below rain
+	previous := rain
+	for i := 0; i < 50; i++ {
+		n := &Node{Name: "barren" + strconv.Itoa(i), States: 2}
+		network.Nodes = append(network.Nodes, n)
+		network.AddEdge(previous, n)
+		n.cpd = []Density{NewDensity(.7, .3), NewDensity(.2, .8)}
+		previous = n
+	}
+	evidence := map[*Node]int{cloudy: 0, rain: 0}
+	pruned, _ := network.Prune([]*Node{sprinkler}, evidence)
+	if len(pruned.Nodes) != 2 {
+		t.Fatal(pruned.Nodes)
+	}
+	densities := network.PosteriorDistribution(rand.New(rand.NewSource(1)), []*Node{sprinkler}, evidence)
+	// P(sprinkler = on | cloudy = yes) = .1
+	if p := densities[sprinkler].StateMap[0]; math.Abs(p-.1) > .02 {
+		t.Fatal(p)
+	}
+}
@@ -0,0 +1,152 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+)
+
+// A Query of a network by the names of its Nodes and the labels of their
+// States (or their numbers for Nodes without labels), e.g. from a config
+// file or a request to a service:
+//
+//	{"targets": ["species"], "evidence": {"petal_width": "0"}}
+type Query struct {
+	// the Nodes whose posterior distributions are wanted; every Node that
+	// isn't observed or intervened on when empty
+	Targets []string `json:"targets,omitempty"`
+	// the observed States
+	Evidence map[string]string `json:"evidence,omitempty"`
+	// the States the Nodes are forced to, as by Do, before the evidence is
+	// observed
+	Interventions map[string]string `json:"interventions,omitempty"`
+	// also find the most probable explanation of the evidence
+	MostProbable bool `json:"mostProbable,omitempty"`
+}
+
+// The answer to a Query
+type QueryResult struct {
+	// the probability of each State of each target, by name and label
+	Posteriors map[string]map[string]float64 `json:"posteriors"`
+	// the probability of the evidence (after the interventions)
+	EvidenceProbability float64 `json:"evidenceProbability"`
+	// the most likely State of every Node, when asked for
+	MostProbable map[string]string `json:"mostProbable,omitempty"`
+}
+
+// the Node of the net with the given name, or nil
+func (net BayesianNetwork) Node(name string) *Node {
+	for _, n := range net.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// the Nodes of the net with the given names
+func (net BayesianNetwork) NodesNamed(names ...string) ([]*Node, error) {
+	nodes := make([]*Node, len(names))
+	for i, name := range names {
+		if nodes[i] = net.Node(name); nodes[i] == nil {
+			return nil, fmt.Errorf("bayesiannetwork: unknown node %q", name)
+		}
+	}
+	return nodes, nil
+}
+
+// the evidence keyed by Node from the labels of the States keyed by the
+// names of the Nodes, e.g. {"species": "setosa"}
+func (net BayesianNetwork) NamedEvidence(labels map[string]string) (map[*Node]int, error) {
+	evidence := make(map[*Node]int, len(labels))
+	for name, label := range labels {
+		n := net.Node(name)
+		if n == nil {
+			return nil, fmt.Errorf("bayesiannetwork: unknown node %q", name)
+		}
+		s, exists := stateOf(n, label)
+		if !exists {
+			return nil, fmt.Errorf("bayesiannetwork: node %q has no state %q", name, label)
+		}
+		evidence[n] = s
+	}
+	return evidence, nil
+}
+
+// the probabilities of the States of n keyed by their labels
+func LabeledDensity(n *Node, d Density) map[string]float64 {
+	labeled := make(map[string]float64, n.States)
+	for s := 0; s < n.States; s++ {
+		labeled[n.StateLabel(s)] = d.StateMap[s]
+	}
+	return labeled
+}
+
+// answer the Query exactly, by variable elimination on the network (or on
+// the network mutilated by the interventions)
+func (net BayesianNetwork) Query(q Query) (*QueryResult, error) {
+	evidence, err := net.NamedEvidence(q.Evidence)
+	if err != nil {
+		return nil, err
+	}
+	interventions, err := net.NamedEvidence(q.Interventions)
+	if err != nil {
+		return nil, err
+	}
+	fixed, err := interventionStates(interventions, evidence)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := net.NodesNamed(q.Targets...)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		for _, n := range net.Nodes {
+			if _, exists := fixed[n]; !exists {
+				targets = append(targets, n)
+			}
+		}
+	}
+
+	// the mutilated network has copies of the Nodes with the same names
+	queried, copies := &net, map[*Node]*Node{}
+	if len(interventions) > 0 {
+		queried, copies = net.Do(interventions)
+	}
+	copyOf := func(n *Node) *Node {
+		if copied, exists := copies[n]; exists {
+			return copied
+		}
+		return n
+	}
+	copiedEvidence := make(map[*Node]int, len(fixed))
+	for n, s := range fixed {
+		copiedEvidence[copyOf(n)] = s
+	}
+	copiedTargets := make([]*Node, len(targets))
+	for i, n := range targets {
+		copiedTargets[i] = copyOf(n)
+	}
+
+	densities, err := queried.ExactPosterior(copiedTargets, copiedEvidence)
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Posteriors: make(map[string]map[string]float64, len(targets))}
+	for _, n := range copiedTargets {
+		result.Posteriors[n.Name] = LabeledDensity(n, densities[n])
+	}
+	if result.EvidenceProbability, err = queried.EvidenceProbability(copiedEvidence); err != nil {
+		return nil, err
+	}
+	if q.MostProbable {
+		assignment, _, err := queried.MostProbableExplanation(copiedEvidence)
+		if err != nil {
+			return nil, err
+		}
+		result.MostProbable = make(map[string]string, len(assignment))
+		for n, s := range assignment {
+			result.MostProbable[n.Name] = n.StateLabel(s)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package bayesiannetwork
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestNodeLookup(t *testing.T) {
+	network, _, sprinkler, rain, _ := initSprinkler(t)
+	if network.Node("rain") != rain || network.Node("snow") != nil {
+		t.Fatal(network.Node("rain"), network.Node("snow"))
+	}
+	nodes, err := network.NodesNamed("sprinkler", "rain")
+	if err != nil || nodes[0] != sprinkler || nodes[1] != rain {
+		t.Fatal(nodes, err)
+	}
+	if _, err := network.NodesNamed("rain", "snow"); err == nil {
+		t.Fatal("expected an error for an unknown node")
+	}
+	evidence, err := network.NamedEvidence(map[string]string{"sprinkler": "off", "rain": "yes"})
+	if err != nil || len(evidence) != 2 || evidence[sprinkler] != 1 || evidence[rain] != 0 {
+		t.Fatal(evidence, err)
+	}
+	if _, err := network.NamedEvidence(map[string]string{"rain": "maybe"}); err == nil {
+		t.Fatal("expected an error for an unknown state")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	network, _, _, _, _ := initSprinkler(t)
+	var q Query
+	err := json.Unmarshal([]byte(`{"targets": ["rain"], "evidence": {"wet": "yes"}, "mostProbable": true}`), &q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := network.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// P(rain = yes | wet = yes) = .4581 / .6471 for the textbook network
+	if p := result.Posteriors["rain"]["yes"]; math.Abs(p-.4581/.6471) > 1e-12 || len(result.Posteriors) != 1 {
+		t.Fatal(result.Posteriors)
+	}
+	if math.Abs(result.EvidenceProbability-.6471) > 1e-12 {
+		t.Fatal(result.EvidenceProbability)
+	}
+	expected := map[string]string{"cloudy": "yes", "sprinkler": "off", "rain": "yes", "wet": "yes"}
+	for name, label := range expected {
+		if result.MostProbable[name] != label {
+			t.Fatal(result.MostProbable)
+		}
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded QueryResult
+	if err := json.Unmarshal(encoded, &decoded); err != nil || decoded.Posteriors["rain"]["no"] != result.Posteriors["rain"]["no"] {
+		t.Fatal(string(encoded), err)
+	}
+
+	// every Node that isn't observed
+	result, err = network.Query(Query{Evidence: map[string]string{"wet": "yes"}})
+	if err != nil || len(result.Posteriors) != 3 || result.MostProbable != nil {
+		t.Fatal(result, err)
+	}
+}
+
+func TestQueryInterventions(t *testing.T) {
+	network, _, _, _, _ := initSprinkler(t)
+	result, err := network.Query(Query{
+		Targets:       []string{"wet", "cloudy"},
+		Interventions: map[string]string{"sprinkler": "on"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// P(wet = yes | do(sprinkler = on)) = .5 * .99 + .5 * .9
+	if p := result.Posteriors["wet"]["yes"]; math.Abs(p-.945) > 1e-12 {
+		t.Fatal(p)
+	}
+	if p := result.Posteriors["cloudy"]["yes"]; math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+	if result.EvidenceProbability != 1 {
+		t.Fatal(result.EvidenceProbability)
+	}
+
+	cases := map[string]Query{
+		"unknown target":   {Targets: []string{"snow"}},
+		"unknown evidence": {Evidence: map[string]string{"snow": "yes"}},
+		"unknown state":    {Interventions: map[string]string{"rain": "maybe"}},
+		"conflict": {
+			Evidence:      map[string]string{"rain": "yes"},
+			Interventions: map[string]string{"rain": "no"}},
+		"impossible": {Evidence: map[string]string{"sprinkler": "off", "rain": "no", "wet": "yes"}},
+	}
+	for name, q := range cases {
+		if _, err := network.Query(q); err == nil {
+			t.Fatal("expected an error for", name)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A TreeCPD is a decision tree over the States of the Parents with a
+// distribution at each leaf.  Joint States of the Parents that reach the same
+// leaf share a distribution, which captures context-specific independence,
+// e.g. once A is 0, B no longer matters.  It needs as many distributions as
+// leaves instead of one per joint State.
+type TreeCPD struct {
+	Root *TreeNode
+}
+
+// a node of a TreeCPD: either a test of a Parent's State or a leaf
+type TreeNode struct {
+	// the Parent tested, nil at a leaf
+	Split *Node
+	// the subtree for each State of Split
+	Branches []*TreeNode
+	// the distribution over the States of the Node at a leaf
+	Probs []float64
+}
+
+// the leaf reached by the States of the Parents.  Panics if the tree splits
+// on a Node that isn't a Parent, as Validate reports.
+func (t *TreeCPD) leaf(n *Node, parentStates []int) *TreeNode {
+	current := t.Root
+	for current.Split != nil {
+		next := current
+		for i, p := range n.Parents {
+			if p == current.Split {
+				next = current.Branches[parentStates[i]]
+				break
+			}
+		}
+		if next == current {
+			panic(fmt.Sprintf("bayesiannetwork: node %q: tree cpd splits on %q, which isn't a parent",
+				n.Name, current.Split.Name))
+		}
+		current = next
+	}
+	return current
+}
+
+// a copy of the subtree splitting on the Nodes mapped from its splits
+func (t *TreeNode) remap(mapping map[*Node]*Node) *TreeNode {
+	if t == nil {
+		return nil
+	}
+	copied := &TreeNode{Probs: t.Probs}
+	if t.Split != nil {
+		copied.Split = mapping[t.Split]
+		copied.Branches = make([]*TreeNode, len(t.Branches))
+		for i, b := range t.Branches {
+			copied.Branches[i] = b.remap(mapping)
+		}
+	}
+	return copied
+}
+
+// the CPD for a copy of a Node whose Parents are the copies of its Parents
+// given by the mapping.  A TreeCPD splits on Parents, so it is copied with
+// its splits mapped; other CPDs only depend on the order of the Parents and
+// are shared.
+func copiedCPD(cpd CPD, mapping map[*Node]*Node) CPD {
+	if tree, isTree := cpd.(*TreeCPD); isTree {
+		return &TreeCPD{Root: tree.Root.remap(mapping)}
+	}
+	return cpd
+}
+
+// the number of leaves of the tree
+func (t *TreeNode) leaves() int {
+	if t.Split == nil {
+		return 1
+	}
+	total := 0
+	for _, b := range t.Branches {
+		total += b.leaves()
+	}
+	return total
+}
+
+func (t *TreeCPD) Probability(n *Node, parentStates []int, state int) float64 {
+	return t.leaf(n, parentStates).Probs[state]
+}
+
+func (t *TreeCPD) Sample(r *rand.Rand, n *Node, parentStates []int) int {
+	return sampleProbs(r, t.leaf(n, parentStates).Probs)
+}
+
+// learn the distributions at the leaves.  Without a Root the tree is grown
+// first: starting from a single leaf, a leaf is split on the Parent that
+// most improves the BIC score until no split improves it.  Without any
+// observed rows the tree is a single uniform leaf.
+func (t *TreeCPD) Fit(n *Node, ds *Dataset, alpha float64) {
+	// the rows with the Node and every Parent observed, the Node first
+	rows := make([][]int, 0)
+	for r := 0; r < ds.Len(); r++ {
+		row := make([]int, len(n.Parents)+1)
+		row[0] = ds.Value(r, n)
+		observed := row[0] != Missing
+		for i, p := range n.Parents {
+			row[i+1] = ds.Value(r, p)
+			observed = observed && row[i+1] != Missing
+		}
+		if observed {
+			rows = append(rows, row)
+		}
+	}
+
+	if t.Root == nil {
+		if len(rows) == 0 {
+			t.Root = &TreeNode{}
+		} else {
+			t.Root = growTree(n, rows, make(map[int]bool), math.Log(float64(len(rows))))
+		}
+	}
+	fitTree(n, t.Root, rows, alpha, uniform(n.States))
+}
+
+func (t *TreeCPD) Factors(n *Node) []*Factor {
+	return []*Factor{TableFactor(n, t)}
+}
+
+// the log likelihood of the Node's States in the rows under their maximum
+// likelihood distribution
+func leafScore(n *Node, rows [][]int) float64 {
+	counts := make([]float64, n.States)
+	for _, row := range rows {
+		counts[row[0]]++
+	}
+	score := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			score += c * math.Log(c/float64(len(rows)))
+		}
+	}
+	return score
+}
+
+// the rows split by the State of Parent i
+func splitRows(n *Node, rows [][]int, i int) [][][]int {
+	branches := make([][][]int, n.Parents[i].States)
+	for _, row := range rows {
+		branches[row[i+1]] = append(branches[row[i+1]], row)
+	}
+	return branches
+}
+
+// grow a subtree over the rows, greedily splitting on the Parent not already
+// used on the path with the largest gain in BIC.  logN is the log of the
+// total number of rows, which sets the penalty per parameter.
+func growTree(n *Node, rows [][]int, used map[int]bool, logN float64) *TreeNode {
+	score := leafScore(n, rows)
+	best, bestGain := -1, 0.0
+	for i, p := range n.Parents {
+		if used[i] {
+			continue
+		}
+		split := 0.0
+		for _, branch := range splitRows(n, rows, i) {
+			split += leafScore(n, branch)
+		}
+		penalty := logN / 2 * float64((p.States-1)*(n.States-1))
+		if gain := split - score - penalty; gain > bestGain {
+			best, bestGain = i, gain
+		}
+	}
+	if best == -1 {
+		return &TreeNode{}
+	}
+
+	used[best] = true
+	node := &TreeNode{Split: n.Parents[best]}
+	for _, branch := range splitRows(n, rows, best) {
+		node.Branches = append(node.Branches, growTree(n, branch, used, logN))
+	}
+	delete(used, best)
+	return node
+}
+
+// set the distributions at the leaves of the subtree from the rows that
+// reach them.  A leaf without rows gets the distribution of its parent.
+func fitTree(n *Node, t *TreeNode, rows [][]int, alpha float64, parent []float64) {
+	counts := make([]float64, n.States)
+	for _, row := range rows {
+		counts[row[0]]++
+	}
+	probs := normalizeCounts(counts, alpha, parent)
+	if t.Split == nil {
+		t.Probs = probs
+		return
+	}
+	for i, p := range n.Parents {
+		if p == t.Split {
+			for s, branch := range splitRows(n, rows, i) {
+				fitTree(n, t.Branches[s], branch, alpha, probs)
+			}
+		}
+	}
+}
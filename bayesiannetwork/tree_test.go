@@ -0,0 +1,119 @@
+package bayesiannetwork
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// a, b, c -> y where y depends on b only when a is 1 and never on c
+func initTreeNetwork() (*BayesianNetwork, *Node, *Node, *Node, *Node) {
+	a := &Node{Name: "a", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	b := &Node{Name: "b", States: 3, cpd: []Density{NewDensity(.3, .3, .4)}}
+	c := &Node{Name: "c", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	y := &Node{Name: "y", States: 2}
+	net := NewBayesianNetwork()
+	net.Nodes = append(net.Nodes, a, b, c, y)
+	net.AddEdge(a, y)
+	net.AddEdge(b, y)
+	net.AddEdge(c, y)
+	return net, a, b, c, y
+}
+
+func TestTreeCPD(t *testing.T) {
+	net, a, b, _, y := initTreeNetwork()
+	truth := &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{
+		{Probs: []float64{.9, .1}},
+		{Split: b, Branches: []*TreeNode{
+			{Probs: []float64{.8, .2}},
+			{Probs: []float64{.5, .5}},
+			{Probs: []float64{.1, .9}}}}}}}
+	y.CPD = truth
+	if p := y.conditionalProbability(map[*Node]int{a: 1, b: 2, y: 1}); p != .9 {
+		t.Fatal(p)
+	}
+	checkPosteriors(t, net, map[*Node]int{y: 1})
+
+	r := rand.New(rand.NewSource(1))
+	samples := make([]map[*Node]int, 0)
+	for i := 0; i < 5000; i++ {
+		samples = append(samples, net.Sample(r))
+	}
+
+	// grow a tree from the samples
+	learned := &TreeCPD{}
+	y.CPD = learned
+	net.updateWeights(samples)
+	if learned.Root.Split != a || learned.Root.leaves() != 4 {
+		t.Fatal("expected the tree of the truth", learned.Root.Split, learned.Root.leaves())
+	}
+	for _, states := range [][]int{{0, 0, 0}, {0, 2, 1}, {1, 0, 1}, {1, 2, 0}} {
+		instance := map[*Node]int{y: 1}
+		for i, p := range y.Parents {
+			instance[p] = states[i]
+		}
+		expected := truth.Probability(y, states, 1)
+		if math.Abs(y.conditionalProbability(instance)-expected) > .05 {
+			t.Fatal(states, y.conditionalProbability(instance), expected)
+		}
+	}
+
+	// refitting keeps the structure
+	net.updateWeights(samples[:100])
+	if learned.Root.leaves() != 4 {
+		t.Fail()
+	}
+}
+
+func TestTreeCPDUnseenBranch(t *testing.T) {
+	_, a, _, _, y := initTreeNetwork()
+	tree := &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{{}, {}}}}
+	y.CPD = tree
+	ds := DatasetFromInstances([]map[*Node]int{{a: 0, y: 1}, {a: 0, y: 1}})
+	y.Parents = []*Node{a}
+	tree.Fit(y, ds, 0)
+	// a = 1 is never seen, so it falls back to the distribution at the root
+	if tree.Root.Branches[1].Probs[1] != 1 {
+		t.Fatal(tree.Root.Branches[1].Probs)
+	}
+}
+
+func TestTreeCPDWithoutData(t *testing.T) {
+	_, _, _, _, y := initTreeNetwork()
+	tree := &TreeCPD{}
+	tree.Fit(y, DatasetFromInstances([]map[*Node]int{}), 1)
+	if tree.Root.Split != nil || tree.Root.Probs[0] != .5 || tree.Root.Probs[1] != .5 {
+		t.Fatal(tree.Root)
+	}
+}
+
+func TestTreeCPDCopies(t *testing.T) {
+	net, a, _, _, y := initTreeNetwork()
+	y.CPD = &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{
+		{Probs: []float64{.9, .1}}, {Probs: []float64{.2, .8}}}}}
+	expected, err := net.ExactPosterior([]*Node{y}, map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a mutilated copy splits on the copy of a
+	mutilated, copies := net.Do(map[*Node]int{})
+	exact, err := mutilated.ExactPosterior([]*Node{copies[y]}, map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareDensities(t, expected[y], exact[copies[y]], y.States)
+
+	// a tree sharing the original split fails loudly instead of looping
+	copies[y].CPD = y.CPD
+	if mutilated.Validate() == nil {
+		t.Fatal("expected an error for a split on a node that isn't a parent")
+	}
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "isn't a parent") {
+			t.Fatal(r)
+		}
+	}()
+	copies[y].conditionalProbability(map[*Node]int{copies[a]: 1, copies[y]: 0})
+}
@@ -0,0 +1,428 @@
+package bayesiannetwork
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the whitespace separated fields of a UAI file
+type uaiFields struct {
+	fields []string
+	pos    int
+	// the kind of file in errors
+	kind string
+}
+
+func readUAIFields(r io.Reader, kind string) (*uaiFields, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &uaiFields{fields: strings.Fields(string(data)), kind: kind}, nil
+}
+
+// the number of fields on the first line that has any
+func firstLineFields(data string) int {
+	for _, line := range strings.Split(data, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			return len(fields)
+		}
+	}
+	return 0
+}
+
+func (u *uaiFields) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bayesiannetwork: uai %s field %d: %s", u.kind, u.pos, fmt.Sprintf(format, args...))
+}
+
+func (u *uaiFields) eof() bool {
+	return u.pos >= len(u.fields)
+}
+
+func (u *uaiFields) next(what string) (string, error) {
+	if u.eof() {
+		return "", u.errorf("expected %s at the end of file", what)
+	}
+	u.pos++
+	return u.fields[u.pos-1], nil
+}
+
+// an integer in [0, limit), or any non-negative integer when limit is 0
+func (u *uaiFields) int(what string, limit int) (int, error) {
+	field, err := u.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(field)
+	if err != nil || v < 0 || (limit > 0 && v >= limit) {
+		return 0, u.errorf("invalid %s %q", what, field)
+	}
+	return v, nil
+}
+
+func (u *uaiFields) float(what string) (float64, error) {
+	field, err := u.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, u.errorf("invalid %s %q", what, field)
+	}
+	return v, nil
+}
+
+// read a Bayesian network in the UAI inference competition format.  The
+// Nodes are named by their indices in the file ("0", "1", ...), by which
+// evidence and result files refer to them, and are in topological order.
+// The last variable of each function's scope is its child.  Markov
+// networks aren't supported.
+func ReadUAI(r io.Reader) (*BayesianNetwork, error) {
+	u, err := readUAIFields(r, "model")
+	if err != nil {
+		return nil, err
+	}
+	kind, err := u.next("the network type")
+	if err != nil {
+		return nil, err
+	}
+	if kind != "BAYES" {
+		return nil, u.errorf("%q networks aren't supported", kind)
+	}
+	size, err := u.int("number of variables", 0)
+	if err != nil {
+		return nil, err
+	}
+	net := NewBayesianNetwork()
+	for i := 0; i < size; i++ {
+		states, err := u.int("cardinality", 0)
+		if err != nil {
+			return nil, err
+		}
+		net.Nodes = append(net.Nodes, &Node{Name: strconv.Itoa(i), States: states})
+	}
+
+	functions, err := u.int("number of functions", 0)
+	if err != nil {
+		return nil, err
+	}
+	if functions != size {
+		return nil, u.errorf("%d functions for %d variables", functions, size)
+	}
+	children := make([]*Node, functions)
+	for f := range children {
+		length, err := u.int("scope size", 0)
+		if err != nil {
+			return nil, err
+		}
+		if length == 0 {
+			return nil, u.errorf("function %d has an empty scope", f)
+		}
+		scope := make([]*Node, length)
+		for i := range scope {
+			v, err := u.int("variable", size)
+			if err != nil {
+				return nil, err
+			}
+			scope[i] = net.Nodes[v]
+		}
+		child := scope[length-1]
+		if len(child.Parents) > 0 || containsNode(children, child) {
+			return nil, u.errorf("variable %s has two functions", child.Name)
+		}
+		for _, p := range scope[:length-1] {
+			if p == child || containsNode(child.Parents, p) {
+				return nil, u.errorf("function %d has variable %s twice", f, p.Name)
+			}
+			net.AddEdge(p, child)
+		}
+		children[f] = child
+	}
+
+	for _, n := range children {
+		entries, err := u.int("table size", 0)
+		if err != nil {
+			return nil, err
+		}
+		expected := n.States * len(NewFactor(n.Parents...).Values)
+		if entries != expected {
+			return nil, u.errorf("the table of variable %s has %d entries, expected %d",
+				n.Name, entries, expected)
+		}
+		values := make([]float64, entries)
+		for i := range values {
+			if values[i], err = u.float("probability"); err != nil {
+				return nil, err
+			}
+		}
+		setRows(n, rowsFromFlat(n, values))
+	}
+	if !u.eof() {
+		return nil, u.errorf("unexpected %q after the tables", u.fields[u.pos])
+	}
+	net.stableTopologicalSort()
+	if err := net.Validate(); err != nil {
+		return nil, err
+	}
+	return net, nil
+}
+
+// load a network from a UAI model file
+func LoadUAI(path string) (*BayesianNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadUAI(f)
+}
+
+// the Nodes of the net by their indices in UAI files: their names when
+// they are named "0", "1", ... as by ReadUAI, otherwise their positions
+func (net BayesianNetwork) uaiVariables() []*Node {
+	variables := make([]*Node, len(net.Nodes))
+	for _, n := range net.Nodes {
+		i, err := strconv.Atoi(n.Name)
+		if err != nil || i < 0 || i >= len(variables) || variables[i] != nil || strconv.Itoa(i) != n.Name {
+			return net.Nodes
+		}
+		variables[i] = n
+	}
+	return variables
+}
+
+// the index of each Node of the net, by which UAI files refer to them
+func (net BayesianNetwork) indices() map[*Node]int {
+	indices := make(map[*Node]int, len(net.Nodes))
+	for i, n := range net.uaiVariables() {
+		indices[n] = i
+	}
+	return indices
+}
+
+// write the network in the UAI format, with a function per Node in the
+// order of their indices.  Any CPD is written as its table.
+func (net BayesianNetwork) WriteUAI(w io.Writer) error {
+	if err := net.Validate(); err != nil {
+		return err
+	}
+	variables := net.uaiVariables()
+	indices := net.indices()
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "BAYES\n%d\n", len(variables))
+	cards := make([]string, len(variables))
+	for i, n := range variables {
+		cards[i] = strconv.Itoa(n.States)
+	}
+	fmt.Fprintf(b, "%s\n%d\n", strings.Join(cards, " "), len(variables))
+	for _, n := range variables {
+		scope := []string{strconv.Itoa(len(n.Parents) + 1)}
+		for _, p := range n.Parents {
+			scope = append(scope, strconv.Itoa(indices[p]))
+		}
+		fmt.Fprintf(b, "%s %d\n", strings.Join(scope, " "), indices[n])
+	}
+	for _, n := range variables {
+		rows, err := probabilityRows(n)
+		if err != nil {
+			return err
+		}
+		values := flatFromRows(n, rows)
+		fmt.Fprintf(b, "\n%d\n", len(values))
+		for i := 0; i < len(values); i += n.States {
+			fmt.Fprintf(b, " %s\n", joinProbabilities(values[i:i+n.States], " "))
+		}
+	}
+	return b.Flush()
+}
+
+// save the network to a UAI model file
+func (net BayesianNetwork) SaveUAI(path string) error {
+	return saveFile(path, net.WriteUAI)
+}
+
+// read the observations of a UAI evidence file, whose variables are the
+// Nodes of the net by index.  Both the single observation of UAI 2010
+// ("n v1 s1 ... vn sn") and the samples of later competitions (the number
+// of samples alone on the first line, then an observation for each) are
+// read.
+func ReadUAIEvidence(r io.Reader, net *BayesianNetwork) ([]map[*Node]int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	u := &uaiFields{fields: strings.Fields(string(data)), kind: "evidence"}
+	if u.eof() {
+		return []map[*Node]int{}, nil
+	}
+	samples := 1
+	if firstLineFields(string(data)) == 1 && len(u.fields) > 1 {
+		if samples, err = u.int("number of samples", 0); err != nil {
+			return nil, err
+		}
+	}
+
+	variables := net.uaiVariables()
+	evidence := make([]map[*Node]int, samples)
+	for i := range evidence {
+		observed, err := u.int("number of observed variables", len(net.Nodes)+1)
+		if err != nil {
+			return nil, err
+		}
+		evidence[i] = make(map[*Node]int, observed)
+		for k := 0; k < observed; k++ {
+			v, err := u.int("variable", len(net.Nodes))
+			if err != nil {
+				return nil, err
+			}
+			n := variables[v]
+			if evidence[i][n], err = u.int("state", n.States); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !u.eof() {
+		return nil, u.errorf("unexpected %q after the evidence", u.fields[u.pos])
+	}
+	return evidence, nil
+}
+
+// write the samples of evidence about the Nodes of the net in the UAI
+// evidence format of the later competitions
+func WriteUAIEvidence(w io.Writer, net *BayesianNetwork, evidence ...map[*Node]int) error {
+	indices := net.indices()
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "%d\n", len(evidence))
+	for _, sample := range evidence {
+		fields := []string{strconv.Itoa(len(sample))}
+		// in the order of the indices, so the file is deterministic
+		for _, n := range net.uaiVariables() {
+			if s, observed := sample[n]; observed {
+				fields = append(fields, strconv.Itoa(indices[n]), strconv.Itoa(s))
+			}
+		}
+		if len(fields) != 1+2*len(sample) {
+			return fmt.Errorf("bayesiannetwork: evidence about a node that isn't in the network")
+		}
+		fmt.Fprintln(b, strings.Join(fields, " "))
+	}
+	return b.Flush()
+}
+
+// the fields of the last solution of a result file, after its header; solvers
+// that improve their answer over time write a solution after each -BEGIN-
+func readUAIResult(r io.Reader, header string) (*uaiFields, error) {
+	u, err := readUAIFields(r, strings.ToLower(header))
+	if err != nil {
+		return nil, err
+	}
+	if u.eof() || u.fields[0] != header {
+		return nil, u.errorf("expected %q", header)
+	}
+	u.pos = 1
+	for i := len(u.fields) - 1; i > 0; i-- {
+		if u.fields[i] == "-BEGIN-" {
+			u.pos = i + 1
+			// the number of evidence samples solved in the block
+			if _, err := u.int("number of samples", 0); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return u, nil
+}
+
+// read the marginal distribution of every Node of the net from a UAI .MAR
+// result file
+func ReadUAIMarginals(r io.Reader, net *BayesianNetwork) (map[*Node]Density, error) {
+	u, err := readUAIResult(r, "MAR")
+	if err != nil {
+		return nil, err
+	}
+	size, err := u.int("number of variables", 0)
+	if err != nil {
+		return nil, err
+	}
+	if size != len(net.Nodes) {
+		return nil, u.errorf("%d variables, expected %d", size, len(net.Nodes))
+	}
+	marginals := make(map[*Node]Density, size)
+	for _, n := range net.uaiVariables() {
+		states, err := u.int("cardinality", 0)
+		if err != nil {
+			return nil, err
+		}
+		if states != n.States {
+			return nil, u.errorf("variable %s has %d states, expected %d", n.Name, states, n.States)
+		}
+		probs := make([]float64, states)
+		for s := range probs {
+			if probs[s], err = u.float("probability"); err != nil {
+				return nil, err
+			}
+		}
+		marginals[n] = NewDensity(probs...)
+	}
+	return marginals, nil
+}
+
+// write the marginal distributions of the Nodes of the net as a UAI .MAR
+// result file
+func WriteUAIMarginals(w io.Writer, net *BayesianNetwork, marginals map[*Node]Density) error {
+	fields := []string{strconv.Itoa(len(net.Nodes))}
+	for _, n := range net.uaiVariables() {
+		d, exists := marginals[n]
+		if !exists {
+			return fmt.Errorf("bayesiannetwork: no marginal for node %q", n.Name)
+		}
+		probs := make([]float64, n.States)
+		for s := range probs {
+			probs[s] = d.StateMap[s]
+		}
+		fields = append(fields, strconv.Itoa(n.States), joinProbabilities(probs, " "))
+	}
+	_, err := fmt.Fprintf(w, "MAR\n%s\n", strings.Join(fields, " "))
+	return err
+}
+
+// read the most probable State of every Node of the net from a UAI .MPE
+// result file
+func ReadUAIMPE(r io.Reader, net *BayesianNetwork) (map[*Node]int, error) {
+	u, err := readUAIResult(r, "MPE")
+	if err != nil {
+		return nil, err
+	}
+	size, err := u.int("number of variables", 0)
+	if err != nil {
+		return nil, err
+	}
+	if size != len(net.Nodes) {
+		return nil, u.errorf("%d variables, expected %d", size, len(net.Nodes))
+	}
+	assignment := make(map[*Node]int, size)
+	for _, n := range net.uaiVariables() {
+		if assignment[n], err = u.int("state", n.States); err != nil {
+			return nil, err
+		}
+	}
+	return assignment, nil
+}
+
+// write the States of the Nodes of the net as a UAI .MPE result file
+func WriteUAIMPE(w io.Writer, net *BayesianNetwork, assignment map[*Node]int) error {
+	fields := []string{strconv.Itoa(len(net.Nodes))}
+	for _, n := range net.uaiVariables() {
+		s, exists := assignment[n]
+		if !exists {
+			return fmt.Errorf("bayesiannetwork: no state for node %q", n.Name)
+		}
+		fields = append(fields, strconv.Itoa(s))
+	}
+	_, err := fmt.Fprintf(w, "MPE\n%s\n", strings.Join(fields, " "))
+	return err
+}
@@ -0,0 +1,260 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the example of the UAI 2008 evaluation's format description
+const testUAI = `BAYES
+3
+2 2 3
+3
+1 0
+2 0 1
+2 1 2
+
+2
+ 0.436 0.564
+
+4
+ 0.128 0.872
+ 0.920 0.080
+
+6
+ 0.210 0.333 0.457
+ 0.811 0.000 0.189
+`
+
+// reference solutions, calculated by hand
+const (
+	testMAR = `MAR
+3 2 0.436 0.564 2 0.574688 0.425312 3 0.465612512 0.191371104 0.343016384
+`
+	testMPE = `MPE
+-BEGIN-
+1
+3 1 0 0
+-BEGIN-
+1
+3 0 1 0
+`
+)
+
+func TestReadUAI(t *testing.T) {
+	network, err := ReadUAI(strings.NewReader(testUAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x0, x1, x2 := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	if x2.Name != "2" || x2.States != 3 || x2.Parents[0] != x1 || len(x0.Parents) != 0 {
+		t.Fatal(x2.Parents)
+	}
+	if p := x1.conditionalProbability(map[*Node]int{x0: 1, x1: 0}); p != .92 {
+		t.Fatal(p)
+	}
+
+	// the marginals agree with the reference
+	reference, err := ReadUAIMarginals(strings.NewReader(testMAR), network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marginals, err := network.ExactPosterior(network.Nodes, map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range network.Nodes {
+		for s := 0; s < n.States; s++ {
+			if math.Abs(marginals[n].StateMap[s]-reference[n].StateMap[s]) > 1e-12 {
+				t.Fatal(n.Name, s, marginals[n].StateMap[s])
+			}
+		}
+	}
+
+	// as does the most probable explanation, the last solution in the file
+	best, err := ReadUAIMPE(strings.NewReader(testMPE), network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpe, _, err := network.MostProbableExplanation(map[*Node]int{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range network.Nodes {
+		if mpe[n] != best[n] {
+			t.Fatal(n.Name, mpe[n], best[n])
+		}
+	}
+
+	cases := map[string]string{
+		"markov":   strings.Replace(testUAI, "BAYES", "MARKOV", 1),
+		"scope":    strings.Replace(testUAI, "2 1 2", "2 1 3", 1),
+		"twice":    strings.Replace(testUAI, "2 1 2", "2 0 1", 1),
+		"size":     strings.Replace(testUAI, "6\n", "5\n", 1),
+		"short":    testUAI[:len(testUAI)-10],
+		"extra":    testUAI + " 0.5",
+		"number":   strings.Replace(testUAI, "0.436", "x", 1),
+		"sum":      strings.Replace(testUAI, "0.436", "0.5", 1),
+		"function": strings.Replace(testUAI, "3\n1 0", "2\n1 0", 1),
+	}
+	for name, data := range cases {
+		if _, err := ReadUAI(strings.NewReader(data)); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+}
+
+// variable 0 is a child of variable 1
+const childFirstUAI = `BAYES
+2
+3 2
+2
+2 1 0
+1 1
+
+6
+ 0.2 0.3 0.5
+ 0.6 0.3 0.1
+
+2
+ 0.25 0.75
+`
+
+func TestUAIChildFirst(t *testing.T) {
+	network, err := ReadUAI(strings.NewReader(childFirstUAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x1, x0 := network.Nodes[0], network.Nodes[1]
+	if x1.Name != "1" || x0.Name != "0" || x0.Parents[0] != x1 {
+		t.Fatal(network.Nodes)
+	}
+	// P(x0 = 2) = .25 * .5 + .75 * .1
+	if p := sampledFrequency(network, x0, 2); math.Abs(p-.2) > .03 {
+		t.Fatal(p)
+	}
+
+	// evidence and result files still refer to the variables by index
+	evidence, err := ReadUAIEvidence(strings.NewReader("1 0 2"), network)
+	if err != nil || evidence[0][x0] != 2 {
+		t.Fatal(evidence, err)
+	}
+	best, err := ReadUAIMPE(strings.NewReader("MPE\n2 2 0"), network)
+	if err != nil || best[x0] != 2 || best[x1] != 0 {
+		t.Fatal(best, err)
+	}
+	var buf bytes.Buffer
+	if err := WriteUAIMPE(&buf, network, best); err != nil || buf.String() != "MPE\n2 2 0\n" {
+		t.Fatal(buf.String(), err)
+	}
+	marginals, err := ReadUAIMarginals(strings.NewReader("MAR\n2 3 .2 .3 .5 2 .25 .75"), network)
+	if err != nil || marginals[x0].StateMap[2] != .5 || marginals[x1].StateMap[0] != .25 {
+		t.Fatal(marginals, err)
+	}
+
+	// and writing the network keeps the indices
+	buf.Reset()
+	if err := network.WriteUAI(&buf); err != nil {
+		t.Fatal(err)
+	}
+	again, err := ReadUAI(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, network, again)
+}
+
+func TestWriteUAI(t *testing.T) {
+	network, err := ReadBIF(strings.NewReader(asiaBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "asia.uai")
+	if err := network.SaveUAI(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadUAI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the same but for names and labels
+	for i, n := range network.Nodes {
+		n.Name, n.StateLabels = loaded.Nodes[i].Name, nil
+	}
+	checkSameNetwork(t, network, loaded)
+}
+
+func TestUAIEvidence(t *testing.T) {
+	network, err := ReadUAI(strings.NewReader(testUAI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	x0, x2 := network.Nodes[0], network.Nodes[2]
+
+	// UAI 2010 files have a single observation
+	evidence, err := ReadUAIEvidence(strings.NewReader("2 0 1 2 2"), network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evidence) != 1 || evidence[0][x0] != 1 || evidence[0][x2] != 2 {
+		t.Fatal(evidence)
+	}
+	evidence, err = ReadUAIEvidence(strings.NewReader("2\n1 2 2\n0\n"), network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evidence) != 2 || evidence[0][x2] != 2 || len(evidence[1]) != 0 {
+		t.Fatal(evidence)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteUAIEvidence(&buf, network, evidence...); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadUAIEvidence(&buf, network)
+	if err != nil || len(read) != 2 || read[0][x2] != 2 {
+		t.Fatal(err, read)
+	}
+	if WriteUAIEvidence(&buf, network, map[*Node]int{{Name: "outside"}: 0}) == nil {
+		t.Fatal("expected an error for a node outside the network")
+	}
+	for _, data := range []string{"1 0 2", "1 3 0", "2 1 2 2", "1 0 1 5"} {
+		if _, err := ReadUAIEvidence(strings.NewReader(data), network); err == nil {
+			t.Error(data, "expected an error")
+		}
+	}
+
+	// results of inference with the evidence
+	mpe, _, err := network.MostProbableExplanation(evidence[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := WriteUAIMPE(&buf, network, mpe); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "MPE\n3 1 0 2\n" {
+		t.Fatal(buf.String())
+	}
+	marginals, err := network.ExactPosterior(network.Nodes, evidence[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := WriteUAIMarginals(&buf, network, marginals); err != nil {
+		t.Fatal(err)
+	}
+	read2, err := ReadUAIMarginals(&buf, network)
+	if err != nil || read2[x2].StateMap[2] != 1 {
+		t.Fatal(err, read2)
+	}
+	if _, err := ReadUAIMarginals(strings.NewReader("MPE\n3 0 0 0"), network); err == nil {
+		t.Fatal("expected an error for the wrong header")
+	}
+	if _, err := ReadUAIMPE(strings.NewReader("MPE\n2 0 0"), network); err == nil {
+		t.Fatal("expected an error for the wrong number of variables")
+	}
+}
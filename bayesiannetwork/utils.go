@@ -47,12 +47,14 @@ func HasCycles(net *BayesianNetwork) (hasCycles bool) {
 	return false
 }
 
-// depth first search to detect cycles
+// depth first search to detect cycles.  visited is true for the Nodes on
+// the current path and false for Nodes whose descendants have all been
+// searched, so reaching a Node twice along different paths isn't a cycle.
 func visitDFS(n *Node, visited map[*Node]bool, gone map[*Node]bool) (hasCycle bool) {
 	//	fmt.Println("visited check", n.Name, visited[n])
 	detected := false
-	if _, exists := visited[n]; exists {
-		return true
+	if onPath, exists := visited[n]; exists {
+		return onPath
 	} else {
 		visited[n] = true
 		for _, child := range n.Children {
@@ -63,6 +65,7 @@ func visitDFS(n *Node, visited map[*Node]bool, gone map[*Node]bool) (hasCycle bo
 				}
 			}
 		}
+		visited[n] = false
 	}
 	return detected
 }
@@ -98,6 +101,7 @@ func (network *BayesianNetwork) binaryToTopology(NodeOrder []*Node, bits []int)
 		n.Children = make([]*Node, 0)
 		n.Parents = make([]*Node, 0)
 		n.cpd = make([]Density, 0)
+		n.CPD = nil
 	}
 
 	// add the edges
@@ -114,7 +118,10 @@ func (network *BayesianNetwork) binaryToTopology(NodeOrder []*Node, bits []int)
 	}
 }
 
-// Function to take a matrix (discritized) and make a []map[*Node]int
+// Function to take a matrix (discritized) and make a []map[*Node]int.
+// DatasetFromMatrix takes the same arguments and makes a columnar Dataset
+// that uses far less memory, which every learner accepts; its Instances
+// method converts back.
 func ConvertDataset(data [][]int, featureNames []string) []map[*Node]int {
 	// convert data sets into []map[*bayesiannetwork.Node]int for BayesianNetwork
 	nodes := make([]*Node, 0)
@@ -175,3 +175,25 @@ func TestConvertDataset(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestHasCyclesDiamond(t *testing.T) {
+	// two paths from A to D are not a cycle
+	network := NewBayesianNetwork()
+	A := Node{Name: "A"}
+	B := Node{Name: "B"}
+	C := Node{Name: "C"}
+	D := Node{Name: "D"}
+	network.Nodes = []*Node{&A, &B, &C, &D}
+	network.AddEdge(&A, &B)
+	network.AddEdge(&A, &C)
+	network.AddEdge(&B, &D)
+	network.AddEdge(&C, &D)
+	if HasCycles(network) {
+		t.Fail()
+	}
+
+	network.AddEdge(&D, &A)
+	if !HasCycles(network) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,171 @@
+package bayesiannetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// how far the probabilities of a distribution may sum from 1
+const distributionTolerance = 1e-6
+
+// check that probs is a distribution over the given number of States
+func validateDistribution(probs []float64, states int) error {
+	if len(probs) != states {
+		return fmt.Errorf("has %d probabilities, expected %d", len(probs), states)
+	}
+	total := 0.0
+	for s, p := range probs {
+		if p < 0 || math.IsNaN(p) {
+			return fmt.Errorf("has an invalid probability %g for state %d", p, s)
+		}
+		total += p
+	}
+	if math.Abs(total-1) > distributionTolerance {
+		return fmt.Errorf("sums to %g", total)
+	}
+	return nil
+}
+
+// the probabilities of a Density in State order
+func (d Density) probabilities() []float64 {
+	probs := make([]float64, len(d.StateMap))
+	for s := range probs {
+		probs[s] = d.StateMap[s]
+	}
+	return probs
+}
+
+// check a table with a Density per joint State of the Parents of n
+func validateTable(n *Node, densities []Density) error {
+	rows := 1
+	for _, p := range n.Parents {
+		rows *= p.States
+	}
+	if len(densities) != rows {
+		return fmt.Errorf(
+			"bayesiannetwork: node %q: cpd has %d rows, expected %d for the states of its %d parents",
+			n.Name, len(densities), rows, len(n.Parents))
+	}
+	for i, d := range densities {
+		if err := validateDistribution(d.probabilities(), n.States); err != nil {
+			return fmt.Errorf("bayesiannetwork: node %q: cpd row %d %v", n.Name, i, err)
+		}
+	}
+	return nil
+}
+
+// check the subtree of a TreeCPD of n; used holds the Parents split on above
+func validateTree(n *Node, t *TreeNode, used map[*Node]bool) error {
+	if t == nil {
+		return fmt.Errorf("bayesiannetwork: node %q: tree cpd has an empty branch", n.Name)
+	}
+	if t.Split == nil {
+		if err := validateDistribution(t.Probs, n.States); err != nil {
+			return fmt.Errorf("bayesiannetwork: node %q: tree cpd leaf %v", n.Name, err)
+		}
+		return nil
+	}
+	if !containsNode(n.Parents, t.Split) || used[t.Split] {
+		return fmt.Errorf("bayesiannetwork: node %q: tree cpd splits on %q, which isn't an unused parent",
+			n.Name, t.Split.Name)
+	}
+	if len(t.Branches) != t.Split.States {
+		return fmt.Errorf("bayesiannetwork: node %q: tree cpd split on %q has %d branches, expected %d",
+			n.Name, t.Split.Name, len(t.Branches), t.Split.States)
+	}
+	used[t.Split] = true
+	defer delete(used, t.Split)
+	for _, b := range t.Branches {
+		if err := validateTree(n, b, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check that the CPD of n, if it has one, fits the States of n and its
+// Parents.  CPDs other than tables, trees and noisy-MAX aren't checked.
+func validateCPD(n *Node) error {
+	switch cpd := n.CPD.(type) {
+	case nil:
+		if len(n.cpd) > 0 {
+			return validateTable(n, n.cpd)
+		}
+	case *TableCPD:
+		return validateTable(n, cpd.Densities)
+	case *NoisyMax:
+		return cpd.Validate(n)
+	case *TreeCPD:
+		return validateTree(n, cpd.Root, make(map[*Node]bool))
+	}
+	return nil
+}
+
+func staleError(n *Node) error {
+	return fmt.Errorf("bayesiannetwork: node %q has a stale CPD; its parents have changed", n.Name)
+}
+
+// check that no CPD of the net is stale, so that inference can use them
+func (net BayesianNetwork) checkStale() error {
+	for _, n := range net.Nodes {
+		if n.stale {
+			return staleError(n)
+		}
+	}
+	return nil
+}
+
+// check that the network is well formed: Node names are unique, every
+// Node has States, labels for all or none of them and Parents in the
+// network, CPDs that fit the States of the Node and its Parents and aren't
+// stale, and no cycles.  Nodes without any CPD (not fitted yet) are
+// allowed, and CPDs other than tables, trees and noisy-MAX aren't checked.
+func (net BayesianNetwork) Validate() error {
+	names := make(map[string]bool, len(net.Nodes))
+	inNet := make(map[*Node]bool, len(net.Nodes))
+	for _, n := range net.Nodes {
+		inNet[n] = true
+	}
+	for _, n := range net.Nodes {
+		if n.Name == "" {
+			return errors.New("bayesiannetwork: node without a name")
+		}
+		if names[n.Name] {
+			return fmt.Errorf("bayesiannetwork: duplicate node name %q", n.Name)
+		}
+		names[n.Name] = true
+		if n.States <= 0 {
+			return fmt.Errorf("bayesiannetwork: node %q has %d states", n.Name, n.States)
+		}
+		if len(n.StateLabels) != 0 && len(n.StateLabels) != n.States {
+			return fmt.Errorf("bayesiannetwork: node %q has %d states but %d labels",
+				n.Name, n.States, len(n.StateLabels))
+		}
+
+		seen := make(map[*Node]bool, len(n.Parents))
+		for _, p := range n.Parents {
+			switch {
+			case p == n:
+				return fmt.Errorf("bayesiannetwork: node %q is its own parent", n.Name)
+			case !inNet[p]:
+				return fmt.Errorf("bayesiannetwork: parent %q of node %q isn't in the network",
+					p.Name, n.Name)
+			case seen[p]:
+				return fmt.Errorf("bayesiannetwork: node %q has parent %q twice", n.Name, p.Name)
+			}
+			seen[p] = true
+		}
+
+		if n.stale {
+			return staleError(n)
+		}
+		if err := validateCPD(n); err != nil {
+			return err
+		}
+	}
+	if HasCycles(&net) {
+		return errors.New("bayesiannetwork: network has cycles")
+	}
+	return nil
+}
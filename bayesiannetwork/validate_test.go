@@ -0,0 +1,75 @@
+package bayesiannetwork
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	network := initStudentNetwork()
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// a Node that hasn't been fitted
+	network.Nodes = append(network.Nodes, &Node{Name: "new", States: 3})
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	G := network.Nodes[4]
+	cpd := G.cpd
+	G.cpd = cpd[:2]
+	if network.Validate() == nil {
+		t.Fatal("expected an error for missing rows")
+	}
+	G.cpd = cpd
+	G.States = 2
+	if network.Validate() == nil {
+		t.Fatal("expected an error for the wrong number of states")
+	}
+	G.States = 3
+
+	network.Nodes[5].Name = G.Name
+	if network.Validate() == nil {
+		t.Fatal("expected an error for duplicate names")
+	}
+	network.Nodes[5].Name = "new"
+
+	outside := &Node{Name: "outside", States: 2}
+	network.AddEdge(outside, network.Nodes[5])
+	if network.Validate() == nil {
+		t.Fatal("expected an error for a parent outside the network")
+	}
+	network.Nodes = append(network.Nodes, outside)
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	network.AddEdge(network.Nodes[5], outside)
+	if network.Validate() == nil {
+		t.Fatal("expected an error for a cycle")
+	}
+}
+
+func TestValidateCPDs(t *testing.T) {
+	a := &Node{Name: "a", States: 2, cpd: []Density{NewDensity(.5, .5)}}
+	b := &Node{Name: "b", States: 2}
+	network := NewBayesianNetwork()
+	network.Nodes = append(network.Nodes, a, b)
+	network.AddEdge(a, b)
+
+	b.CPD = &TreeCPD{Root: &TreeNode{Split: a, Branches: []*TreeNode{{Probs: []float64{1, 0}}}}}
+	if network.Validate() == nil {
+		t.Fatal("expected an error for a missing branch")
+	}
+	b.CPD = &TreeCPD{Root: &TreeNode{Probs: []float64{.5, .5}}}
+	if err := network.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b.CPD = NewNoisyOR(.5, .5)
+	if network.Validate() == nil {
+		t.Fatal("expected an error for an extra link")
+	}
+	b.CPD = &TableCPD{Densities: []Density{NewDensity(.5, .5), NewDensity(.2, .7)}}
+	if network.Validate() == nil {
+		t.Fatal("expected an error for a row that doesn't sum to 1")
+	}
+}
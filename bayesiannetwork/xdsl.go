@@ -0,0 +1,315 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type xdslFile struct {
+	XMLName    xml.Name        `xml:"smile"`
+	Version    string          `xml:"version,attr"`
+	ID         string          `xml:"id,attr"`
+	Nodes      xdslNodes       `xml:"nodes"`
+	Extensions *xdslExtensions `xml:"extensions"`
+}
+
+type xdslNodes struct {
+	// of any kind, in order
+	Nodes []xdslNode `xml:",any"`
+}
+
+type xdslNode struct {
+	// the kind of node, e.g. cpt or decision
+	XMLName       xml.Name
+	ID            string      `xml:"id,attr"`
+	States        []xdslState `xml:"state"`
+	Parents       string      `xml:"parents,omitempty"`
+	Probabilities string      `xml:"probabilities,omitempty"`
+	// the State of a deterministic node for each joint State of its
+	// Parents
+	ResultingStates string `xml:"resultingstates,omitempty"`
+}
+
+type xdslState struct {
+	ID string `xml:"id,attr"`
+}
+
+type xdslExtensions struct {
+	Genie xdslGenie `xml:"genie"`
+}
+
+type xdslGenie struct {
+	Version   string          `xml:"version,attr"`
+	App       string          `xml:"app,attr"`
+	Name      string          `xml:"name,attr"`
+	Nodes     []xdslGenieNode `xml:"node"`
+	Submodels []xdslSubmodel  `xml:"submodel"`
+}
+
+type xdslSubmodel struct {
+	Nodes     []xdslGenieNode `xml:"node"`
+	Submodels []xdslSubmodel  `xml:"submodel"`
+}
+
+type xdslColor struct {
+	Color string `xml:"color,attr"`
+}
+
+type xdslFont struct {
+	Color string `xml:"color,attr"`
+	Name  string `xml:"name,attr"`
+	Size  int    `xml:"size,attr"`
+}
+
+type xdslGenieNode struct {
+	ID       string     `xml:"id,attr"`
+	Name     string     `xml:"name"`
+	Interior *xdslColor `xml:"interior"`
+	Outline  *xdslColor `xml:"outline"`
+	Font     *xdslFont  `xml:"font"`
+	// left, top, right and bottom
+	Position string `xml:"position"`
+}
+
+// the size of the nodes drawn in GeNIe
+const xdslWidth, xdslHeight = 72, 48
+
+// read files declared as Latin-1, as GeNIe writes them, as well as UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "windows-1252":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// the positions of the nodes of GeNIe's extension, including submodels
+func (s xdslSubmodel) positions(found map[string]*Position) {
+	for _, n := range s.Nodes {
+		fields := strings.Fields(n.Position)
+		if len(fields) != 4 {
+			continue
+		}
+		corners := make([]float64, 4)
+		valid := true
+		for i, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			corners[i], valid = v, valid && err == nil
+		}
+		if valid {
+			found[n.ID] = &Position{X: (corners[0] + corners[2]) / 2, Y: (corners[1] + corners[3]) / 2}
+		}
+	}
+	for _, sub := range s.Submodels {
+		sub.positions(found)
+	}
+}
+
+// read a network in the GeNIe .xdsl format, with the Nodes in topological
+// order (otherwise in the order they are declared), their state ids as
+// State labels and the centres of their drawings as positions.  CPT and
+// deterministic nodes are supported; other nodes (e.g. noisy-MAX, decision,
+// utility and equation nodes) are skipped with a warning, and a node that
+// depends on one is an error.
+func ReadXDSL(r io.Reader) (*BayesianNetwork, []string, error) {
+	var encoded xdslFile
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&encoded); err != nil {
+		return nil, nil, fmt.Errorf("bayesiannetwork: malformed xdsl: %v", err)
+	}
+
+	warnings := make([]string, 0)
+	net := NewBayesianNetwork()
+	byName := make(map[string]*Node)
+	skipped := make(map[string]string)
+	for _, node := range encoded.Nodes.Nodes {
+		kind := node.XMLName.Local
+		if _, exists := byName[node.ID]; exists || skipped[node.ID] != "" {
+			return nil, warnings, fmt.Errorf("bayesiannetwork: duplicate xdsl node %q", node.ID)
+		}
+		if kind != "cpt" && kind != "deterministic" {
+			warnings = append(warnings,
+				fmt.Sprintf("%s node %q isn't supported and is skipped", kind, node.ID))
+			skipped[node.ID] = kind
+			continue
+		}
+
+		n := &Node{Name: node.ID, States: len(node.States)}
+		for _, s := range node.States {
+			n.StateLabels = append(n.StateLabels, s.ID)
+		}
+		if n.States == 0 {
+			return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl node %q has no states", n.Name)
+		}
+		byName[n.Name] = n
+		net.Nodes = append(net.Nodes, n)
+	}
+
+	// a parent may be declared after its child
+	for _, node := range encoded.Nodes.Nodes {
+		kind := node.XMLName.Local
+		if skipped[node.ID] != "" {
+			continue
+		}
+		n := byName[node.ID]
+		for _, name := range strings.Fields(node.Parents) {
+			if kind := skipped[name]; kind != "" {
+				return nil, warnings, fmt.Errorf(
+					"bayesiannetwork: xdsl node %q depends on %q, a %s node, which isn't supported",
+					n.Name, name, kind)
+			}
+			p, exists := byName[name]
+			if !exists {
+				return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl node %q has unknown parent %q",
+					n.Name, name)
+			}
+			if containsNode(n.Parents, p) {
+				return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl node %q has parent %q twice",
+					n.Name, name)
+			}
+			net.AddEdge(p, n)
+		}
+
+		var values []float64
+		if kind == "cpt" {
+			var err error
+			if values, err = parseTable(n, strings.Fields(node.Probabilities)); err != nil {
+				return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl %v", err)
+			}
+		} else {
+			results := strings.Fields(node.ResultingStates)
+			if len(results) != len(NewFactor(n.Parents...).Values) {
+				return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl node %q has %d resulting states, expected %d",
+					n.Name, len(results), len(NewFactor(n.Parents...).Values))
+			}
+			values = make([]float64, n.States*len(results))
+			for k, result := range results {
+				s, exists := stateOf(n, result)
+				if !exists {
+					return nil, warnings, fmt.Errorf("bayesiannetwork: xdsl node %q has no state %q",
+						n.Name, result)
+				}
+				values[k*n.States+s] = 1
+			}
+		}
+		setRows(n, rowsFromFlat(n, values))
+	}
+
+	if encoded.Extensions != nil {
+		positions := make(map[string]*Position)
+		genie := encoded.Extensions.Genie
+		xdslSubmodel{Nodes: genie.Nodes, Submodels: genie.Submodels}.positions(positions)
+		for name, position := range positions {
+			if n, exists := byName[name]; exists {
+				n.Position = position
+			}
+		}
+		if len(genie.Submodels) > 0 {
+			warnings = append(warnings, "submodels are flattened")
+		}
+	}
+	net.stableTopologicalSort()
+	if err := net.Validate(); err != nil {
+		return nil, warnings, err
+	}
+	return net, warnings, nil
+}
+
+// load a network from a GeNIe .xdsl file
+func LoadXDSL(path string) (*BayesianNetwork, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadXDSL(f)
+}
+
+// write the network in the GeNIe .xdsl format, with a CPT for each Node
+// and its position when it has one (or else a place on a grid).  Any CPD
+// is written as its table.  Node names and State labels must be
+// identifiers; States without labels are written as State0, State1, ...
+func (net BayesianNetwork) WriteXDSL(w io.Writer) error {
+	if err := net.Validate(); err != nil {
+		return err
+	}
+	encoded := xdslFile{
+		Version:    "1.0",
+		ID:         "Network",
+		Extensions: &xdslExtensions{Genie: xdslGenie{Version: "1.0", App: "gobn", Name: "Network"}}}
+	for i, n := range net.Nodes {
+		if !isIdentifier(n.Name) {
+			return fmt.Errorf("bayesiannetwork: node name %q can't be written in xdsl", n.Name)
+		}
+		node := xdslNode{XMLName: xml.Name{Local: "cpt"}, ID: n.Name}
+		for s := 0; s < n.States; s++ {
+			label := "State" + strconv.Itoa(s)
+			if len(n.StateLabels) > 0 {
+				label = n.StateLabels[s]
+			}
+			if !isIdentifier(label) {
+				return fmt.Errorf("bayesiannetwork: state %q of node %q can't be written in xdsl",
+					label, n.Name)
+			}
+			node.States = append(node.States, xdslState{ID: label})
+		}
+		parents := make([]string, len(n.Parents))
+		for k, p := range n.Parents {
+			parents[k] = p.Name
+		}
+		node.Parents = strings.Join(parents, " ")
+		rows, err := probabilityRows(n)
+		if err != nil {
+			return err
+		}
+		node.Probabilities = joinProbabilities(flatFromRows(n, rows), " ")
+		encoded.Nodes.Nodes = append(encoded.Nodes.Nodes, node)
+
+		position := Position{X: float64(50 + i%8*120), Y: float64(50 + i/8*100)}
+		if n.Position != nil {
+			position = *n.Position
+		}
+		left := math.Round(position.X - xdslWidth/2)
+		top := math.Round(position.Y - xdslHeight/2)
+		encoded.Extensions.Genie.Nodes = append(encoded.Extensions.Genie.Nodes, xdslGenieNode{
+			ID:       n.Name,
+			Name:     n.Name,
+			Interior: &xdslColor{"e5f6f7"},
+			Outline:  &xdslColor{"000080"},
+			Font:     &xdslFont{Color: "000000", Name: "Arial", Size: 8},
+			Position: fmt.Sprintf("%g %g %g %g", left, top, left+xdslWidth, top+xdslHeight)})
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	e := xml.NewEncoder(&b)
+	e.Indent("", "\t")
+	if err := e.Encode(encoded); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// save the network to a GeNIe .xdsl file
+func (net BayesianNetwork) SaveXDSL(path string) error {
+	return saveFile(path, net.WriteXDSL)
+}
@@ -0,0 +1,183 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXDSL = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<smile version="1.0" id="Network1" numsamples="10000" discsamples="10000">
+	<nodes>
+		<cpt id="a">
+			<state id="t" />
+			<state id="f" />
+			<probabilities>0.2 0.8</probabilities>
+		</cpt>
+		<cpt id="b">
+			<state id="x" />
+			<state id="y" />
+			<state id="z" />
+			<probabilities>0.2 0.3 0.5</probabilities>
+		</cpt>
+		<cpt id="c">
+			<state id="t" />
+			<state id="f" />
+			<parents>a b</parents>
+			<probabilities>0.1 0.9 0.2 0.8 0.3 0.7 0.4 0.6 0.5 0.5 0.6 0.4</probabilities>
+		</cpt>
+		<deterministic id="e">
+			<state id="on" />
+			<state id="off" />
+			<parents>a</parents>
+			<resultingstates>off on</resultingstates>
+		</deterministic>
+		<decision id="d">
+			<state id="go" />
+			<state id="stop" />
+		</decision>
+	</nodes>
+	<extensions>
+		<genie version="1.0" app="GeNIe 2.0" name="Network1">
+			<node id="a">
+				<name>A</name>
+				<interior color="e5f6f7" />
+				<outline color="000080" />
+				<font color="000000" name="Arial" size="8" />
+				<position>64 26 136 74</position>
+			</node>
+			<submodel id="sub">
+				<node id="b">
+					<name>B</name>
+					<position>10 20 30 40</position>
+				</node>
+			</submodel>
+		</genie>
+	</extensions>
+</smile>
+`
+
+func TestReadXDSL(t *testing.T) {
+	network, warnings, err := ReadXDSL(strings.NewReader(testXDSL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 4 {
+		t.Fatal(len(network.Nodes))
+	}
+	a, b, c, e := network.Nodes[0], network.Nodes[1], network.Nodes[2], network.Nodes[3]
+	if *a.Position != (Position{100, 50}) || *b.Position != (Position{20, 30}) || c.Position != nil {
+		t.Fatal(a.Position, b.Position)
+	}
+	if p := c.conditionalProbability(map[*Node]int{a: 1, b: 1, c: 0}); math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+	if p := c.conditionalProbability(map[*Node]int{a: 0, b: 2, c: 1}); math.Abs(p-.7) > 1e-12 {
+		t.Fatal(p)
+	}
+	if e.conditionalProbability(map[*Node]int{a: 0, e: 1}) != 1 ||
+		e.conditionalProbability(map[*Node]int{a: 1, e: 0}) != 1 {
+		t.Fatal("wrong deterministic table")
+	}
+	// the decision and the submodel
+	if len(warnings) != 2 {
+		t.Fatal(warnings)
+	}
+
+	replace := func(old, new string) string {
+		return strings.Replace(testXDSL, old, new, 1)
+	}
+	cases := map[string]string{
+		"malformed": testXDSL[:200],
+		"unknown":   replace("<parents>a b</parents>", "<parents>a g</parents>"),
+		"decision":  replace("<parents>a</parents>", "<parents>d</parents>"),
+		"count":     replace("0.6 0.4</probabilities>", "0.6</probabilities>"),
+		"number":    replace("0.2 0.8", "0.2 x"),
+		"sum":       replace("0.2 0.8", "0.2 0.2"),
+		"result":    replace("off on", "off maybe"),
+		"duplicate": replace(`<cpt id="b">`, `<cpt id="a">`),
+	}
+	for name, data := range cases {
+		if _, _, err := ReadXDSL(strings.NewReader(data)); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+}
+
+func TestXDSLChildFirst(t *testing.T) {
+	// c and e are declared before their Parents
+	child := `		<cpt id="c">
+			<state id="t" />
+			<state id="f" />
+			<parents>a b</parents>
+			<probabilities>0.1 0.9 0.2 0.8 0.3 0.7 0.4 0.6 0.5 0.5 0.6 0.4</probabilities>
+		</cpt>
+		<deterministic id="e">
+			<state id="on" />
+			<state id="off" />
+			<parents>a</parents>
+			<resultingstates>off on</resultingstates>
+		</deterministic>
+`
+	input := strings.Replace(testXDSL, child, "", 1)
+	input = strings.Replace(input, "\t<nodes>\n", "\t<nodes>\n"+child, 1)
+	if strings.Index(input, `id="c"`) > strings.Index(input, `id="a"`) {
+		t.Fatal("c isn't declared first")
+	}
+	network, _, err := ReadXDSL(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(network.Nodes) != 4 {
+		t.Fatal(network.Nodes)
+	}
+	a, b, c, e := network.Nodes[0], network.Nodes[1], network.Nodes[2], network.Nodes[3]
+	if a.Name != "a" || b.Name != "b" || c.Name != "c" || e.Name != "e" {
+		t.Fatal(network.Nodes)
+	}
+	// P(c = t) = .2 * (.2 * .1 + .3 * .2 + .5 * .3) + .8 * (.2 * .4 + .3 * .5 + .5 * .6)
+	if p := sampledFrequency(network, c, 0); math.Abs(p-.47) > .04 {
+		t.Fatal(p)
+	}
+}
+
+func TestWriteXDSL(t *testing.T) {
+	network, _, err := ReadXDSL(strings.NewReader(testXDSL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := network.WriteXDSL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, warnings, err := ReadXDSL(&buf)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err, warnings)
+	}
+	checkSameNetwork(t, network, read)
+	if *read.Nodes[0].Position != *network.Nodes[0].Position {
+		t.Fatal(read.Nodes[0].Position)
+	}
+
+	// States without labels get names
+	noisy, causes, _ := initNoisyORNetwork([]float64{.2, .4}, []float64{.8, .5}, .1)
+	causes[1].Name = "other"
+	path := filepath.Join(t.TempDir(), "noisy.xdsl")
+	if err := noisy.SaveXDSL(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _, err := LoadXDSL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Nodes[0].StateLabel(1) != "State1" || loaded.Nodes[0].Position == nil {
+		t.Fatal(loaded.Nodes[0].StateLabels)
+	}
+
+	network.Nodes[0].StateLabels[0] = "not an identifier"
+	if err := network.WriteXDSL(&buf); err == nil {
+		t.Fatal("expected an error for a state that isn't an identifier")
+	}
+}
@@ -0,0 +1,161 @@
+package bayesiannetwork
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type xmlBIF struct {
+	XMLName xml.Name   `xml:"BIF"`
+	Version string     `xml:"VERSION,attr"`
+	Network xmlNetwork `xml:"NETWORK"`
+}
+
+type xmlNetwork struct {
+	Name      string          `xml:"NAME"`
+	Variables []xmlVariable   `xml:"VARIABLE"`
+	Tables    []xmlDefinition `xml:"DEFINITION"`
+	// the name of DEFINITION before XMLBIF 0.3
+	Probabilities []xmlDefinition `xml:"PROBABILITY"`
+}
+
+type xmlVariable struct {
+	Type       string   `xml:"TYPE,attr"`
+	Name       string   `xml:"NAME"`
+	Outcomes   []string `xml:"OUTCOME"`
+	Properties []string `xml:"PROPERTY,omitempty"`
+}
+
+type xmlDefinition struct {
+	For   string   `xml:"FOR"`
+	Given []string `xml:"GIVEN"`
+	// the probabilities with the State of FOR varying fastest, then the
+	// last GIVEN's State
+	Table string `xml:"TABLE"`
+}
+
+// read a network in XMLBIF 0.3, with the Nodes in topological order
+// (otherwise in the order the variables are declared) and their outcomes as
+// State labels.  Only variables of type "nature" are supported.
+func ReadXMLBIF(r io.Reader) (*BayesianNetwork, error) {
+	var encoded xmlBIF
+	if err := xml.NewDecoder(r).Decode(&encoded); err != nil {
+		return nil, fmt.Errorf("bayesiannetwork: malformed xmlbif: %v", err)
+	}
+
+	net := NewBayesianNetwork()
+	byName := make(map[string]*Node)
+	for _, v := range encoded.Network.Variables {
+		name := strings.TrimSpace(v.Name)
+		if v.Type != "" && v.Type != "nature" {
+			return nil, fmt.Errorf("bayesiannetwork: xmlbif variable %q has unsupported type %q",
+				name, v.Type)
+		}
+		if _, exists := byName[name]; exists {
+			return nil, fmt.Errorf("bayesiannetwork: duplicate xmlbif variable %q", name)
+		}
+		n := &Node{Name: name, States: len(v.Outcomes)}
+		for _, outcome := range v.Outcomes {
+			n.StateLabels = append(n.StateLabels, strings.TrimSpace(outcome))
+		}
+		byName[name] = n
+		net.Nodes = append(net.Nodes, n)
+	}
+
+	defined := make(map[*Node]bool)
+	for _, d := range append(encoded.Network.Tables, encoded.Network.Probabilities...) {
+		n, exists := byName[strings.TrimSpace(d.For)]
+		if !exists {
+			return nil, fmt.Errorf("bayesiannetwork: xmlbif definition for unknown variable %q", d.For)
+		}
+		if defined[n] {
+			return nil, fmt.Errorf("bayesiannetwork: xmlbif variable %q has two definitions", n.Name)
+		}
+		defined[n] = true
+		for _, name := range d.Given {
+			p, exists := byName[strings.TrimSpace(name)]
+			if !exists {
+				return nil, fmt.Errorf("bayesiannetwork: xmlbif variable %q has unknown parent %q",
+					n.Name, name)
+			}
+			if p == n || containsNode(n.Parents, p) {
+				return nil, fmt.Errorf("bayesiannetwork: xmlbif variable %q can't have %q as a parent",
+					n.Name, p.Name)
+			}
+			net.AddEdge(p, n)
+		}
+
+		values, err := parseTable(n, strings.Fields(d.Table))
+		if err != nil {
+			return nil, fmt.Errorf("bayesiannetwork: xmlbif %v", err)
+		}
+		rows := rowsFromFlat(n, values)
+		setRows(n, rows)
+	}
+	for _, n := range net.Nodes {
+		if !defined[n] {
+			return nil, fmt.Errorf("bayesiannetwork: xmlbif variable %q has no definition", n.Name)
+		}
+	}
+	net.stableTopologicalSort()
+	if err := net.Validate(); err != nil {
+		return nil, err
+	}
+	return net, nil
+}
+
+// load a network from an XMLBIF file
+func LoadXMLBIF(path string) (*BayesianNetwork, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadXMLBIF(f)
+}
+
+// write the network in XMLBIF 0.3.  Any CPD is written as its table.
+func (net BayesianNetwork) WriteXMLBIF(w io.Writer) error {
+	if err := net.Validate(); err != nil {
+		return err
+	}
+	encoded := xmlBIF{Version: "0.3", Network: xmlNetwork{Name: "unknown"}}
+	for _, n := range net.Nodes {
+		v := xmlVariable{Type: "nature", Name: n.Name, Outcomes: make([]string, n.States)}
+		for s := range v.Outcomes {
+			v.Outcomes[s] = n.StateLabel(s)
+		}
+		encoded.Network.Variables = append(encoded.Network.Variables, v)
+	}
+	for _, n := range net.Nodes {
+		rows, err := probabilityRows(n)
+		if err != nil {
+			return err
+		}
+		d := xmlDefinition{For: n.Name}
+		for _, p := range n.Parents {
+			d.Given = append(d.Given, p.Name)
+		}
+		d.Table = joinProbabilities(flatFromRows(n, rows), " ")
+		encoded.Network.Tables = append(encoded.Network.Tables, d)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(encoded); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// save the network to an XMLBIF file
+func (net BayesianNetwork) SaveXMLBIF(path string) error {
+	return saveFile(path, net.WriteXMLBIF)
+}
@@ -0,0 +1,163 @@
+package bayesiannetwork
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXMLBIF = `<?xml version="1.0"?>
+<BIF VERSION="0.3">
+<NETWORK>
+<NAME>test</NAME>
+<VARIABLE TYPE="nature">
+  <NAME>a</NAME>
+  <OUTCOME>t</OUTCOME>
+  <OUTCOME>f</OUTCOME>
+  <PROPERTY>position = (10, 20)</PROPERTY>
+</VARIABLE>
+<VARIABLE TYPE="nature">
+  <NAME>b</NAME>
+  <OUTCOME>x</OUTCOME>
+  <OUTCOME>y</OUTCOME>
+  <OUTCOME>z</OUTCOME>
+</VARIABLE>
+<VARIABLE TYPE="nature">
+  <NAME>c</NAME>
+  <OUTCOME>t</OUTCOME>
+  <OUTCOME>f</OUTCOME>
+</VARIABLE>
+<DEFINITION>
+  <FOR>a</FOR>
+  <TABLE>0.2 0.8</TABLE>
+</DEFINITION>
+<DEFINITION>
+  <FOR>b</FOR>
+  <TABLE>0.2 0.3 0.5</TABLE>
+</DEFINITION>
+<DEFINITION>
+  <FOR>c</FOR>
+  <GIVEN>a</GIVEN>
+  <GIVEN>b</GIVEN>
+  <TABLE>
+    0.1 0.9  0.2 0.8  0.3 0.7
+    0.4 0.6  0.5 0.5  0.6 0.4
+  </TABLE>
+</DEFINITION>
+</NETWORK>
+</BIF>
+`
+
+func TestReadXMLBIF(t *testing.T) {
+	network, err := ReadXMLBIF(strings.NewReader(testXMLBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	if c.Parents[0] != a || c.Parents[1] != b || b.StateLabel(2) != "z" {
+		t.Fatal(c.Parents)
+	}
+	// the child varies fastest, then the last parent
+	if p := c.conditionalProbability(map[*Node]int{a: 1, b: 1, c: 0}); math.Abs(p-.5) > 1e-12 {
+		t.Fatal(p)
+	}
+	if p := c.conditionalProbability(map[*Node]int{a: 0, b: 2, c: 1}); math.Abs(p-.7) > 1e-12 {
+		t.Fatal(p)
+	}
+
+	replace := func(old, new string) string {
+		return strings.Replace(testXMLBIF, old, new, 1)
+	}
+	cases := map[string]string{
+		"malformed": testXMLBIF[:100],
+		"type":      replace(`TYPE="nature"`, `TYPE="decision"`),
+		"unknown":   replace("<GIVEN>b</GIVEN>", "<GIVEN>d</GIVEN>"),
+		"count":     replace("0.6 0.4\n", "\n"),
+		"number":    replace("0.2 0.8", "0.2 x"),
+		"sum":       replace("0.2 0.8", "0.2 0.2"),
+		"undefined": replace("<FOR>a</FOR>", "<FOR>c</FOR>"),
+	}
+	for name, data := range cases {
+		if _, err := ReadXMLBIF(strings.NewReader(data)); err == nil {
+			t.Error(name, "expected an error")
+		}
+	}
+}
+
+func TestXMLBIFChildFirst(t *testing.T) {
+	// c is declared and defined before its Parents
+	input := strings.Replace(testXMLBIF, `<VARIABLE TYPE="nature">
+  <NAME>c</NAME>
+  <OUTCOME>t</OUTCOME>
+  <OUTCOME>f</OUTCOME>
+</VARIABLE>
+`, "", 1)
+	input = strings.Replace(input, `<VARIABLE TYPE="nature">
+  <NAME>a</NAME>`, `<VARIABLE TYPE="nature">
+  <NAME>c</NAME>
+  <OUTCOME>t</OUTCOME>
+  <OUTCOME>f</OUTCOME>
+</VARIABLE>
+<VARIABLE TYPE="nature">
+  <NAME>a</NAME>`, 1)
+	if strings.Index(input, "<NAME>c</NAME>") > strings.Index(input, "<NAME>a</NAME>") {
+		t.Fatal("c isn't declared first")
+	}
+	network, err := ReadXMLBIF(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b, c := network.Nodes[0], network.Nodes[1], network.Nodes[2]
+	if a.Name != "a" || b.Name != "b" || c.Name != "c" {
+		t.Fatal(network.Nodes)
+	}
+	// P(c = t) = .2 * (.2 * .1 + .3 * .2 + .5 * .3) + .8 * (.2 * .4 + .3 * .5 + .5 * .6)
+	r := rand.New(rand.NewSource(1))
+	count := 0
+	for i := 0; i < 2000; i++ {
+		if network.Sample(r)[c] == 0 {
+			count++
+		}
+	}
+	if p := float64(count) / 2000; math.Abs(p-.47) > .04 {
+		t.Fatal(p)
+	}
+}
+
+func TestWriteXMLBIF(t *testing.T) {
+	network, err := ReadBIF(strings.NewReader(asiaBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := network.WriteXMLBIF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadXMLBIF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, network, read)
+
+	network, err = ReadXMLBIF(strings.NewReader(testXMLBIF))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.xml")
+	if err := network.SaveXMLBIF(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadXMLBIF(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameNetwork(t, network, loaded)
+
+	network.Nodes = append(network.Nodes, &Node{Name: "unfitted", States: 2})
+	if err := network.WriteXMLBIF(&buf); err == nil {
+		t.Fatal("expected an error for a node without a CPD")
+	}
+}
@@ -0,0 +1,167 @@
+package discretization
+
+import (
+	"bayesiannetwork"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// A Binning maps continuous values to the bins between its Edges.  Value v is
+// in bin i when Edges[i-1] <= v < Edges[i], so there is one more bin than
+// there are Edges.  A Binning marshals to JSON so that it can be fitted once
+// and applied to new data later.
+type Binning struct {
+	// the algorithm that chose the Edges
+	Method string `json:"method"`
+	// the interior cut points in increasing order
+	Edges []float64 `json:"edges"`
+}
+
+// the number of bins
+func (b *Binning) Bins() int {
+	return len(b.Edges) + 1
+}
+
+// check that the Edges are finite and increasing, e.g. after unmarshalling
+func (b *Binning) Validate() error {
+	for i, e := range b.Edges {
+		if math.IsNaN(e) || math.IsInf(e, 0) {
+			return fmt.Errorf("discretization: edge %d is %v", i, e)
+		}
+		if i > 0 && e <= b.Edges[i-1] {
+			return fmt.Errorf("discretization: edges are not increasing at %d", i)
+		}
+	}
+	return nil
+}
+
+// the bin of a value; NaN is bayesiannetwork.Missing
+func (b *Binning) Apply(v float64) int {
+	if math.IsNaN(v) {
+		return bayesiannetwork.Missing
+	}
+	return sort.Search(len(b.Edges), func(i int) bool { return v < b.Edges[i] })
+}
+
+// the bin of every value
+func (b *Binning) ApplyAll(values []float64) []int {
+	bins := make([]int, len(values))
+	for i, v := range values {
+		bins[i] = b.Apply(v)
+	}
+	return bins
+}
+
+// a label for every bin showing its edges, e.g. "< 5.5", "[5.5, 6.7)" and
+// ">= 6.7"
+func (b *Binning) Labels() []string {
+	if len(b.Edges) == 0 {
+		return []string{"all"}
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+
+	labels := make([]string, b.Bins())
+	labels[0] = "< " + format(b.Edges[0])
+	for i := 1; i < len(b.Edges); i++ {
+		labels[i] = "[" + format(b.Edges[i-1]) + ", " + format(b.Edges[i]) + ")"
+	}
+	labels[len(b.Edges)] = ">= " + format(b.Edges[len(b.Edges)-1])
+	return labels
+}
+
+// a Node with a State for every bin, labelled with the bin edges
+func (b *Binning) Node(name string) *bayesiannetwork.Node {
+	return &bayesiannetwork.Node{
+		Name:        name,
+		States:      b.Bins(),
+		StateLabels: b.Labels()}
+}
+
+// convert a numeric Column to a categorical one whose categories are the
+// labelled bins
+func (b *Binning) Discretize(column *bayesiannetwork.Column) error {
+	if column.Categorical() {
+		return fmt.Errorf("discretization: column %q is already categorical", column.Name)
+	}
+	column.Categories = b.Labels()
+	column.States = b.ApplyAll(column.Values)
+	column.Values = nil
+	return nil
+}
+
+// the values that aren't NaN, sorted
+func sortedValues(values []float64) ([]float64, error) {
+	sorted := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			sorted = append(sorted, v)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil, errors.New("discretization: no values")
+	}
+	sort.Float64s(sorted)
+	return sorted, nil
+}
+
+// remove repeated edges, which would make empty bins
+func uniqueEdges(edges []float64) []float64 {
+	unique := make([]float64, 0, len(edges))
+	for _, e := range edges {
+		if len(unique) == 0 || e > unique[len(unique)-1] {
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// bins of equal width between the smallest and largest value
+func EqualWidth(values []float64, bins int) (*Binning, error) {
+	if bins < 1 {
+		return nil, errors.New("discretization: need at least one bin")
+	}
+	sorted, err := sortedValues(values)
+	if err != nil {
+		return nil, err
+	}
+	min, max := sorted[0], sorted[len(sorted)-1]
+
+	edges := make([]float64, 0, bins-1)
+	for i := 1; i < bins && max > min; i++ {
+		edges = append(edges, min+float64(i)*(max-min)/float64(bins))
+	}
+	return &Binning{Method: "equal-width", Edges: uniqueEdges(edges)}, nil
+}
+
+// bins holding (nearly) the same number of values, cut at the quantiles.
+// Repeated values can't be split, so there may be fewer bins than asked for.
+func EqualFrequency(values []float64, bins int) (*Binning, error) {
+	if bins < 1 {
+		return nil, errors.New("discretization: need at least one bin")
+	}
+	sorted, err := sortedValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]float64, 0, bins-1)
+	for i := 1; i < bins; i++ {
+		// cut halfway between the values either side of the quantile, or at
+		// the quantile when they're the same
+		index := i * len(sorted) / bins
+		if index == 0 {
+			continue
+		}
+		edge := sorted[index]
+		if sorted[index-1] < edge {
+			edge = (sorted[index-1] + edge) / 2
+		}
+		if edge > sorted[0] {
+			edges = append(edges, edge)
+		}
+	}
+	return &Binning{Method: "equal-frequency", Edges: uniqueEdges(edges)}, nil
+}
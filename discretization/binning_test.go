@@ -0,0 +1,127 @@
+package discretization
+
+import (
+	"bayesiannetwork"
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestEqualWidth(t *testing.T) {
+	values := []float64{0, 1, 2, 3, 4, 5, 6, math.NaN()}
+	b, err := EqualWidth(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Bins() != 3 || b.Edges[0] != 2 || b.Edges[1] != 4 {
+		t.Fatal(b.Edges)
+	}
+	solution := []int{0, 0, 1, 1, 2, 2, 2, bayesiannetwork.Missing}
+	for i, bin := range b.ApplyAll(values) {
+		if bin != solution[i] {
+			t.Fail()
+		}
+	}
+	// values outside of the fitted range go to the outer bins
+	if b.Apply(-10) != 0 || b.Apply(10) != 2 {
+		t.Fail()
+	}
+
+	// a constant has a single bin
+	b, _ = EqualWidth([]float64{1, 1, 1}, 3)
+	if b.Bins() != 1 {
+		t.Fail()
+	}
+	if _, err := EqualWidth([]float64{math.NaN()}, 3); err == nil {
+		t.Fail()
+	}
+	if _, err := EqualWidth(values, 0); err == nil {
+		t.Fail()
+	}
+}
+
+func TestEqualFrequency(t *testing.T) {
+	values := []float64{9, 1, 5, 3, 7, 2, 8, 4, 6}
+	b, err := EqualFrequency(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int, b.Bins())
+	for _, bin := range b.ApplyAll(values) {
+		counts[bin]++
+	}
+	for _, c := range counts {
+		if c != 3 {
+			t.Fatal(b.Edges, counts)
+		}
+	}
+
+	// repeated values can't be split
+	b, _ = EqualFrequency([]float64{1, 1, 1, 1, 2, 3}, 3)
+	if b.Bins() != 2 || b.Apply(1) != 0 || b.Apply(3) != 1 {
+		t.Fatal(b.Edges)
+	}
+}
+
+func TestBinningLabels(t *testing.T) {
+	b := &Binning{Edges: []float64{1.5, 3}}
+	labels := b.Labels()
+	if labels[0] != "< 1.5" || labels[1] != "[1.5, 3)" || labels[2] != ">= 3" {
+		t.Fatal(labels)
+	}
+	n := b.Node("x")
+	if n.Name != "x" || n.States != 3 || n.StateLabels[1] != "[1.5, 3)" {
+		t.Fail()
+	}
+	if (&Binning{}).Labels()[0] != "all" {
+		t.Fail()
+	}
+}
+
+func TestBinningJSON(t *testing.T) {
+	b, _ := EqualWidth([]float64{0, 10}, 4)
+	encoded, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Binning{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Method != "equal-width" || decoded.Validate() != nil {
+		t.Fail()
+	}
+	for _, v := range []float64{-1, 2.5, 5, 9.99, 11} {
+		if decoded.Apply(v) != b.Apply(v) {
+			t.Fail()
+		}
+	}
+
+	if (&Binning{Edges: []float64{2, 1}}).Validate() == nil {
+		t.Fail()
+	}
+	if (&Binning{Edges: []float64{math.Inf(1)}}).Validate() == nil {
+		t.Fail()
+	}
+}
+
+func TestDiscretize(t *testing.T) {
+	column := &bayesiannetwork.Column{Name: "x", Values: []float64{0, 5, 10, math.NaN()}}
+	b, _ := EqualWidth(column.Values, 2)
+	if err := b.Discretize(column); err != nil {
+		t.Fatal(err)
+	}
+	if !column.Categorical() || column.States[0] != 0 || column.States[2] != 1 ||
+		column.States[3] != bayesiannetwork.Missing || column.Categories[1] != ">= 5" {
+		t.Fail()
+	}
+	if b.Discretize(column) == nil {
+		t.Fail()
+	}
+
+	table := &bayesiannetwork.Table{Columns: []*bayesiannetwork.Column{column}, Rows: 4}
+	ds, err := table.Dataset()
+	if err != nil || ds.Node("x").StateLabels[0] != "< 5" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,66 @@
+package discretization
+
+import (
+	"errors"
+	"math"
+)
+
+// the largest number of iterations of Lloyd's algorithm used by KMeans
+const kMeansIterations = 100
+
+// bins from one dimensional k-means clustering: the edges are halfway
+// between neighbouring cluster centers.  The centers start at evenly spaced
+// quantiles so the result is deterministic.
+func KMeans(values []float64, k int) (*Binning, error) {
+	if k < 1 {
+		return nil, errors.New("discretization: need at least one bin")
+	}
+	sorted, err := sortedValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	centers := make([]float64, k)
+	for i := range centers {
+		centers[i] = sorted[(2*i+1)*len(sorted)/(2*k)]
+	}
+
+	for iteration := 0; iteration < kMeansIterations; iteration++ {
+		sums := make([]float64, k)
+		counts := make([]int, k)
+		for _, v := range sorted {
+			nearest := 0
+			for c := range centers {
+				if math.Abs(v-centers[c]) < math.Abs(v-centers[nearest]) {
+					nearest = c
+				}
+			}
+			sums[nearest] += v
+			counts[nearest]++
+		}
+
+		changed := false
+		for c := range centers {
+			if counts[c] == 0 {
+				continue
+			}
+			if mean := sums[c] / float64(counts[c]); mean != centers[c] {
+				centers[c] = mean
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	// the centers stay sorted because the clusters of sorted values are
+	// contiguous, but clusters can merge
+	edges := make([]float64, 0, k-1)
+	for c := 1; c < k; c++ {
+		if centers[c] > centers[c-1] {
+			edges = append(edges, (centers[c-1]+centers[c])/2)
+		}
+	}
+	return &Binning{Method: "k-means", Edges: uniqueEdges(edges)}, nil
+}
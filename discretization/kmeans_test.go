@@ -0,0 +1,30 @@
+package discretization
+
+import "testing"
+
+func TestKMeans(t *testing.T) {
+	// three well separated clusters
+	values := []float64{1, 1.2, 0.8, 5, 5.1, 4.9, 5.2, 10, 10.5, 9.5}
+	b, err := KMeans(values, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Bins() != 3 {
+		t.Fatal(b.Edges)
+	}
+	solution := []int{0, 0, 0, 1, 1, 1, 1, 2, 2, 2}
+	for i, bin := range b.ApplyAll(values) {
+		if bin != solution[i] {
+			t.Fail()
+		}
+	}
+
+	// fewer distinct values than clusters
+	b, _ = KMeans([]float64{1, 1, 2, 2}, 4)
+	if b.Bins() != 2 || b.Apply(1) == b.Apply(2) {
+		t.Fatal(b.Edges)
+	}
+	if _, err := KMeans(values, 0); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,115 @@
+package discretization
+
+import (
+	"bayesiannetwork"
+	"errors"
+	"math"
+	"sort"
+)
+
+// supervised bins chosen by recursive minimal entropy partitioning with the
+// minimum description length stopping rule of Fayyad and Irani (1993).
+// classes holds the class State of each value; values that are NaN or whose
+// class is bayesiannetwork.Missing are ignored.  When no cut is worth its
+// description length there is a single bin.
+func MDL(values []float64, classes []int) (*Binning, error) {
+	if len(values) != len(classes) {
+		return nil, errors.New("discretization: need a class for every value")
+	}
+	points := make([]labelled, 0, len(values))
+	nClasses := 0
+	for i, v := range values {
+		if math.IsNaN(v) || classes[i] == bayesiannetwork.Missing {
+			continue
+		}
+		points = append(points, labelled{v, classes[i]})
+		if classes[i]+1 > nClasses {
+			nClasses = classes[i] + 1
+		}
+	}
+	if len(points) == 0 {
+		return nil, errors.New("discretization: no values")
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].value < points[j].value })
+
+	edges := mdlCuts(points, nClasses)
+	sort.Float64s(edges)
+	return &Binning{Method: "mdl", Edges: edges}, nil
+}
+
+type labelled struct {
+	value float64
+	class int
+}
+
+// the class counts of the points
+func classCounts(points []labelled, nClasses int) []float64 {
+	counts := make([]float64, nClasses)
+	for _, p := range points {
+		counts[p.class]++
+	}
+	return counts
+}
+
+// the entropy (in bits) of class counts and the number of classes present
+func entropy(counts []float64) (float64, int) {
+	total := 0.0
+	for _, c := range counts {
+		total += c
+	}
+	h, present := 0.0, 0
+	for _, c := range counts {
+		if c > 0 {
+			p := c / total
+			h -= p * math.Log2(p)
+			present++
+		}
+	}
+	return h, present
+}
+
+// the accepted cut points of the sorted points, recursively
+func mdlCuts(points []labelled, nClasses int) []float64 {
+	n := float64(len(points))
+	total := classCounts(points, nClasses)
+	h, k := entropy(total)
+
+	// find the boundary with the lowest weighted entropy of the two sides
+	left := make([]float64, nClasses)
+	right := make([]float64, nClasses)
+	best, bestEntropy := -1, math.Inf(1)
+	var bestLeft, bestRight float64
+	for i := 0; i < len(points)-1; i++ {
+		left[points[i].class]++
+		if points[i].value == points[i+1].value {
+			continue
+		}
+		for c := range right {
+			right[c] = total[c] - left[c]
+		}
+		hl, _ := entropy(left)
+		hr, _ := entropy(right)
+		size := float64(i + 1)
+		weighted := size/n*hl + (n-size)/n*hr
+		if weighted < bestEntropy {
+			best, bestEntropy, bestLeft, bestRight = i, weighted, hl, hr
+		}
+	}
+	if best == -1 {
+		return []float64{}
+	}
+
+	// the MDL criterion for accepting the cut
+	_, k1 := entropy(classCounts(points[:best+1], nClasses))
+	_, k2 := entropy(classCounts(points[best+1:], nClasses))
+	gain := h - bestEntropy
+	delta := math.Log2(math.Pow(3, float64(k))-2) -
+		(float64(k)*h - float64(k1)*bestLeft - float64(k2)*bestRight)
+	if gain <= (math.Log2(n-1)+delta)/n {
+		return []float64{}
+	}
+
+	cut := (points[best].value + points[best+1].value) / 2
+	cuts := append(mdlCuts(points[:best+1], nClasses), cut)
+	return append(cuts, mdlCuts(points[best+1:], nClasses)...)
+}
@@ -0,0 +1,40 @@
+package discretization
+
+import (
+	"bayesiannetwork"
+	"math"
+	"testing"
+)
+
+func TestMDL(t *testing.T) {
+	// the class changes at 10 and 20
+	values := make([]float64, 0)
+	classes := make([]int, 0)
+	for i := 0; i < 30; i++ {
+		values = append(values, float64(i))
+		classes = append(classes, i/10)
+	}
+	values = append(values, math.NaN(), 3)
+	classes = append(classes, 1, bayesiannetwork.Missing)
+
+	b, err := MDL(values, classes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Bins() != 3 || b.Edges[0] != 9.5 || b.Edges[1] != 19.5 {
+		t.Fatal(b.Edges)
+	}
+
+	// a feature unrelated to the class isn't cut
+	for i := range classes {
+		classes[i] = i % 2
+	}
+	b, _ = MDL(values, classes)
+	if b.Bins() != 1 {
+		t.Fatal(b.Edges)
+	}
+
+	if _, err := MDL(values, classes[:3]); err == nil {
+		t.Fail()
+	}
+}
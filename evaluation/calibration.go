@@ -0,0 +1,83 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+)
+
+// A bin of a reliability curve: the instances whose predicted probability of
+// a class fell in [Lower, Upper)
+type CalibrationBin struct {
+	Lower float64
+	Upper float64
+	Count int
+	// the mean predicted probability of the class in the bin
+	MeanPredicted float64
+	// the fraction of the instances in the bin that were the class
+	ObservedFrequency float64
+}
+
+// the reliability curve of the predicted probabilities of class k over bins
+// of equal width.  A well calibrated classifier has MeanPredicted close to
+// ObservedFrequency in every bin.  Empty bins are included with a Count of 0.
+// There must be at least one bin and a probability in [0, 1] of class k for
+// every instance.
+func Calibration(actual []int, probs [][]float64, k int, bins int) ([]CalibrationBin, error) {
+	if bins <= 0 {
+		return nil, fmt.Errorf("evaluation: %d bins, expected at least 1", bins)
+	}
+	if len(actual) != len(probs) {
+		return nil, fmt.Errorf("evaluation: %d actual classes but %d predicted distributions",
+			len(actual), len(probs))
+	}
+	for i, p := range probs {
+		if k < 0 || k >= len(p) {
+			return nil, fmt.Errorf("evaluation: class %d is out of range [0, %d) for instance %d",
+				k, len(p), i)
+		}
+		if !(p[k] >= 0 && p[k] <= 1) {
+			return nil, fmt.Errorf("evaluation: probability %g of instance %d is outside [0, 1]",
+				p[k], i)
+		}
+	}
+
+	curve := make([]CalibrationBin, bins)
+	for b := range curve {
+		curve[b].Lower = float64(b) / float64(bins)
+		curve[b].Upper = float64(b+1) / float64(bins)
+	}
+
+	for i, c := range actual {
+		p := probs[i][k]
+		// a probability of 1 belongs in the last bin
+		b := int(math.Min(math.Floor(p*float64(bins)), float64(bins-1)))
+		curve[b].Count++
+		curve[b].MeanPredicted += p
+		if c == k {
+			curve[b].ObservedFrequency++
+		}
+	}
+
+	for b := range curve {
+		if curve[b].Count > 0 {
+			curve[b].MeanPredicted /= float64(curve[b].Count)
+			curve[b].ObservedFrequency /= float64(curve[b].Count)
+		}
+	}
+	return curve, nil
+}
+
+// the expected calibration error: the mean absolute difference between the
+// predicted and observed frequency over the bins, weighted by their counts
+func ExpectedCalibrationError(curve []CalibrationBin) float64 {
+	total, err := 0, 0.0
+	for _, bin := range curve {
+		total += bin.Count
+		err += float64(bin.Count) *
+			math.Abs(bin.MeanPredicted-bin.ObservedFrequency)
+	}
+	if total == 0 {
+		return 0
+	}
+	return err / float64(total)
+}
@@ -0,0 +1,44 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalibration(t *testing.T) {
+	actual := []int{1, 0, 1, 1, 0}
+	probs := [][]float64{{.9, .1}, {.8, .2}, {.2, .8}, {0, 1}, {.3, .7}}
+	curve, err := Calibration(actual, probs, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(curve) != 2 || curve[0].Upper != .5 || curve[1].Lower != .5 {
+		t.Fatal(curve)
+	}
+	// .1 and .2 in the lower bin, one of which is class 1
+	if curve[0].Count != 2 || math.Abs(curve[0].MeanPredicted-.15) > 1e-12 ||
+		curve[0].ObservedFrequency != .5 {
+		t.Fail()
+	}
+	// .8, 1 and .7 in the upper bin, two of which are class 1
+	if curve[1].Count != 3 || math.Abs(curve[1].ObservedFrequency-2.0/3) > 1e-12 {
+		t.Fail()
+	}
+
+	ece := (2*math.Abs(.15-.5) + 3*math.Abs(2.5/3-2.0/3)) / 5
+	if math.Abs(ExpectedCalibrationError(curve)-ece) > 1e-12 {
+		t.Fail()
+	}
+
+	if _, err := Calibration(actual, probs, 1, 0); err == nil {
+		t.Fatal("expected an error without bins")
+	}
+	if _, err := Calibration(actual, probs, 2, 2); err == nil {
+		t.Fatal("expected an error for a class out of range")
+	}
+	probs[3][1] = 1.5
+	if _, err := Calibration(actual, probs, 1, 2); err == nil {
+		t.Fatal("expected an error for a probability outside [0, 1]")
+	}
+}
@@ -0,0 +1,115 @@
+package evaluation
+
+import (
+	"bayesiannetwork"
+	"fmt"
+	"math/rand"
+)
+
+// A Fold of cross validation: fit on Train and evaluate on Test
+type Fold struct {
+	Train []map[*bayesiannetwork.Node]int
+	Test  []map[*bayesiannetwork.Node]int
+}
+
+// check that there is at least one fold
+func checkFolds(k int) error {
+	if k <= 0 {
+		return fmt.Errorf("evaluation: %d folds, expected at least 1", k)
+	}
+	return nil
+}
+
+// split the data into k folds at random; every instance is in exactly one
+// test set
+func KFold(
+	data []map[*bayesiannetwork.Node]int,
+	k int,
+	r *rand.Rand) ([]Fold, error) {
+
+	if err := checkFolds(k); err != nil {
+		return nil, err
+	}
+	assignment := make([]int, len(data))
+	for i, index := range r.Perm(len(data)) {
+		assignment[index] = i % k
+	}
+	return makeFolds(data, assignment, k), nil
+}
+
+// split the data into k folds at random so that each fold has (nearly) the
+// same proportion of every State of the class Node as the whole data set
+func StratifiedKFold(
+	data []map[*bayesiannetwork.Node]int,
+	class *bayesiannetwork.Node,
+	k int,
+	r *rand.Rand) ([]Fold, error) {
+
+	if err := checkFolds(k); err != nil {
+		return nil, err
+	}
+	// group the instances by class, in order of first appearance so that the
+	// folds only depend on r
+	groups := make(map[int][]int)
+	classes := make([]int, 0)
+	for i, instance := range data {
+		c := instance[class]
+		if _, exists := groups[c]; !exists {
+			classes = append(classes, c)
+		}
+		groups[c] = append(groups[c], i)
+	}
+
+	// deal the shuffled instances of each class to the folds in turn,
+	// continuing where the previous class left off
+	assignment := make([]int, len(data))
+	next := 0
+	for _, c := range classes {
+		group := groups[c]
+		for _, i := range r.Perm(len(group)) {
+			assignment[group[i]] = next % k
+			next++
+		}
+	}
+	return makeFolds(data, assignment, k), nil
+}
+
+// build the folds given the test fold of each instance
+func makeFolds(
+	data []map[*bayesiannetwork.Node]int,
+	assignment []int,
+	k int) []Fold {
+
+	folds := make([]Fold, k)
+	for f := range folds {
+		folds[f].Train = make([]map[*bayesiannetwork.Node]int, 0)
+		folds[f].Test = make([]map[*bayesiannetwork.Node]int, 0)
+	}
+	for i, instance := range data {
+		for f := range folds {
+			if assignment[i] == f {
+				folds[f].Test = append(folds[f].Test, instance)
+			} else {
+				folds[f].Train = append(folds[f].Train, instance)
+			}
+		}
+	}
+	return folds
+}
+
+// run evaluate on every fold and collect the scores, stopping at the first
+// error
+func CrossValidate(
+	folds []Fold,
+	evaluate func(fold Fold) (float64, error)) ([]float64, error) {
+
+	scores := make([]float64, len(folds))
+	for i, fold := range folds {
+		score, err := evaluate(fold)
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
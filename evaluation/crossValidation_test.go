@@ -0,0 +1,90 @@
+package evaluation
+
+import (
+	"bayesiannetwork"
+	"math/rand"
+	"testing"
+)
+
+func makeData(n int) ([]map[*bayesiannetwork.Node]int, *bayesiannetwork.Node) {
+	class := &bayesiannetwork.Node{Name: "class", States: 3}
+	feature := &bayesiannetwork.Node{Name: "feature", States: 2}
+	data := make([]map[*bayesiannetwork.Node]int, n)
+	for i := range data {
+		// classes in the proportion 1:2:3
+		c := 2
+		if i%6 == 0 {
+			c = 0
+		} else if i%6 < 3 {
+			c = 1
+		}
+		data[i] = map[*bayesiannetwork.Node]int{class: c, feature: i % 2}
+	}
+	return data, class
+}
+
+func TestKFold(t *testing.T) {
+	data, _ := makeData(23)
+	folds, err := KFold(data, 5, rand.New(rand.NewSource(1)))
+	if err != nil || len(folds) != 5 {
+		t.Fatal(len(folds), err)
+	}
+
+	for _, fold := range folds {
+		if len(fold.Train)+len(fold.Test) != len(data) {
+			t.Fail()
+		}
+		if len(fold.Test) < 4 || len(fold.Test) > 5 {
+			t.Fail()
+		}
+	}
+	// every instance is tested exactly once
+	count := 0
+	for _, fold := range folds {
+		count += len(fold.Test)
+	}
+	if count != len(data) {
+		t.Fail()
+	}
+
+	if _, err := KFold(data, 0, rand.New(rand.NewSource(1))); err == nil {
+		t.Fatal("expected an error without folds")
+	}
+}
+
+func TestStratifiedKFold(t *testing.T) {
+	data, class := makeData(60)
+	folds, err := StratifiedKFold(data, class, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fold := range folds {
+		counts := make([]int, 3)
+		for _, instance := range fold.Test {
+			counts[instance[class]]++
+		}
+		// 10, 20 and 30 instances of each class are split over 5 folds
+		if counts[0] != 2 || counts[1] != 4 || counts[2] != 6 {
+			t.Error(counts)
+		}
+	}
+
+	if _, err := StratifiedKFold(data, class, -1, rand.New(rand.NewSource(1))); err == nil {
+		t.Fatal("expected an error without folds")
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	data, _ := makeData(10)
+	folds, err := KFold(data, 2, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores, err := CrossValidate(folds, func(fold Fold) (float64, error) {
+		return float64(len(fold.Test)), nil
+	})
+	if err != nil || len(scores) != 2 || scores[0]+scores[1] != 10 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,65 @@
+package evaluation
+
+import (
+	"bayesiannetwork"
+	"fmt"
+	"math"
+)
+
+// probabilities are clipped to [epsilon, 1 - epsilon] by LogLoss so that a
+// single confident mistake doesn't make the loss infinite
+const epsilon = 1e-15
+
+// Counts of classified instances.  Rows are the actual classes and columns
+// the predicted classes.  The same type that Classifier.Evaluate returns,
+// so its metrics are computed in one place.
+type ConfusionMatrix = bayesiannetwork.ConfusionMatrix
+
+// build the confusion matrix of predicted against actual classes, which
+// must be in [0, nClasses)
+func NewConfusionMatrix(actual, predicted []int, nClasses int) (ConfusionMatrix, error) {
+	if len(actual) != len(predicted) {
+		return nil, fmt.Errorf("evaluation: %d actual classes but %d predicted",
+			len(actual), len(predicted))
+	}
+	m := make(ConfusionMatrix, nClasses)
+	for i := range m {
+		m[i] = make([]int, nClasses)
+	}
+	for i := range actual {
+		for _, c := range []int{actual[i], predicted[i]} {
+			if c < 0 || c >= nClasses {
+				return nil, fmt.Errorf("evaluation: class %d of instance %d is out of range [0, %d)",
+					c, i, nClasses)
+			}
+		}
+		m[actual[i]][predicted[i]]++
+	}
+	return m, nil
+}
+
+// the mean negative log probability given to the actual class
+func LogLoss(actual []int, probs [][]float64) float64 {
+	loss := 0.0
+	for i, c := range actual {
+		p := math.Min(math.Max(probs[i][c], epsilon), 1-epsilon)
+		loss -= math.Log(p)
+	}
+	return loss / float64(len(actual))
+}
+
+// the mean squared distance between the predicted distribution and the
+// one-hot encoding of the actual class (the original multi-class definition,
+// which ranges from 0 to 2)
+func BrierScore(actual []int, probs [][]float64) float64 {
+	score := 0.0
+	for i, c := range actual {
+		for k, p := range probs[i] {
+			if k == c {
+				p -= 1
+			}
+			score += p * p
+		}
+	}
+	return score / float64(len(actual))
+}
@@ -0,0 +1,68 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConfusionMatrix(t *testing.T) {
+	actual := []int{0, 0, 0, 1, 1, 2}
+	predicted := []int{0, 0, 1, 1, 2, 2}
+	m, err := NewConfusionMatrix(actual, predicted, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m[0][0] != 2 || m[0][1] != 1 || m[1][2] != 1 {
+		t.Fail()
+	}
+	if math.Abs(m.Accuracy()-4.0/6) > 1e-12 {
+		t.Fail()
+	}
+	// class 1 is predicted twice, correctly once; it occurs twice
+	if m.Precision(1) != .5 || m.Recall(1) != .5 {
+		t.Fail()
+	}
+	// class 0 is always correct when predicted but is missed once
+	if m.Precision(0) != 1 || math.Abs(m.Recall(0)-2.0/3) > 1e-12 {
+		t.Fail()
+	}
+	if math.Abs(m.F1(0)-.8) > 1e-12 {
+		t.Fail()
+	}
+	macro := (m.F1(0) + m.F1(1) + m.F1(2)) / 3
+	if math.Abs(m.MacroF1()-macro) > 1e-12 {
+		t.Fail()
+	}
+
+	if _, err := NewConfusionMatrix(actual, predicted[1:], 3); err == nil {
+		t.Fatal("expected an error for different lengths")
+	}
+	if _, err := NewConfusionMatrix(actual, predicted, 2); err == nil {
+		t.Fatal("expected an error for a class out of range")
+	}
+	if _, err := NewConfusionMatrix([]int{-1}, []int{0}, 2); err == nil {
+		t.Fatal("expected an error for a negative class")
+	}
+}
+
+func TestLogLoss(t *testing.T) {
+	actual := []int{0, 1}
+	probs := [][]float64{{.5, .5}, {.2, .8}}
+	solution := -(math.Log(.5) + math.Log(.8)) / 2
+	if math.Abs(LogLoss(actual, probs)-solution) > 1e-12 {
+		t.Fail()
+	}
+	// a confident mistake is clipped instead of infinite
+	if math.IsInf(LogLoss([]int{0}, [][]float64{{0, 1}}), 0) {
+		t.Fail()
+	}
+}
+
+func TestBrierScore(t *testing.T) {
+	actual := []int{0, 1}
+	probs := [][]float64{{1, 0}, {.5, .5}}
+	if math.Abs(BrierScore(actual, probs)-.25) > 1e-12 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,104 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+// A point on a receiver operating characteristic curve
+type ROCPoint struct {
+	FalsePositiveRate float64
+	TruePositiveRate  float64
+	// instances scoring at least Threshold are predicted positive
+	Threshold float64
+}
+
+// the ROC curve of scores for the positive instances, from the highest
+// threshold (+Inf, where nothing is predicted positive) to the lowest.
+// Instances with tied scores are added together.
+func ROCCurve(positive []bool, scores []float64) []ROCPoint {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	nPositive, nNegative := 0.0, 0.0
+	for _, p := range positive {
+		if p {
+			nPositive++
+		} else {
+			nNegative++
+		}
+	}
+
+	curve := []ROCPoint{{Threshold: math.Inf(1)}}
+	tp, fp := 0.0, 0.0
+	for i, index := range order {
+		if positive[index] {
+			tp++
+		} else {
+			fp++
+		}
+		if i+1 < len(order) && scores[order[i+1]] == scores[index] {
+			continue
+		}
+		point := ROCPoint{Threshold: scores[index]}
+		if nNegative > 0 {
+			point.FalsePositiveRate = fp / nNegative
+		}
+		if nPositive > 0 {
+			point.TruePositiveRate = tp / nPositive
+		}
+		curve = append(curve, point)
+	}
+	return curve
+}
+
+// the area under the ROC curve by the trapezoidal rule
+func AUC(positive []bool, scores []float64) float64 {
+	curve := ROCCurve(positive, scores)
+	area := 0.0
+	for i := 1; i < len(curve); i++ {
+		width := curve[i].FalsePositiveRate - curve[i-1].FalsePositiveRate
+		height := (curve[i].TruePositiveRate + curve[i-1].TruePositiveRate) / 2
+		area += width * height
+	}
+	return area
+}
+
+// the one-vs-rest AUC of every class, scoring instances by the probability
+// of that class.  Classes that never occur (or always occur) have no
+// meaningful AUC and are reported as 0.5.
+func MulticlassAUC(actual []int, probs [][]float64, nClasses int) []float64 {
+	aucs := make([]float64, nClasses)
+	for k := range aucs {
+		positive := make([]bool, len(actual))
+		scores := make([]float64, len(actual))
+		count := 0
+		for i, c := range actual {
+			positive[i] = c == k
+			scores[i] = probs[i][k]
+			if positive[i] {
+				count++
+			}
+		}
+		if count == 0 || count == len(actual) {
+			aucs[k] = .5
+		} else {
+			aucs[k] = AUC(positive, scores)
+		}
+	}
+	return aucs
+}
+
+// the unweighted mean of the one-vs-rest AUCs of all classes
+func MacroAUC(actual []int, probs [][]float64, nClasses int) float64 {
+	total := 0.0
+	for _, auc := range MulticlassAUC(actual, probs, nClasses) {
+		total += auc
+	}
+	return total / float64(nClasses)
+}
@@ -0,0 +1,69 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAUC(t *testing.T) {
+	// perfectly separated
+	positive := []bool{true, true, false, false}
+	if AUC(positive, []float64{.9, .8, .3, .1}) != 1 {
+		t.Fail()
+	}
+	// perfectly wrong
+	if AUC(positive, []float64{.1, .2, .8, .9}) != 0 {
+		t.Fail()
+	}
+	// one of the four positive-negative pairs is misordered
+	if math.Abs(AUC(positive, []float64{.9, .3, .4, .1})-.75) > 1e-12 {
+		t.Fail()
+	}
+	// ties count as half
+	if math.Abs(AUC(positive, []float64{.5, .5, .5, .5})-.5) > 1e-12 {
+		t.Fail()
+	}
+}
+
+func TestROCCurve(t *testing.T) {
+	positive := []bool{true, false, true, false}
+	curve := ROCCurve(positive, []float64{.9, .7, .6, .2})
+	if len(curve) != 5 {
+		t.Fatal(curve)
+	}
+	last := curve[len(curve)-1]
+	if curve[0].TruePositiveRate != 0 || last.TruePositiveRate != 1 ||
+		last.FalsePositiveRate != 1 {
+		t.Fail()
+	}
+	if curve[1].TruePositiveRate != .5 || curve[1].FalsePositiveRate != 0 {
+		t.Fail()
+	}
+	// scores needn't be probabilities
+	curve = ROCCurve(positive, []float64{9, 7, 6, 2})
+	if !math.IsInf(curve[0].Threshold, 1) || curve[1].Threshold != 9 {
+		t.Fatal(curve)
+	}
+}
+
+func TestMulticlassAUC(t *testing.T) {
+	actual := []int{0, 1, 2, 2}
+	probs := [][]float64{
+		{.8, .1, .1, 0},
+		{.1, .8, .1, 0},
+		{.1, .1, .8, 0},
+		{.2, .2, .6, 0}}
+	aucs := MulticlassAUC(actual, probs, 4)
+	for k := 0; k < 3; k++ {
+		if aucs[k] != 1 {
+			t.Fail()
+		}
+	}
+	// class 3 never occurs
+	if aucs[3] != .5 {
+		t.Fail()
+	}
+	if math.Abs(MacroAUC(actual, probs, 3)-1) > 1e-12 {
+		t.Fail()
+	}
+}
@@ -2,96 +2,75 @@ package main
 
 import (
 	"bayesiannetwork"
-	"bufio"
-	"encoding/csv"
+	"discretization"
+	"evaluation"
 	"fmt"
-	"io"
 	"math"
 	"math/rand"
 	"os"
-	"strconv"
 )
 
-func loadData(fname string) [][]float64 {
-	// Load a TXT file.
-	f, _ := os.Open(fname)
-
-	// Create a new reader.
-	r := csv.NewReader(bufio.NewReader(f))
-
-	data := make([][]float64, 0)
-
-	for {
-		record, err := r.Read()
-		// Stop at EOF.
-		if err == io.EOF {
-			break
-		}
-
-		row := make([]float64, 0)
-		for _, value := range record {
-			v, _ := strconv.ParseFloat(value, 64)
-			row = append(row, v)
-		}
-		data = append(data, row)
+var featureNames = []string{
+	"sepal_length",
+	"sepal_width",
+	"petal_length",
+	"petal_width",
+	"species"}
+
+func loadData(fname string) (*bayesiannetwork.Table, error) {
+	// Load a CSV file without a header; the measurements must be numbers so
+	// that a malformed value is reported instead of becoming a category
+	table, err := bayesiannetwork.LoadCSV(fname, bayesiannetwork.CSVOptions{
+		Header:      bayesiannetwork.HeaderAbsent,
+		Numeric:     []string{"0", "1", "2", "3"},
+		Categorical: []string{"4"}})
+	if err != nil {
+		return nil, err
 	}
-	return data
-
+	for i, c := range table.Columns {
+		c.Name = featureNames[i]
+	}
+	return table, nil
 }
 
 func main() {
 
-	data := loadData("iris.data")
-	// bin the data -- bayesiannetwork requires discrete statesw
-	nCols := len(data[0])
-	max := make([]float64, nCols)
-	min := make([]float64, nCols)
-
-	for c := 0; c < nCols; c++ {
-		min[c] = math.MaxFloat64
+	table, err := loadData("iris.data")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-
-	for _, row := range data {
-		for i, v := range row {
-			if max[i] < v {
-				max[i] = v
-			}
-			if min[i] > v {
-				min[i] = v
-			}
+	// bin the data -- bayesiannetwork requires discrete states.  The
+	// categorical species column already has states.
+	nBins := 3
+	for _, column := range table.Columns {
+		if column.Categorical() {
+			continue
 		}
-	}
-
-	// discritize the features
-	nBins := 3.0
-	discritized := make([][]int, 0)
-
-	for i := 0; i < len(data); i++ {
-		discritized = append(discritized, make([]int, nCols))
-	}
-	for c := 0; c < nCols; c++ {
-		for i, row := range data {
-			bin := math.Floor(nBins * (row[c] - min[c]) / (max[c] - min[c]))
-			bin = math.Min(nBins-1, bin)
-			discritized[i][c] = int(bin)
+		binning, err := discretization.EqualWidth(column.Values, nBins)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := binning.Discretize(column); err != nil {
+			fmt.Println(err)
+			return
 		}
 	}
-
-	featureNames := []string{
-		"sepal_length",
-		"sepal_width",
-		"petal_length",
-		"petal_width",
-		"species"}
+	dataset, err := table.Dataset()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	// make the data set into a []map[*node]int
-	converted := bayesiannetwork.ConvertDataset(discritized, featureNames)
+	converted := dataset.Instances()
 
 	// Typically, we would split this data set into a test and train set but
 	// this is such a small data set that not all states would be observed in
 	// the BN and we wouldn't be able to make inferences.
 	// split the data into a training and test set
-	order := rand.Perm(len(data))
+	order := rand.Perm(len(converted))
 	train := make([]map[*bayesiannetwork.Node]int, 0)
 	test := make([]map[*bayesiannetwork.Node]int, 0)
 	for i, index := range order {
@@ -113,6 +92,23 @@ func main() {
 	}
 	fmt.Println()
 
+	// draw the topology with the CPDs and the strength of each edge; render
+	// it with e.g. dot -Tpng iris.dot -o iris.png
+	f, err := os.Create("iris.dot")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = inferred.WriteDOT(f, bayesiannetwork.GraphOptions{
+		CPDs:     true,
+		Strength: bayesiannetwork.MutualInformationStrength,
+		Data:     dataset})
+	f.Close()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	mn := math.MaxFloat64
 	for _, v := range inferred.Likelihood(train) {
 		if v < mn {
@@ -122,74 +118,98 @@ func main() {
 	modelLL := inferred.ModelLikelihood(train)
 	fmt.Println("Model log likelihood", modelLL)
 
-	// get the decision matrix
-	source := rand.NewSource(1)
-	r := rand.New(source)
-
-	// get the class node
-	var classNode *bayesiannetwork.Node
-	for n, _ := range train[0] {
-		if n.Name == featureNames[len(featureNames)-1] {
-			classNode = n
-			break
-		}
+	// get the class node and the petal width node
+	nodes, err := inferred.NodesNamed(featureNames[len(featureNames)-1], "petal_width")
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	//initialize decision matrix
-	confusionMatrix := make([][]int, int(nBins))
-	for i := 0; i < int(nBins); i++ {
-		confusionMatrix[i] = make([]int, int(nBins))
+	classNode, petalWidth := nodes[0], nodes[1]
+
+	// query the species of a flower with the narrowest petals by name and
+	// label
+	narrowest := petalWidth.StateLabel(0)
+	answer, err := inferred.Query(bayesiannetwork.Query{
+		Targets:  []string{classNode.Name},
+		Evidence: map[string]string{"petal_width": narrowest}})
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
+	fmt.Printf("P(species | petal_width %s): %v\n", narrowest, answer.Posteriors[classNode.Name])
+	fmt.Println()
 
-	// classify the instances (first removing the class feature)
-	for _, instance := range train {
-		// copy the instance except the class feature
-		classless := make(map[*bayesiannetwork.Node]int)
-		for k, v := range instance {
-			if k.Name != featureNames[len(featureNames)-1] {
-				classless[k] = v
-			}
-		}
-
-		dist := inferred.PosteriorDistribution(
-			r,
-			[]*bayesiannetwork.Node{classNode},
-			classless)
-
-		// argmax of dist / update decision matrix
-		actual := instance[classNode]
-
-		mx := 0.0
-		mxState := 0
-		for k, v := range dist[classNode].StateMap {
-			if v > mx {
-				mx = v
-				mxState = k
-			}
-		}
-		predicted := mxState
-
-		confusionMatrix[actual][predicted] += 1
+	// classify the instances; the class feature of each instance is ignored
+	// when predicting
+	classifier := bayesiannetwork.NewClassifier(inferred, classNode)
+	matrix, err := classifier.Evaluate(train)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
 
 	// print the confusion matrix
 	fmt.Println("confusionMatrix:")
-	for _, row := range confusionMatrix {
+	for _, row := range matrix {
 		fmt.Println(row)
 	}
+	precision := make([]float64, len(matrix))
+	recall := make([]float64, len(matrix))
+	for k := range matrix {
+		precision[k], recall[k] = matrix.Precision(k), matrix.Recall(k)
+	}
+	fmt.Println("precision: ", precision)
+	fmt.Println("recall: ", recall)
+	fmt.Println()
 
-	// calculate the precision per class
-	precision := make([]float64, int(nBins))
-	for ci := 0; ci < len(confusionMatrix); ci++ {
-		tp := float64(confusionMatrix[ci][ci])
-		tpfp := 0.0
-		for ri := 0; ri < len(confusionMatrix); ri++ {
-			tpfp += float64(confusionMatrix[ci][ri])
+	// estimate how well a naive Bayes classifier does on unseen data with
+	// stratified cross validation.  The States of the Nodes are set from all
+	// of the data so that every fold knows about every State. NOTE: this
+	// replaces the inferred topology since the Nodes are shared.
+	naiveBayes := bayesiannetwork.NewClassifier(
+		bayesiannetwork.NaiveBayes(converted, classNode), classNode)
+	folds, err := evaluation.StratifiedKFold(
+		converted, classNode, 5, rand.New(rand.NewSource(1)))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	actual := make([]int, 0)
+	probs := make([][]float64, 0)
+	for _, fold := range folds {
+		naiveBayes.Fit(fold.Train)
+		p, err := naiveBayes.PredictProba(fold.Test)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		for i, instance := range fold.Test {
+			actual = append(actual, instance[classNode])
+			probs = append(probs, p[i])
 		}
-		precision[ci] = tp / tpfp
 	}
-	fmt.Println("precision: ", precision)
+	predicted := make([]int, len(actual))
+	for i, p := range probs {
+		for k := range p {
+			if p[k] > p[predicted[i]] {
+				predicted[i] = k
+			}
+		}
+	}
+	cv, err := evaluation.NewConfusionMatrix(actual, predicted, classNode.States)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("naive Bayes 5-fold cross validation:")
+	fmt.Println("accuracy: ", cv.Accuracy())
+	fmt.Println("macro F1: ", cv.MacroF1())
+	fmt.Println("macro AUC: ",
+		evaluation.MacroAUC(actual, probs, classNode.States))
+	fmt.Println("log loss: ", evaluation.LogLoss(actual, probs))
 }
 
 // Discussion:
-// For a classification problem like this, it would be better to replace the InferBayesianNetwork function with a function that evaluates the model based on how well the class is predicted instead of general model likelihood.  You would also typically use a separate train and testing set.
+// For a classification problem like this, it would be better to use InferClassifierNetwork, which evaluates the model based on how well the class is predicted instead of general model likelihood.  You would also typically use a separate train and testing set.
 // There are tradeoffs in using a discrete bayesian network such as this.  On the one hand, the model can approximate arbitrary distributions with multinomials with increasing numbers of buckets. On the other hand, as the number of buckets increases, the number of observations necessary to support the increased complexity grows very quickly.  A continuous bayesian network might reduce the number of necessary observations by offloading some of the intelligence into the distribution types used.
@@ -1,9 +1,23 @@
 package geneticalgorithm
 
 import (
+	"context"
 	"math/rand"
+	"time"
 )
 
+// Progress is reported to an observer after every iteration of the search
+type Progress struct {
+	Iteration int
+	// BestScore is the score of Best
+	BestScore float64
+	// Best is a copy of the best individual found so far
+	Best []int
+	// Accepted is the number of mutations that replaced the best so far
+	Accepted int
+	Elapsed  time.Duration
+}
+
 // randomly perturb the current best and update it when it reduces the score
 func Evolve(
 	scoreFunction func([]int) float64,
@@ -12,24 +26,72 @@ func Evolve(
 	iterations int,
 	minimize bool) []int {
 
+	best, _ := EvolveContext(
+		context.Background(),
+		scoreFunction,
+		mutateCheckFunction,
+		genomeSize,
+		iterations,
+		minimize,
+		nil)
+	return best
+}
+
+// Evolve with cancellation and progress reporting.  observe is called after
+// every iteration (when it is not nil) and stops the search early by returning
+// false.  The best individual found so far is always returned; the error is
+// the context's error when ctx is done before the search finishes.
+func EvolveContext(
+	ctx context.Context,
+	scoreFunction func([]int) float64,
+	mutateCheckFunction func(bits []int) bool,
+	genomeSize int,
+	iterations int,
+	minimize bool,
+	observe func(Progress) bool) ([]int, error) {
+
+	start := time.Now()
 	//	check := make([]int, genomeSize)
 	best := mutate(make([]int, genomeSize), mutateCheckFunction)
+	if err := ctx.Err(); err != nil {
+		return best, err
+	}
 	value := scoreFunction(best)
 	bestScore := value
+	accepted := 0
 
 	for i := 0; i < iterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return best, err
+		}
 		child := mutate(best, mutateCheckFunction)
 		score := scoreFunction(child)
 		if minimize && score < bestScore {
 			bestScore = score
 			best = child
+			accepted++
 		} else if !minimize && score > bestScore {
 			bestScore = score
 			best = child
+			accepted++
+		}
+
+		if observe != nil {
+			snapshot := make([]int, len(best))
+			copy(snapshot, best)
+			progress := Progress{
+				Iteration: i,
+				BestScore: bestScore,
+				Best:      snapshot,
+				Accepted:  accepted,
+				Elapsed:   time.Since(start)}
+			if !observe(progress) {
+				break
+			}
 		}
 	}
 
-	return best
+	return best, nil
 }
 
 // randomly perturb the individual a random amount
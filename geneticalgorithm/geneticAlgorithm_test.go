@@ -1,7 +1,10 @@
 package geneticalgorithm
 
-import "testing"
-import "math/rand"
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
 
 func scoreFunction(b []int) float64 {
 	solution := []int{0, 1, 1, 1, 0}
@@ -30,3 +33,29 @@ func TestEvolve(t *testing.T) {
 		}
 	}
 }
+
+func TestEvolveContext(t *testing.T) {
+	rand.Seed(1)
+	iterations := 0
+	observe := func(p Progress) bool {
+		iterations++
+		if len(p.Best) != 5 {
+			t.Fail()
+		}
+		// stop as soon as the solution is found
+		return p.BestScore > 0
+	}
+	best, err := EvolveContext(context.Background(), scoreFunction,
+		mutateCheckFunction, 5, 100, true, observe)
+	if err != nil || scoreFunction(best) != 0 || iterations == 100 {
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = EvolveContext(ctx, scoreFunction, mutateCheckFunction, 5, 100,
+		true, nil)
+	if err != context.Canceled {
+		t.Fail()
+	}
+}